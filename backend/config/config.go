@@ -7,7 +7,11 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/jimsyyap/auctions/backend/models"
 	"github.com/joho/godotenv"
 )
 
@@ -16,7 +20,7 @@ func LoadEnv() {
 	// Get the current file's directory
 	_, filename, _, _ := runtime.Caller(0)
 	dir := filepath.Dir(filename)
-	
+
 	// Load .env file from the root directory
 	err := godotenv.Load(filepath.Join(dir, "..", ".env"))
 	if err != nil {
@@ -73,6 +77,314 @@ func GetServerConfig() *ServerConfig {
 	}
 }
 
+// AuthConfig holds token lifetimes used by the authentication service
+type AuthConfig struct {
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+// GetAuthConfig returns authentication configuration from environment variables
+func GetAuthConfig() *AuthConfig {
+	return &AuthConfig{
+		AccessTokenTTL:  getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+	}
+}
+
+// JWTKeyConfig describes one signing key. For HS256 Source is the shared
+// secret; for RS256 and EdDSA it is the path to a PEM file holding either a
+// private key (sign and verify) or a public key (verify only).
+type JWTKeyConfig struct {
+	ID        string
+	Algorithm string
+	Source    string
+}
+
+// JWTConfig holds token signing configuration
+type JWTConfig struct {
+	Issuer      string
+	Audience    string
+	ActiveKeyID string
+	Keys        []JWTKeyConfig
+}
+
+// GetJWTConfig returns JWT configuration from environment variables.
+// JWT_KEYS is a comma-separated list of kid=ALG:source entries, e.g.
+// "2025-01=RS256:/etc/auctions/jwt-2025-01.pem,legacy=HS256:oldsecret".
+// When JWT_KEYS is unset, JWT_SECRET is used as a single HS256 key.
+func GetJWTConfig() (*JWTConfig, error) {
+	cfg := &JWTConfig{
+		Issuer:      getEnv("JWT_ISSUER", "auctions-api"),
+		Audience:    getEnv("JWT_AUDIENCE", "auctions-web"),
+		ActiveKeyID: getEnv("JWT_ACTIVE_KID", ""),
+	}
+
+	rawKeys := getEnv("JWT_KEYS", "")
+	if rawKeys == "" {
+		if secret := getEnv("JWT_SECRET", ""); secret != "" {
+			cfg.Keys = append(cfg.Keys, JWTKeyConfig{ID: "default", Algorithm: "HS256", Source: secret})
+		}
+	}
+
+	for _, entry := range strings.Split(rawKeys, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kid, rest, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid JWT_KEYS entry %q: expected kid=ALG:source", entry)
+		}
+		alg, source, ok := strings.Cut(rest, ":")
+		if !ok || kid == "" || source == "" {
+			return nil, fmt.Errorf("invalid JWT_KEYS entry %q: expected kid=ALG:source", entry)
+		}
+		cfg.Keys = append(cfg.Keys, JWTKeyConfig{ID: kid, Algorithm: alg, Source: source})
+	}
+
+	// Default to the first configured key for signing
+	if cfg.ActiveKeyID == "" && len(cfg.Keys) > 0 {
+		cfg.ActiveKeyID = cfg.Keys[0].ID
+	}
+
+	return cfg, nil
+}
+
+// AppConfig holds settings about how the application is reached by users
+type AppConfig struct {
+	FrontendURL string
+	APIURL      string
+}
+
+// GetAppConfig returns application configuration from environment variables
+func GetAppConfig() *AppConfig {
+	return &AppConfig{
+		FrontendURL: getEnv("FRONTEND_URL", "http://localhost:3000"),
+		APIURL:      getEnv("API_URL", "http://localhost:8080"),
+	}
+}
+
+// StorageConfig holds file storage locations. Private files (such as
+// identity documents) are never served directly; public files are served
+// under PublicURLPath.
+type StorageConfig struct {
+	PrivateDir    string
+	PublicDir     string
+	PublicURLPath string
+}
+
+// GetStorageConfig returns storage configuration from environment variables
+func GetStorageConfig() *StorageConfig {
+	return &StorageConfig{
+		PrivateDir:    getEnv("STORAGE_PRIVATE_DIR", "uploads/private"),
+		PublicDir:     getEnv("STORAGE_PUBLIC_DIR", "uploads/public"),
+		PublicURLPath: getEnv("STORAGE_PUBLIC_URL_PATH", "/uploads"),
+	}
+}
+
+// MarketplaceConfig holds marketplace business rules
+type MarketplaceConfig struct {
+	// Listings priced above this need a verified seller; 0 disables the check
+	UnverifiedSellerMaxPrice models.Money
+	// How long after a sale completes the buyer and seller can rate each other
+	FeedbackWindow time.Duration
+	// How many questions one user can ask about one listing per day
+	QuestionsPerListingPerDay int
+	// Share of the item price the platform takes from the seller when no
+	// final value fee rule matches the sale
+	PlatformFeeRate float64
+	// Sales tax charged to the buyer on the item and shipping
+	SalesTaxRate float64
+	// How often sellers are paid the proceeds of their settled sales
+	PayoutInterval time.Duration
+	// How long after shipping an escrow payment is released to the seller
+	// if the buyer neither confirms delivery nor opens a dispute
+	EscrowReleaseAfter time.Duration
+	// How long after a sale completes the buyer can still open a dispute
+	DisputeWindow time.Duration
+	// How long a seller has to respond to a dispute before it goes to an admin
+	DisputeResponseWindow time.Duration
+}
+
+// GetMarketplaceConfig returns marketplace configuration from environment variables
+func GetMarketplaceConfig() *MarketplaceConfig {
+	return &MarketplaceConfig{
+		UnverifiedSellerMaxPrice:  getEnvMoney("UNVERIFIED_SELLER_MAX_PRICE", "1000"),
+		FeedbackWindow:            getEnvDuration("FEEDBACK_WINDOW", 60*24*time.Hour),
+		QuestionsPerListingPerDay: getEnvInt("QUESTIONS_PER_LISTING_PER_DAY", 3),
+		PlatformFeeRate:           getEnvFloat("PLATFORM_FEE_RATE", 0.05),
+		SalesTaxRate:              getEnvFloat("SALES_TAX_RATE", 0),
+		PayoutInterval:            getEnvDuration("PAYOUT_INTERVAL", 24*time.Hour),
+		EscrowReleaseAfter:        getEnvDuration("ESCROW_RELEASE_AFTER", 14*24*time.Hour),
+		DisputeWindow:             getEnvDuration("DISPUTE_WINDOW", 30*24*time.Hour),
+		DisputeResponseWindow:     getEnvDuration("DISPUTE_RESPONSE_WINDOW", 3*24*time.Hour),
+	}
+}
+
+// ReputationConfig holds the Bayesian prior used to smooth rating scores.
+// A user's score starts at PriorMean and moves towards their own average
+// as they collect more than PriorWeight ratings.
+type ReputationConfig struct {
+	PriorMean   float64
+	PriorWeight float64
+}
+
+// GetReputationConfig returns reputation configuration from environment variables
+func GetReputationConfig() *ReputationConfig {
+	return &ReputationConfig{
+		PriorMean:   getEnvFloat("REPUTATION_PRIOR_MEAN", 3.5),
+		PriorWeight: getEnvFloat("REPUTATION_PRIOR_WEIGHT", 10),
+	}
+}
+
+// AccountConfig holds account lifecycle settings
+type AccountConfig struct {
+	// How long a deletion request can be cancelled before it is carried out
+	DeletionCoolingOff time.Duration
+}
+
+// GetAccountConfig returns account lifecycle configuration from environment variables
+func GetAccountConfig() *AccountConfig {
+	return &AccountConfig{
+		DeletionCoolingOff: getEnvDuration("ACCOUNT_DELETION_COOLING_OFF", 14*24*time.Hour),
+	}
+}
+
+// ModerationConfig holds settings for the content moderation checks
+type ModerationConfig struct {
+	// Domains that links may point to without being treated as suspicious,
+	// in addition to the frontend's own domain
+	AllowedLinkDomains []string
+}
+
+// GetModerationConfig returns moderation configuration from environment variables
+func GetModerationConfig() *ModerationConfig {
+	var domains []string
+	for _, domain := range strings.Split(getEnv("MODERATION_ALLOWED_LINK_DOMAINS", ""), ",") {
+		if domain = strings.ToLower(strings.TrimSpace(domain)); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	return &ModerationConfig{
+		AllowedLinkDomains: domains,
+	}
+}
+
+// PaymentConfig selects the payment gateway. Only the fake gateway, which
+// never moves real money, is available so far.
+type PaymentConfig struct {
+	Provider      string
+	WebhookSecret string
+	// Webhooks signed longer ago than this are rejected as replays
+	WebhookTolerance time.Duration
+}
+
+// GetPaymentConfig returns payment configuration from environment variables
+func GetPaymentConfig() *PaymentConfig {
+	return &PaymentConfig{
+		Provider:         getEnv("PAYMENT_PROVIDER", "fake"),
+		WebhookSecret:    getEnv("PAYMENT_WEBHOOK_SECRET", ""),
+		WebhookTolerance: getEnvDuration("PAYMENT_WEBHOOK_TOLERANCE", 5*time.Minute),
+	}
+}
+
+// ExchangeRateConfig sets where exchange rates come from. Without a feed
+// URL, admins maintain the rates by hand.
+type ExchangeRateConfig struct {
+	// A JSON document such as {"base": "USD", "rates": {"EUR": 0.92}}
+	FeedURL         string
+	RefreshInterval time.Duration
+}
+
+// GetExchangeRateConfig returns exchange rate configuration from environment variables
+func GetExchangeRateConfig() *ExchangeRateConfig {
+	return &ExchangeRateConfig{
+		FeedURL:         getEnv("EXCHANGE_RATES_URL", ""),
+		RefreshInterval: getEnvDuration("EXCHANGE_RATES_REFRESH_INTERVAL", 6*time.Hour),
+	}
+}
+
+// EmailConfig holds outgoing mail settings. When SMTPHost is empty, emails
+// are written to the log instead of being sent.
+type EmailConfig struct {
+	SMTPHost string
+	SMTPPort string
+	Username string
+	Password string
+	From     string
+}
+
+// GetEmailConfig returns email configuration from environment variables
+func GetEmailConfig() *EmailConfig {
+	return &EmailConfig{
+		SMTPHost: getEnv("SMTP_HOST", ""),
+		SMTPPort: getEnv("SMTP_PORT", "587"),
+		Username: getEnv("SMTP_USERNAME", ""),
+		Password: getEnv("SMTP_PASSWORD", ""),
+		From:     getEnv("EMAIL_FROM", "no-reply@auctionhub.local"),
+	}
+}
+
+// SecurityConfig holds account security policy settings
+type SecurityConfig struct {
+	TOTPIssuer                string
+	RequireTwoFactorForAdmins bool
+	TwoFactorSalesThreshold   models.Money // 0 disables the seller requirement
+
+	// Login brute-force protection
+	MaxFailedLoginsPerUser int
+	MaxFailedLoginsPerIP   int
+	LoginFailureWindow     time.Duration
+	LoginLockoutDuration   time.Duration
+}
+
+// GetSecurityConfig returns security policy configuration from environment variables
+func GetSecurityConfig() *SecurityConfig {
+	return &SecurityConfig{
+		TOTPIssuer:                getEnv("TOTP_ISSUER", "AuctionHub"),
+		RequireTwoFactorForAdmins: getEnvBool("REQUIRE_2FA_FOR_ADMINS", true),
+		TwoFactorSalesThreshold:   getEnvMoney("REQUIRE_2FA_SALES_THRESHOLD", "10000"),
+		MaxFailedLoginsPerUser:    getEnvInt("LOGIN_MAX_FAILURES_PER_USER", 5),
+		MaxFailedLoginsPerIP:      getEnvInt("LOGIN_MAX_FAILURES_PER_IP", 20),
+		LoginFailureWindow:        getEnvDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+		LoginLockoutDuration:      getEnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+	}
+}
+
+// OIDCProviderConfig describes an OpenID Connect identity provider. Any
+// standards-compliant issuer works, including a local mock server in tests.
+type OIDCProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// GetOIDCProviders returns the providers listed in OIDC_PROVIDERS. Each name
+// is configured with OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET,
+// _REDIRECT_URL and optionally _SCOPES.
+func GetOIDCProviders() []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+	for _, name := range strings.Split(getEnv("OIDC_PROVIDERS", ""), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		providers = append(providers, OIDCProviderConfig{
+			Name:         strings.ToLower(name),
+			Issuer:       getEnv(prefix+"ISSUER", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  getEnv(prefix+"REDIRECT_URL", GetAppConfig().FrontendURL+"/auth/callback/"+strings.ToLower(name)),
+			Scopes:       strings.Fields(getEnv(prefix+"SCOPES", "openid email profile")),
+		})
+	}
+	return providers
+}
+
 // Helper function to get environment variable with fallback
 func getEnv(key, fallback string) string {
 	if value, exists := os.LookupEnv(key); exists {
@@ -80,3 +392,71 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// Helper function to get a duration environment variable (e.g. "15m") with fallback
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Warning: invalid duration for %s: %v", key, err)
+		return fallback
+	}
+	return d
+}
+
+// Helper function to get a boolean environment variable with fallback
+func getEnvBool(key string, fallback bool) bool {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Warning: invalid boolean for %s: %v", key, err)
+		return fallback
+	}
+	return b
+}
+
+// Helper function to get an integer environment variable with fallback
+func getEnvInt(key string, fallback int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Warning: invalid integer for %s: %v", key, err)
+		return fallback
+	}
+	return i
+}
+
+// Helper function to get a numeric environment variable with fallback
+func getEnvFloat(key string, fallback float64) float64 {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Warning: invalid number for %s: %v", key, err)
+		return fallback
+	}
+	return f
+}
+
+// Helper function to get an amount in the default currency (e.g. "1000.00")
+// from an environment variable with fallback
+func getEnvMoney(key, fallback string) models.Money {
+	value := getEnv(key, fallback)
+	m, err := models.ParseMoney(value, models.DefaultCurrency)
+	if err != nil {
+		log.Printf("Warning: invalid amount for %s: %v", key, err)
+		m, _ = models.ParseMoney(fallback, models.DefaultCurrency)
+	}
+	return m
+}
@@ -18,11 +18,53 @@ func Migrate() {
         &models.Category{},
         &models.Image{},
         &models.Rating{},
+        &models.RefreshToken{},
+        &models.OneTimeToken{},
+        &models.RecoveryCode{},
+        &models.LoginThrottle{},
+        &models.ExternalIdentity{},
+        &models.OIDCState{},
+        &models.Role{},
+        &models.Permission{},
+        &models.Notification{},
+        &models.UserVerification{},
+        &models.VerificationDocument{},
+        &models.Session{},
+        &models.UserAddress{},
+        &models.UserReputation{},
+        &models.ReputationDaily{},
+        &models.MessageThread{},
+        &models.Message{},
+        &models.MessageAttachment{},
+        &models.ListingQuestion{},
+        &models.ModerationRule{},
+        &models.BannedWord{},
+        &models.ModerationDecision{},
+        &models.Transaction{},
+        &models.Payment{},
+        &models.PaymentEvent{},
+        &models.ExchangeRate{},
+        &models.FeeRule{},
+        &models.LedgerAccount{},
+        &models.LedgerEntry{},
+        &models.LedgerPosting{},
+        &models.Payout{},
+        &models.Dispute{},
+        &models.DisputeMessage{},
+        &models.DisputeEvidence{},
+        &models.SystemSetting{},
+        &models.Invoice{},
+        &models.InvoiceSequence{},
     )
     
     if err != nil {
         log.Fatalf("Failed to migrate database: %v", err)
     }
+
+    SeedRoles()
+    SeedModerationRules()
+    BackfillReputation()
+    BackfillMoney()
     
     log.Println("Database migration completed")
 }
@@ -0,0 +1,177 @@
+// database/seed.go
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+)
+
+// SeedModerationRules creates the default moderation rules that do not
+// exist yet. Rules admins have changed are left alone.
+func SeedModerationRules() {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		for contentType, actions := range models.DefaultModerationRules {
+			for check, action := range actions {
+				rule := models.ModerationRule{}
+				err := tx.Where(models.ModerationRule{ContentType: contentType, Check: check}).
+					Attrs(models.ModerationRule{Action: action, Enabled: true}).
+					FirstOrCreate(&rule).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		log.Fatalf("Failed to seed moderation rules: %v", err)
+	}
+}
+
+// SeedRoles makes sure the built-in roles and their permissions exist and
+// that legacy IsAdmin accounts hold the admin role
+func SeedRoles() {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		for roleName, permNames := range models.DefaultRolePermissions {
+			var role models.Role
+			if err := tx.Where(models.Role{Name: roleName}).FirstOrCreate(&role).Error; err != nil {
+				return err
+			}
+
+			var perms []models.Permission
+			for _, permName := range permNames {
+				var perm models.Permission
+				if err := tx.Where(models.Permission{Name: permName}).FirstOrCreate(&perm).Error; err != nil {
+					return err
+				}
+				perms = append(perms, perm)
+			}
+
+			// Only add missing permissions so admin customisations are kept
+			if err := tx.Model(&role).Association("Permissions").Append(perms); err != nil {
+				return err
+			}
+		}
+
+		var adminRole models.Role
+		if err := tx.Where("name = ?", models.RoleAdmin).First(&adminRole).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`
+			INSERT INTO user_roles (user_id, role_id)
+			SELECT id, ? FROM users
+			WHERE is_admin = true AND deleted_at IS NULL
+			ON CONFLICT DO NOTHING`, adminRole.ID).Error
+	})
+
+	if err != nil {
+		log.Fatalf("Failed to seed roles: %v", err)
+	}
+}
+
+// BackfillReputation fills in the role of ratings created before ratings
+// recorded it, and builds the reputation aggregates from existing ratings
+// the first time they are needed. Afterwards the aggregates are maintained
+// as ratings are added.
+func BackfillReputation() {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`
+			UPDATE ratings SET rated_as = CASE WHEN ratings.rated_user_id = listings.user_id THEN ? ELSE ? END
+			FROM listings
+			WHERE listings.id = ratings.listing_id AND (ratings.rated_as IS NULL OR ratings.rated_as = '')`,
+			models.RatedAsSeller, models.RatedAsBuyer).Error; err != nil {
+			return err
+		}
+
+		var existing int64
+		if err := tx.Model(&models.UserReputation{}).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return nil
+		}
+
+		const sums = `
+			COUNT(*) FILTER (WHERE score >= 4),
+			COUNT(*) FILTER (WHERE score = 3),
+			COUNT(*) FILTER (WHERE score <= 2),
+			COALESCE(SUM(score), 0)`
+
+		if err := tx.Exec(`
+			INSERT INTO user_reputations (user_id, role, positive, neutral, negative, score_sum, updated_at)
+			SELECT rated_user_id, rated_as,` + sums + `, NOW()
+			FROM ratings WHERE deleted_at IS NULL AND rated_as <> ''
+			GROUP BY rated_user_id, rated_as`).Error; err != nil {
+			return err
+		}
+
+		return tx.Exec(`
+			INSERT INTO reputation_daily (user_id, role, day, positive, neutral, negative, score_sum)
+			SELECT rated_user_id, rated_as, DATE(created_at AT TIME ZONE 'UTC'),` + sums + `
+			FROM ratings WHERE deleted_at IS NULL AND rated_as <> '' AND created_at >= NOW() - INTERVAL '366 days'
+			GROUP BY rated_user_id, rated_as, DATE(created_at AT TIME ZONE 'UTC')`).Error
+	})
+
+	if err != nil {
+		log.Fatalf("Failed to backfill reputation: %v", err)
+	}
+}
+
+// BackfillMoney moves prices that were stored as floating point numbers
+// into the Money columns that replaced them, then drops the old columns.
+// Those prices were all dollars with cents. Once the old columns are gone
+// it does nothing.
+func BackfillMoney() {
+	oldColumns := []struct{ table, column string }{
+		{"listings", "start_price"},
+		{"listings", "reserve_price"},
+		{"listings", "buy_now_price"},
+		{"listings", "shipping_price"},
+		{"bids", "amount"},
+		{"transactions", "item_price"},
+		{"transactions", "shipping_cost"},
+		{"transactions", "platform_fee"},
+		{"transactions", "tax_amount"},
+		{"transactions", "amount"},
+		{"payments", "amount"},
+	}
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		for _, old := range oldColumns {
+			if !migrator.HasColumn(old.table, old.column) {
+				continue
+			}
+			currency := fmt.Sprintf("'%s'", models.DefaultCurrency)
+			if migrator.HasColumn(old.table, "currency") {
+				currency = "currency"
+			}
+			if err := tx.Exec(fmt.Sprintf(
+				"UPDATE %[1]s SET %[2]s_minor = ROUND(COALESCE(%[2]s, 0) * 100), %[2]s_currency = %[3]s",
+				old.table, old.column, currency)).Error; err != nil {
+				return err
+			}
+			if err := migrator.DropColumn(old.table, old.column); err != nil {
+				return err
+			}
+		}
+
+		// Transactions and payments kept their currency in its own column
+		for _, table := range []string{"transactions", "payments"} {
+			if migrator.HasColumn(table, "currency") {
+				if err := migrator.DropColumn(table, "currency"); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		log.Fatalf("Failed to backfill money columns: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+// handlers/account_handler.go
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimsyyap/auctions/backend/services"
+)
+
+type AccountHandler struct {
+	accountService *services.AccountService
+}
+
+func NewAccountHandler(accountService *services.AccountService) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+	}
+}
+
+// Export downloads a zip archive of the logged-in user's data
+func (h *AccountHandler) Export(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	// Build the archive first so a failure can still be reported as JSON
+	var buf bytes.Buffer
+	if err := h.accountService.Export(userID.(uint), &buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export account data"})
+		return
+	}
+
+	filename := fmt.Sprintf("account-export-%s.zip", time.Now().Format("2006-01-02"))
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
+
+// GetDeletion shows whether the logged-in user's account is due for deletion
+func (h *AccountHandler) GetDeletion(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	status, err := h.accountService.GetDeletionStatus(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get deletion status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// RequestDeletion schedules the logged-in user's account for deletion
+func (h *AccountHandler) RequestDeletion(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status, err := h.accountService.RequestDeletion(userID.(uint), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrOpenAuctions) || errors.Is(err, services.ErrOpenTransactions) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, status)
+}
+
+// CancelDeletion withdraws the logged-in user's deletion request
+func (h *AccountHandler) CancelDeletion(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := h.accountService.CancelDeletion(userID.(uint)); err != nil {
+		if errors.Is(err, services.ErrDeletionNotRequested) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel deletion"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account deletion cancelled"})
+}
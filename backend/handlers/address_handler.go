@@ -0,0 +1,167 @@
+// handlers/address_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimsyyap/auctions/backend/services"
+)
+
+type AddressHandler struct {
+	addressService *services.AddressService
+}
+
+func NewAddressHandler(addressService *services.AddressService) *AddressHandler {
+	return &AddressHandler{
+		addressService: addressService,
+	}
+}
+
+// GetAddresses lists the logged-in user's addresses
+func (h *AddressHandler) GetAddresses(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	addresses, err := h.addressService.GetAddresses(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get addresses"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"addresses": addresses})
+}
+
+// GetAddress shows one of the logged-in user's addresses
+func (h *AddressHandler) GetAddress(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address ID"})
+		return
+	}
+
+	address, err := h.addressService.GetAddress(userID.(uint), uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, address)
+}
+
+// CreateAddress saves a new address for the logged-in user
+func (h *AddressHandler) CreateAddress(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.AddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	address, err := h.addressService.CreateAddress(userID.(uint), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, address)
+}
+
+// UpdateAddress changes one of the logged-in user's addresses
+func (h *AddressHandler) UpdateAddress(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address ID"})
+		return
+	}
+
+	var req services.AddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	address, err := h.addressService.UpdateAddress(userID.(uint), uint(id), &req)
+	if err != nil {
+		respondAddressError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, address)
+}
+
+// SetDefault makes one of the logged-in user's addresses the default
+func (h *AddressHandler) SetDefault(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address ID"})
+		return
+	}
+
+	address, err := h.addressService.SetDefault(userID.(uint), uint(id))
+	if err != nil {
+		respondAddressError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, address)
+}
+
+// DeleteAddress removes one of the logged-in user's addresses
+func (h *AddressHandler) DeleteAddress(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address ID"})
+		return
+	}
+
+	if err := h.addressService.DeleteAddress(userID.(uint), uint(id)); err != nil {
+		respondAddressError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Address deleted successfully"})
+}
+
+// respondAddressError maps address service errors to HTTP responses
+func respondAddressError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrAddressNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
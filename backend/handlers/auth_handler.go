@@ -2,7 +2,11 @@
 package handlers
 
 import (
+	"errors"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jimsyyap/auctions/backend/services"
@@ -26,7 +30,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.authService.Register(&req)
+	resp, err := h.authService.Register(&req, clientInfo(c))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -43,28 +47,168 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.authService.Login(&req)
+	resp, challenge, err := h.authService.Login(&req, clientInfo(c))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		respondLoginError(c, err)
+		return
+	}
+
+	// Accounts with 2FA must complete a second step
+	if challenge != nil {
+		c.JSON(http.StatusOK, challenge)
 		return
 	}
 
 	c.JSON(http.StatusOK, resp)
 }
 
-// RefreshToken generates a new JWT token
+// LoginTwoFactor completes a login with a TOTP or recovery code
+func (h *AuthHandler) LoginTwoFactor(c *gin.Context) {
+	var req services.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.authService.CompleteTwoFactorLogin(&req, clientInfo(c))
+	if err != nil {
+		respondLoginError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// RefreshToken exchanges a refresh token for a new token pair
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	var req services.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.authService.RefreshToken(req.RefreshToken, clientInfo(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Logout revokes the session the given refresh token belongs to
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req services.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.authService.Logout(req.RefreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// LogoutAll revokes every session of the logged-in user
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 
-	resp, err := h.authService.RefreshToken(userID.(uint))
-	if err != nil {
+	if err := h.authService.LogoutAll(userID.(uint)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out of all devices"})
+}
+
+// ForgotPassword emails a password reset link. The response is the same
+// whether or not the email is registered.
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req services.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, resp)
+	if err := h.authService.RequestPasswordReset(req.Email); err != nil {
+		log.Printf("Password reset request failed: %v", err)
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If an account exists for that email, a reset link has been sent"})
+}
+
+// ResetPassword sets a new password using a reset token
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req services.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.authService.ResetPassword(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset"})
+}
+
+// VerifyEmail confirms the user's email address
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req services.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.authService.VerifyEmail(req.Token); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified. Refresh your session to continue."})
+}
+
+// ResendVerification sends a new verification email to the logged-in user
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := h.authService.ResendVerificationEmail(userID.(uint)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
+
+// respondLoginError maps login failures to responses. Throttled attempts get
+// 429 with Retry-After; everything else is a generic 401.
+func respondLoginError(c *gin.Context, err error) {
+	var throttled *services.ThrottledError
+	if errors.As(err, &throttled) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": throttled.Error()})
+		return
+	}
+
+	c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+}
+
+// clientInfo describes the client making the request, for session tracking
+func clientInfo(c *gin.Context) services.ClientInfo {
+	return services.ClientInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
 }
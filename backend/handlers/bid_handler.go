@@ -2,7 +2,9 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jimsyyap/auctions/backend/services"
@@ -18,7 +20,38 @@ func NewBidHandler(bidService *services.BidService) *BidHandler {
 	}
 }
 
+// PlaceBid bids on a listing for the logged-in user
 func (h *BidHandler) PlaceBid(c *gin.Context) {
-	// Implementation
-	c.JSON(http.StatusCreated, gin.H{"message": "Bid placed successfully"})
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	listingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid listing ID"})
+		return
+	}
+
+	var req services.BidRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	bid, err := h.bidService.PlaceBid(userID.(uint), uint(listingID), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrListingNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrOwnListingBid):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"bid": bid})
 }
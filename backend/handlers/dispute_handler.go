@@ -0,0 +1,270 @@
+// handlers/dispute_handler.go
+package handlers
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/services"
+)
+
+type DisputeHandler struct {
+	disputeService *services.DisputeService
+}
+
+func NewDisputeHandler(disputeService *services.DisputeService) *DisputeHandler {
+	return &DisputeHandler{
+		disputeService: disputeService,
+	}
+}
+
+// Open starts a dispute about one of the logged-in user's purchases. It
+// accepts multipart form data with "evidence" files.
+func (h *DisputeHandler) Open(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	var req services.OpenDisputeRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dispute, err := h.disputeService.Open(userID.(uint), uint(id), &req, evidenceFiles(c))
+	if err != nil {
+		respondDisputeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dispute)
+}
+
+// GetDispute returns the dispute about one of the logged-in user's sales
+func (h *DisputeHandler) GetDispute(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	dispute, err := h.disputeService.GetDispute(userID.(uint), uint(id))
+	if err != nil {
+		respondDisputeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dispute)
+}
+
+// AddMessage adds the buyer's or seller's message to a dispute
+func (h *DisputeHandler) AddMessage(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	var req services.DisputeMessageRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	message, err := h.disputeService.AddMessage(userID.(uint), uint(id), &req, evidenceFiles(c))
+	if err != nil {
+		respondDisputeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, message)
+}
+
+// GetEvidence streams a piece of evidence to the buyer or seller
+func (h *DisputeHandler) GetEvidence(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	h.serveEvidence(c, func(id, evidenceID uint) (io.ReadCloser, *models.DisputeEvidence, error) {
+		return h.disputeService.OpenEvidence(userID.(uint), id, evidenceID)
+	})
+}
+
+// GetDisputes lists disputes for admins. ?status lists disputes with one
+// status, oldest first.
+func (h *DisputeHandler) GetDisputes(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	disputes, total, err := h.disputeService.GetDisputes(c.Query("status"), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get disputes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"disputes": disputes,
+		"pagination": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+			"pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// GetDisputeByID returns a dispute with its timeline (admin)
+func (h *DisputeHandler) GetDisputeByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dispute ID"})
+		return
+	}
+
+	dispute, err := h.disputeService.GetDisputeByID(uint(id))
+	if err != nil {
+		respondDisputeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dispute)
+}
+
+// AddAdminMessage adds an admin's message to a dispute
+func (h *DisputeHandler) AddAdminMessage(c *gin.Context) {
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dispute ID"})
+		return
+	}
+
+	var req services.DisputeMessageRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	message, err := h.disputeService.AddAdminMessage(adminID.(uint), uint(id), &req, evidenceFiles(c))
+	if err != nil {
+		respondDisputeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, message)
+}
+
+// GetDisputeEvidence streams a piece of evidence to an admin
+func (h *DisputeHandler) GetDisputeEvidence(c *gin.Context) {
+	h.serveEvidence(c, h.disputeService.OpenDisputeEvidence)
+}
+
+// Resolve closes a dispute with a full refund, a partial refund or no
+// action (admin)
+func (h *DisputeHandler) Resolve(c *gin.Context) {
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dispute ID"})
+		return
+	}
+
+	var req services.ResolveDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dispute, err := h.disputeService.Resolve(adminID.(uint), uint(id), &req)
+	if err != nil {
+		respondDisputeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dispute)
+}
+
+func (h *DisputeHandler) serveEvidence(c *gin.Context, open func(id, evidenceID uint) (io.ReadCloser, *models.DisputeEvidence, error)) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	evidenceID, err := strconv.ParseUint(c.Param("evidenceId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid evidence ID"})
+		return
+	}
+
+	r, evidence, err := open(uint(id), uint(evidenceID))
+	if err != nil {
+		respondDisputeError(c, err)
+		return
+	}
+	defer r.Close()
+
+	// Evidence is private to the dispute
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Disposition", "attachment; filename="+strconv.Quote(evidence.OriginalName))
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", evidence.ContentType)
+	io.Copy(c.Writer, r)
+}
+
+// evidenceFiles returns the files uploaded with a multipart dispute message
+func evidenceFiles(c *gin.Context) []*multipart.FileHeader {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil
+	}
+	return form.File["evidence"]
+}
+
+// respondDisputeError maps dispute service errors to HTTP statuses
+func respondDisputeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrDisputeNotFound), errors.Is(err, services.ErrEvidenceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		respondTransactionError(c, err)
+	}
+}
@@ -0,0 +1,68 @@
+// handlers/exchange_rate_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimsyyap/auctions/backend/services"
+)
+
+type ExchangeRateHandler struct {
+	exchangeRateService *services.ExchangeRateService
+}
+
+func NewExchangeRateHandler(exchangeRateService *services.ExchangeRateService) *ExchangeRateHandler {
+	return &ExchangeRateHandler{
+		exchangeRateService: exchangeRateService,
+	}
+}
+
+// GetRates lists the exchange rates used to show approximate prices
+func (h *ExchangeRateHandler) GetRates(c *gin.Context) {
+	rates, err := h.exchangeRateService.GetRates()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get exchange rates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rates": rates})
+}
+
+// SetRate creates or replaces the rate for a currency
+func (h *ExchangeRateHandler) SetRate(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.SetRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rate, err := h.exchangeRateService.SetRate(userID.(uint), c.Param("currency"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rate)
+}
+
+// DeleteRate removes the rate for a currency
+func (h *ExchangeRateHandler) DeleteRate(c *gin.Context) {
+	if err := h.exchangeRateService.DeleteRate(c.Param("currency")); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrExchangeRateNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Exchange rate deleted"})
+}
@@ -0,0 +1,96 @@
+// handlers/fee_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimsyyap/auctions/backend/services"
+)
+
+type FeeHandler struct {
+	feeService *services.FeeService
+}
+
+func NewFeeHandler(feeService *services.FeeService) *FeeHandler {
+	return &FeeHandler{
+		feeService: feeService,
+	}
+}
+
+// GetRules lists the fee rules. ?kind=insertion or final_value lists one kind.
+func (h *FeeHandler) GetRules(c *gin.Context) {
+	rules, err := h.feeService.GetRules(c.Query("kind"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get fee rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+// CreateRule adds a fee rule
+func (h *FeeHandler) CreateRule(c *gin.Context) {
+	var req services.FeeRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.feeService.CreateRule(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateRule replaces a fee rule
+func (h *FeeHandler) UpdateRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fee rule ID"})
+		return
+	}
+
+	var req services.FeeRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.feeService.UpdateRule(uint(id), &req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrFeeRuleNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRule removes a fee rule
+func (h *FeeHandler) DeleteRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fee rule ID"})
+		return
+	}
+
+	if err := h.feeService.DeleteRule(uint(id)); err != nil {
+		if errors.Is(err, services.ErrFeeRuleNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete fee rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Fee rule deleted"})
+}
@@ -0,0 +1,132 @@
+// handlers/feedback_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimsyyap/auctions/backend/services"
+)
+
+type FeedbackHandler struct {
+	feedbackService *services.FeedbackService
+}
+
+func NewFeedbackHandler(feedbackService *services.FeedbackService) *FeedbackHandler {
+	return &FeedbackHandler{
+		feedbackService: feedbackService,
+	}
+}
+
+// LeaveFeedback rates the other party of a completed sale
+func (h *FeedbackHandler) LeaveFeedback(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	listingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid listing ID"})
+		return
+	}
+
+	var req services.FeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	feedback, err := h.feedbackService.LeaveFeedback(userID.(uint), uint(listingID), &req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrFeedbackNotAllowed) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, feedback)
+}
+
+// Reply posts the rated user's public reply to feedback
+func (h *FeedbackHandler) Reply(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid feedback ID"})
+		return
+	}
+
+	var req services.FeedbackReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	feedback, err := h.feedbackService.Reply(userID.(uint), uint(id), &req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrFeedbackNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, feedback)
+}
+
+// GetReceived lists feedback a user received
+func (h *FeedbackHandler) GetReceived(c *gin.Context) {
+	h.list(c, h.feedbackService.GetReceived)
+}
+
+// GetGiven lists feedback a user left for others
+func (h *FeedbackHandler) GetGiven(c *gin.Context) {
+	h.list(c, h.feedbackService.GetGiven)
+}
+
+type feedbackLister func(userID uint, query services.FeedbackQuery, page, limit int) ([]services.FeedbackView, int64, error)
+
+func (h *FeedbackHandler) list(c *gin.Context, find feedbackLister) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var query services.FeedbackQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	feedback, total, err := find(uint(id), query, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feedback"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"feedback": feedback,
+		"pagination": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+			"pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
@@ -0,0 +1,33 @@
+// handlers/handlers.go
+package handlers
+
+// Handlers groups all HTTP handlers so they can be passed to the router together
+type Handlers struct {
+	AuthHandler      *AuthHandler
+	UserHandler      *UserHandler
+	ListingHandler   *ListingHandler
+	BidHandler       *BidHandler
+	TwoFactorHandler *TwoFactorHandler
+	OIDCHandler      *OIDCHandler
+	LockoutHandler   *LockoutHandler
+	RoleHandler      *RoleHandler
+	WellKnownHandler *WellKnownHandler
+
+	NotificationHandler *NotificationHandler
+	VerificationHandler *VerificationHandler
+	SessionHandler      *SessionHandler
+	AccountHandler      *AccountHandler
+	AddressHandler      *AddressHandler
+	FeedbackHandler     *FeedbackHandler
+	MessageHandler      *MessageHandler
+	QuestionHandler     *QuestionHandler
+	ModerationHandler   *ModerationHandler
+	TransactionHandler  *TransactionHandler
+	PaymentHandler      *PaymentHandler
+	ExchangeRateHandler *ExchangeRateHandler
+	FeeHandler          *FeeHandler
+	LedgerHandler       *LedgerHandler
+	DisputeHandler      *DisputeHandler
+	SettingHandler      *SettingHandler
+	InvoiceHandler      *InvoiceHandler
+}
@@ -0,0 +1,59 @@
+// handlers/invoice_handler.go
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimsyyap/auctions/backend/services"
+)
+
+type InvoiceHandler struct {
+	invoiceService *services.InvoiceService
+}
+
+func NewInvoiceHandler(invoiceService *services.InvoiceService) *InvoiceHandler {
+	return &InvoiceHandler{
+		invoiceService: invoiceService,
+	}
+}
+
+// GetInvoice returns the PDF invoice for one of the logged-in user's
+// completed sales
+func (h *InvoiceHandler) GetInvoice(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	invoice, content, err := h.invoiceService.GetInvoice(userID.(uint), uint(id))
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrTransactionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrInvoiceNotReady):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	default:
+		log.Printf("Failed to get the invoice for transaction %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get invoice"})
+		return
+	}
+
+	// Invoices are private to the buyer and seller
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Disposition", "inline; filename="+strconv.Quote(invoice.Number+".pdf"))
+	c.Data(http.StatusOK, "application/pdf", content)
+}
@@ -0,0 +1,137 @@
+// handlers/ledger_handler.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimsyyap/auctions/backend/services"
+)
+
+type LedgerHandler struct {
+	ledgerService *services.LedgerService
+}
+
+func NewLedgerHandler(ledgerService *services.LedgerService) *LedgerHandler {
+	return &LedgerHandler{
+		ledgerService: ledgerService,
+	}
+}
+
+// GetBalance returns the logged-in seller's balance awaiting payout, one
+// per currency
+func (h *LedgerHandler) GetBalance(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	balances, err := h.ledgerService.GetBalances(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get balance"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"balances": balances})
+}
+
+// GetMyPayouts lists the payouts made to the logged-in seller
+func (h *LedgerHandler) GetMyPayouts(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	h.respondPayouts(c, userID.(uint))
+}
+
+// GetPayouts lists payouts to every seller. ?seller_id lists one seller's.
+func (h *LedgerHandler) GetPayouts(c *gin.Context) {
+	sellerID, _ := strconv.ParseUint(c.DefaultQuery("seller_id", "0"), 10, 32)
+	h.respondPayouts(c, uint(sellerID))
+}
+
+// GetAccounts lists ledger accounts. ?type lists one type of account.
+func (h *LedgerHandler) GetAccounts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	accounts, total, err := h.ledgerService.GetAccounts(c.Query("type"), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get ledger accounts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"accounts": accounts,
+		"pagination": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+			"pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// GetEntries lists the ledger entries that posted to an account
+func (h *LedgerHandler) GetEntries(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	entries, total, err := h.ledgerService.GetEntries(uint(id), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get ledger entries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"pagination": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+			"pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// Reconcile checks that the ledger balances and reports any discrepancy
+func (h *LedgerHandler) Reconcile(c *gin.Context) {
+	report, err := h.ledgerService.Reconcile()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reconcile the ledger"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *LedgerHandler) respondPayouts(c *gin.Context, sellerID uint) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	payouts, total, err := h.ledgerService.GetPayouts(sellerID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get payouts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"payouts": payouts,
+		"pagination": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+			"pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
@@ -2,44 +2,141 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jimsyyap/auctions/backend/models"
 	"github.com/jimsyyap/auctions/backend/services"
 )
 
 type ListingHandler struct {
-	listingService *services.ListingService
+	listingService  *services.ListingService
+	questionService *services.QuestionService
 }
 
-func NewListingHandler(listingService *services.ListingService) *ListingHandler {
+func NewListingHandler(listingService *services.ListingService, questionService *services.QuestionService) *ListingHandler {
 	return &ListingHandler{
-		listingService: listingService,
+		listingService:  listingService,
+		questionService: questionService,
 	}
 }
 
+// GetListings searches active listings. ?min_price and ?max_price filter
+// on the current price, and ?currency shows prices converted to it.
 func (h *ListingHandler) GetListings(c *gin.Context) {
-	// Implementation
-	c.JSON(http.StatusOK, gin.H{"listings": []string{}})
+	var query services.ListingQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	listings, total, err := h.listingService.GetListings(&query, page, limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"listings": listings,
+		"pagination": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+			"pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
 }
 
+// GetListing shows a listing with its published questions and answers.
+// ?currency shows its prices converted to that currency.
 func (h *ListingHandler) GetListing(c *gin.Context) {
-	// Implementation
-	c.JSON(http.StatusOK, gin.H{"listing": "Listing details"})
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid listing ID"})
+		return
+	}
+
+	// Listings held or rejected by moderation are not public
+	listing, err := h.listingService.GetListing(uint(id))
+	if err != nil || listing.Status == models.ModerationStatusHeld || listing.Status == models.ModerationStatusRejected {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Listing not found"})
+		return
+	}
+
+	view, err := h.listingService.ViewListing(listing, c.Query("currency"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	questions, err := h.questionService.GetPublished(listing.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get listing questions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"listing": view, "questions": questions})
 }
 
+// CreateListing lists an item for the logged-in seller. Listings held by
+// moderation go live once a moderator releases them.
 func (h *ListingHandler) CreateListing(c *gin.Context) {
-	// Implementation
-	c.JSON(http.StatusCreated, gin.H{"message": "Listing created"})
+	var req services.ListingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	listing, err := h.listingService.CreateListing(c.GetUint("user_id"), &req)
+	if err != nil {
+		respondListingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"listing": listing})
 }
 
+// UpdateListing changes one of the seller's listings before it has bids
 func (h *ListingHandler) UpdateListing(c *gin.Context) {
-	// Implementation
-	c.JSON(http.StatusOK, gin.H{"message": "Listing updated"})
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid listing ID"})
+		return
+	}
+
+	var req services.ListingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	listing, err := h.listingService.UpdateListing(uint(id), c.GetUint("user_id"), &req)
+	if err != nil {
+		respondListingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"listing": listing})
 }
 
+// DeleteListing removes one of the seller's listings before it has bids
 func (h *ListingHandler) DeleteListing(c *gin.Context) {
-	// Implementation
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid listing ID"})
+		return
+	}
+
+	if err := h.listingService.DeleteListing(uint(id), c.GetUint("user_id")); err != nil {
+		respondListingError(c, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Listing deleted"})
 }
 
@@ -52,3 +149,15 @@ func (h *ListingHandler) GetListingsByCategory(c *gin.Context) {
 	// Implementation
 	c.JSON(http.StatusOK, gin.H{"listings": []string{}})
 }
+
+// respondListingError maps listing service errors to HTTP statuses
+func respondListingError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrListingNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotListingOwner), errors.Is(err, services.ErrVerifiedSellerRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
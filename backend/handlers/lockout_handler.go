@@ -0,0 +1,59 @@
+// handlers/lockout_handler.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimsyyap/auctions/backend/services"
+)
+
+type LockoutHandler struct {
+	throttleService *services.LoginThrottleService
+}
+
+func NewLockoutHandler(throttleService *services.LoginThrottleService) *LockoutHandler {
+	return &LockoutHandler{
+		throttleService: throttleService,
+	}
+}
+
+// GetLockouts lists usernames and IPs that are currently locked out
+func (h *LockoutHandler) GetLockouts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	lockouts, total, err := h.throttleService.ListLockouts(page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get lockouts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"lockouts": lockouts,
+		"pagination": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+			"pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// ClearLockout removes a lockout so the user or IP can log in again
+func (h *LockoutHandler) ClearLockout(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lockout ID"})
+		return
+	}
+
+	if err := h.throttleService.ClearLockout(uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Lockout cleared"})
+}
@@ -0,0 +1,312 @@
+// handlers/message_handler.go
+package handlers
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimsyyap/auctions/backend/services"
+)
+
+type MessageHandler struct {
+	messageService *services.MessageService
+}
+
+func NewMessageHandler(messageService *services.MessageService) *MessageHandler {
+	return &MessageHandler{
+		messageService: messageService,
+	}
+}
+
+// StartThread sends the first message about a listing
+func (h *MessageHandler) StartThread(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	listingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid listing ID"})
+		return
+	}
+
+	var req services.StartThreadRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	thread, message, err := h.messageService.StartThread(userID.(uint), uint(listingID), &req, attachmentFiles(c))
+	if err != nil {
+		respondMessageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"thread": thread, "message": message})
+}
+
+// GetThreads lists the logged-in user's conversations
+func (h *MessageHandler) GetThreads(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	threads, total, err := h.messageService.GetThreads(userID.(uint), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get conversations"})
+		return
+	}
+
+	unread, err := h.messageService.GetUnreadCount(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get conversations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"threads":      threads,
+		"unread_count": unread,
+		"pagination": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+			"pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// GetMessages lists a conversation's messages and marks them as read
+func (h *MessageHandler) GetMessages(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	messages, total, err := h.messageService.GetMessages(userID.(uint), uint(id), page, limit)
+	if err != nil {
+		respondMessageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"messages": messages,
+		"pagination": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+			"pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// SendMessage adds a message to a conversation
+func (h *MessageHandler) SendMessage(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	var req services.MessageRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	message, err := h.messageService.SendMessage(userID.(uint), uint(id), &req, attachmentFiles(c))
+	if err != nil {
+		respondMessageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, message)
+}
+
+// GetAttachment streams an image sent in a conversation
+func (h *MessageHandler) GetAttachment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	h.serveAttachment(c, func(threadID, attachmentID uint) (io.ReadCloser, string, error) {
+		return h.messageService.OpenAttachment(userID.(uint), threadID, attachmentID)
+	})
+}
+
+// Block stops further messages in a conversation
+func (h *MessageHandler) Block(c *gin.Context) {
+	h.threadAction(c, h.messageService.Block, "Conversation blocked")
+}
+
+// Unblock allows messages in a conversation again
+func (h *MessageHandler) Unblock(c *gin.Context) {
+	h.threadAction(c, h.messageService.Unblock, "Conversation unblocked")
+}
+
+// Report flags a conversation for admin review
+func (h *MessageHandler) Report(c *gin.Context) {
+	var req services.ReportThreadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.threadAction(c, func(userID, threadID uint) error {
+		return h.messageService.Report(userID, threadID, &req)
+	}, "Conversation reported")
+}
+
+// GetReportedThreads lists reported conversations (admin)
+func (h *MessageHandler) GetReportedThreads(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	threads, total, err := h.messageService.GetReportedThreads(page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get reported conversations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"threads": threads,
+		"pagination": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+			"pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// GetReportedThread shows a reported conversation and its messages (admin)
+func (h *MessageHandler) GetReportedThread(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	thread, messages, total, err := h.messageService.GetReportedThread(uint(id), page, limit)
+	if err != nil {
+		respondMessageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"thread":   thread,
+		"messages": messages,
+		"pagination": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+			"pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// GetReportedAttachment streams an image from a reported conversation (admin)
+func (h *MessageHandler) GetReportedAttachment(c *gin.Context) {
+	h.serveAttachment(c, h.messageService.OpenReportedAttachment)
+}
+
+// threadAction runs an action on a conversation for the logged-in user
+func (h *MessageHandler) threadAction(c *gin.Context, action func(userID, threadID uint) error, done string) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	if err := action(userID.(uint), uint(id)); err != nil {
+		respondMessageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": done})
+}
+
+func (h *MessageHandler) serveAttachment(c *gin.Context, open func(threadID, attachmentID uint) (io.ReadCloser, string, error)) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+	attachmentID, err := strconv.ParseUint(c.Param("attachmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+
+	r, contentType, err := open(uint(id), uint(attachmentID))
+	if err != nil {
+		respondMessageError(c, err)
+		return
+	}
+	defer r.Close()
+
+	// Attachments are private to the conversation
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", contentType)
+	io.Copy(c.Writer, r)
+}
+
+// attachmentFiles returns the images uploaded with a multipart message
+func attachmentFiles(c *gin.Context) []*multipart.FileHeader {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil
+	}
+	return form.File["attachments"]
+}
+
+// respondMessageError maps message service errors to HTTP statuses
+func respondMessageError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrThreadNotFound), errors.Is(err, services.ErrAttachmentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrThreadBlocked):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
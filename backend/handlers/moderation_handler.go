@@ -0,0 +1,166 @@
+// handlers/moderation_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimsyyap/auctions/backend/services"
+)
+
+type ModerationHandler struct {
+	moderationService *services.ModerationService
+}
+
+func NewModerationHandler(moderationService *services.ModerationService) *ModerationHandler {
+	return &ModerationHandler{
+		moderationService: moderationService,
+	}
+}
+
+// GetDecisions lists the moderation audit log. ?pending=true lists held
+// content awaiting review.
+func (h *ModerationHandler) GetDecisions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	decisions, total, err := h.moderationService.GetDecisions(c.Query("content_type"), c.Query("action"), c.Query("pending") == "true", page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get moderation decisions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"decisions": decisions,
+		"pagination": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+			"pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// GetDecision shows one moderation decision
+func (h *ModerationHandler) GetDecision(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid decision ID"})
+		return
+	}
+
+	decision, err := h.moderationService.GetDecision(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, decision)
+}
+
+// Review approves or rejects held content
+func (h *ModerationHandler) Review(c *gin.Context) {
+	reviewerID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid decision ID"})
+		return
+	}
+
+	var req services.ReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	decision, err := h.moderationService.Review(reviewerID.(uint), uint(id), &req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrDecisionNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, decision)
+}
+
+// GetRules lists the moderation rules
+func (h *ModerationHandler) GetRules(c *gin.Context) {
+	rules, err := h.moderationService.GetRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get moderation rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+// UpdateRule sets the action for a check on a kind of content
+func (h *ModerationHandler) UpdateRule(c *gin.Context) {
+	var req services.RuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.moderationService.UpdateRule(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// GetBannedWords lists the banned words and phrases
+func (h *ModerationHandler) GetBannedWords(c *gin.Context) {
+	words, err := h.moderationService.GetBannedWords()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get banned words"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"banned_words": words})
+}
+
+// AddBannedWord bans a word or phrase
+func (h *ModerationHandler) AddBannedWord(c *gin.Context) {
+	var req services.BannedWordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	word, err := h.moderationService.AddBannedWord(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, word)
+}
+
+// RemoveBannedWord lifts a ban on a word or phrase
+func (h *ModerationHandler) RemoveBannedWord(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid banned word ID"})
+		return
+	}
+
+	if err := h.moderationService.RemoveBannedWord(uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Banned word removed"})
+}
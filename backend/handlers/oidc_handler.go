@@ -0,0 +1,70 @@
+// handlers/oidc_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimsyyap/auctions/backend/services"
+)
+
+type OIDCHandler struct {
+	oidcService *services.OIDCService
+}
+
+func NewOIDCHandler(oidcService *services.OIDCService) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: oidcService,
+	}
+}
+
+// GetProviders lists the identity providers users can sign in with
+func (h *OIDCHandler) GetProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": h.oidcService.ListProviders()})
+}
+
+// StartLogin returns the provider authorization URL to redirect the user to
+func (h *OIDCHandler) StartLogin(c *gin.Context) {
+	resp, err := h.oidcService.StartLogin(c.Param("provider"))
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownOIDCProvider) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Callback completes the login with the code and state the provider returned
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	var req services.OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, challenge, err := h.oidcService.CompleteLogin(c.Param("provider"), &req, clientInfo(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUnknownOIDCProvider):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrOIDCEmailConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	// Accounts with 2FA must complete a second step
+	if challenge != nil {
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
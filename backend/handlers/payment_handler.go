@@ -0,0 +1,130 @@
+// handlers/payment_handler.go
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimsyyap/auctions/backend/payments"
+	"github.com/jimsyyap/auctions/backend/services"
+)
+
+// Webhook bodies are small; anything larger is not from the provider
+const maxWebhookSize = 1 << 20
+
+type PaymentHandler struct {
+	paymentService *services.PaymentService
+}
+
+func NewPaymentHandler(paymentService *services.PaymentService) *PaymentHandler {
+	return &PaymentHandler{
+		paymentService: paymentService,
+	}
+}
+
+// Pay starts paying for one of the logged-in user's purchases
+func (h *PaymentHandler) Pay(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	var req services.PayRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	checkout, err := h.paymentService.StartPayment(userID.(uint), uint(id), &req)
+	if err != nil {
+		respondTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, checkout)
+}
+
+// GetPayments lists the payment attempts for one of the logged-in user's
+// transactions
+func (h *PaymentHandler) GetPayments(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	attempts, err := h.paymentService.GetPayments(userID.(uint), uint(id))
+	if err != nil {
+		respondTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"payments": attempts})
+}
+
+// Webhook receives payment events from the provider. Any response other
+// than 200 makes the provider deliver the event again later.
+func (h *PaymentHandler) Webhook(c *gin.Context) {
+	payload, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookSize))
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Webhook body is too large"})
+		return
+	}
+
+	err = h.paymentService.HandleWebhook(c.Param("provider"), payload, c.Request.Header)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"received": true})
+	case errors.Is(err, services.ErrUnknownProvider):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, payments.ErrInvalidSignature):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Failed to handle %s payment webhook: %v", c.Param("provider"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to handle webhook"})
+	}
+}
+
+// ConfirmFakePayment stands in for the fake provider's checkout page
+func (h *PaymentHandler) ConfirmFakePayment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.FakeCheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payment, err := h.paymentService.ConfirmFakePayment(userID.(uint), c.Param("intentId"), &req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrPaymentNotFound) || errors.Is(err, services.ErrFakeCheckoutOnly) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
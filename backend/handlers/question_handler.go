@@ -0,0 +1,131 @@
+// handlers/question_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimsyyap/auctions/backend/services"
+)
+
+type QuestionHandler struct {
+	questionService *services.QuestionService
+}
+
+func NewQuestionHandler(questionService *services.QuestionService) *QuestionHandler {
+	return &QuestionHandler{
+		questionService: questionService,
+	}
+}
+
+// Ask sends a question about a listing to its seller
+func (h *QuestionHandler) Ask(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	listingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid listing ID"})
+		return
+	}
+
+	var req services.AskQuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	question, err := h.questionService.Ask(userID.(uint), uint(listingID), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, question)
+}
+
+// GetQuestions lists a listing's questions visible to the logged-in user
+func (h *QuestionHandler) GetQuestions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	listingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid listing ID"})
+		return
+	}
+
+	questions, err := h.questionService.GetQuestions(userID.(uint), uint(listingID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"questions": questions})
+}
+
+// Answer records the seller's answer to a question
+func (h *QuestionHandler) Answer(c *gin.Context) {
+	var req services.AnswerQuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.sellerAction(c, func(userID, listingID, questionID uint) (*services.QuestionView, error) {
+		return h.questionService.Answer(userID, listingID, questionID, &req)
+	})
+}
+
+// Publish shows a question and its answer on the listing page
+func (h *QuestionHandler) Publish(c *gin.Context) {
+	h.sellerAction(c, h.questionService.Publish)
+}
+
+// Hide removes a question from the listing page
+func (h *QuestionHandler) Hide(c *gin.Context) {
+	h.sellerAction(c, h.questionService.Hide)
+}
+
+// sellerAction runs a seller's action on one question of a listing
+func (h *QuestionHandler) sellerAction(c *gin.Context, action func(userID, listingID, questionID uint) (*services.QuestionView, error)) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	listingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid listing ID"})
+		return
+	}
+	questionID, err := strconv.ParseUint(c.Param("questionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question ID"})
+		return
+	}
+
+	question, err := action(userID.(uint), uint(listingID), uint(questionID))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrQuestionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrNotListingSeller):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, question)
+}
@@ -0,0 +1,58 @@
+// handlers/setting_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimsyyap/auctions/backend/services"
+)
+
+type SettingHandler struct {
+	settingService *services.SettingService
+}
+
+func NewSettingHandler(settingService *services.SettingService) *SettingHandler {
+	return &SettingHandler{
+		settingService: settingService,
+	}
+}
+
+// GetSettings lists the system settings with their current values
+func (h *SettingHandler) GetSettings(c *gin.Context) {
+	settings, err := h.settingService.GetSettings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"settings": settings})
+}
+
+// UpdateSetting changes a system setting
+func (h *SettingHandler) UpdateSetting(c *gin.Context) {
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.SettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	setting, err := h.settingService.UpdateSetting(adminID.(uint), c.Param("key"), &req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrUnknownSetting) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, setting)
+}
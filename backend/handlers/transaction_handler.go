@@ -0,0 +1,229 @@
+// handlers/transaction_handler.go
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimsyyap/auctions/backend/services"
+)
+
+type TransactionHandler struct {
+	transactionService *services.TransactionService
+}
+
+func NewTransactionHandler(transactionService *services.TransactionService) *TransactionHandler {
+	return &TransactionHandler{
+		transactionService: transactionService,
+	}
+}
+
+// BuyNow buys a listing at its Buy Now price
+func (h *TransactionHandler) BuyNow(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	listingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid listing ID"})
+		return
+	}
+
+	var req services.BuyNowRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transaction, err := h.transactionService.BuyNow(userID.(uint), uint(listingID), &req)
+	if err != nil {
+		respondTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, transaction)
+}
+
+// GetTransactions lists the logged-in user's purchases and sales
+func (h *TransactionHandler) GetTransactions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var query services.TransactionQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	transactions, total, err := h.transactionService.GetTransactions(userID.(uint), query, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get transactions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transactions": transactions,
+		"pagination": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+			"pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// GetTransaction returns one of the logged-in user's transactions
+func (h *TransactionHandler) GetTransaction(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	transaction, err := h.transactionService.GetTransaction(userID.(uint), uint(id))
+	if err != nil {
+		respondTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, transaction)
+}
+
+// SetShippingAddress chooses the address an unpaid purchase ships to
+func (h *TransactionHandler) SetShippingAddress(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	var req services.ShippingAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transaction, err := h.transactionService.SetShippingAddress(userID.(uint), uint(id), &req)
+	if err != nil {
+		respondTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, transaction)
+}
+
+// Ship records that the seller sent the item
+func (h *TransactionHandler) Ship(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	var req services.ShipRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transaction, err := h.transactionService.Ship(userID.(uint), uint(id), &req)
+	if err != nil {
+		respondTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, transaction)
+}
+
+// ConfirmDelivery records that the buyer received the item
+func (h *TransactionHandler) ConfirmDelivery(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	transaction, err := h.transactionService.ConfirmDelivery(userID.(uint), uint(id))
+	if err != nil {
+		respondTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, transaction)
+}
+
+// Cancel calls off a sale
+func (h *TransactionHandler) Cancel(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	var req services.TransactionReasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transaction, err := h.transactionService.Cancel(userID.(uint), uint(id), &req)
+	if err != nil {
+		respondTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, transaction)
+}
+
+func respondTransactionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrTransactionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotTransactionBuyer), errors.Is(err, services.ErrNotTransactionSeller):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -27,7 +27,7 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 		return
 	}
 	
-	profile, err := h.userService.GetUserProfile(userID.(uint))
+	profile, err := h.userService.GetPrivateProfile(userID.(uint))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user profile"})
 		return
@@ -59,6 +59,45 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Profile updated successfully"})
 }
 
+// UploadAvatar replaces the logged-in user's profile picture
+func (h *UserHandler) UploadAvatar(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	file, err := c.FormFile("avatar")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "An avatar image is required"})
+		return
+	}
+
+	url, err := h.userService.UpdateAvatar(userID.(uint), file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"avatar_url": url})
+}
+
+// DeleteAvatar removes the logged-in user's profile picture
+func (h *UserHandler) DeleteAvatar(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := h.userService.DeleteAvatar(userID.(uint)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove avatar"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Avatar removed"})
+}
+
 // GetUser retrieves a public user profile by ID
 func (h *UserHandler) GetUser(c *gin.Context) {
 	idParam := c.Param("id")
@@ -68,7 +107,7 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 		return
 	}
 	
-	profile, err := h.userService.GetUserProfile(uint(id))
+	profile, err := h.userService.GetPublicProfile(uint(id))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
@@ -0,0 +1,179 @@
+// handlers/verification_handler.go
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/services"
+)
+
+type VerificationHandler struct {
+	verificationService *services.VerificationService
+}
+
+func NewVerificationHandler(verificationService *services.VerificationService) *VerificationHandler {
+	return &VerificationHandler{
+		verificationService: verificationService,
+	}
+}
+
+// Submit uploads verification documents for the logged-in user
+func (h *VerificationHandler) Submit(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.SubmitVerificationRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Documents must be uploaded as multipart form data"})
+		return
+	}
+
+	verification, err := h.verificationService.Submit(userID.(uint), &req, form.File["documents"])
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, verification)
+}
+
+// GetMine lists the logged-in user's verification requests
+func (h *VerificationHandler) GetMine(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	verifications, err := h.verificationService.GetUserVerifications(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get verification requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"verifications": verifications})
+}
+
+// GetVerifications lists verification requests for review
+func (h *VerificationHandler) GetVerifications(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	status := c.DefaultQuery("status", "pending")
+
+	verifications, total, err := h.verificationService.GetVerifications(status, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get verification requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"verifications": verifications,
+		"pagination": gin.H{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+			"pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// GetVerification shows one verification request
+func (h *VerificationHandler) GetVerification(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid verification ID"})
+		return
+	}
+
+	verification, err := h.verificationService.GetVerification(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, verification)
+}
+
+// GetDocument streams a verification document from private storage
+func (h *VerificationHandler) GetDocument(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid verification ID"})
+		return
+	}
+	docID, err := strconv.ParseUint(c.Param("docId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document ID"})
+		return
+	}
+
+	r, doc, err := h.verificationService.OpenDocument(uint(id), uint(docID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	defer r.Close()
+
+	// Identity documents must never be cached by browsers or proxies
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", doc.OriginalName))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", doc.ContentType)
+	io.Copy(c.Writer, r)
+}
+
+// Approve approves a verification request
+func (h *VerificationHandler) Approve(c *gin.Context) {
+	h.review(c, h.verificationService.Approve)
+}
+
+// Reject rejects a verification request
+func (h *VerificationHandler) Reject(c *gin.Context) {
+	h.review(c, h.verificationService.Reject)
+}
+
+// review runs an approve or reject action for the logged-in reviewer
+func (h *VerificationHandler) review(c *gin.Context, action func(reviewerID, id uint, req *services.ReviewVerificationRequest) (*models.UserVerification, error)) {
+	reviewerID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid verification ID"})
+		return
+	}
+
+	// Notes are optional on approval, so an empty body is allowed
+	var req services.ReviewVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	verification, err := action(reviewerID.(uint), uint(id), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, verification)
+}
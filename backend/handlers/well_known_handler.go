@@ -0,0 +1,21 @@
+// handlers/well_known_handler.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimsyyap/auctions/backend/middlewares"
+)
+
+type WellKnownHandler struct{}
+
+func NewWellKnownHandler() *WellKnownHandler {
+	return &WellKnownHandler{}
+}
+
+// JWKS publishes the public keys other services use to verify our tokens
+func (h *WellKnownHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, middlewares.PublicJWKS())
+}
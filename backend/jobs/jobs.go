@@ -0,0 +1,22 @@
+// jobs/jobs.go
+package jobs
+
+import (
+	"log"
+	"time"
+)
+
+// Every runs task in the background once per interval for the life of the
+// process. Errors are logged and the task is retried on the next tick.
+func Every(name string, interval time.Duration, task func() error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := task(); err != nil {
+				log.Printf("Job %s failed: %v", name, err)
+			}
+		}
+	}()
+}
@@ -12,16 +12,25 @@ import (
 	"github.com/jimsyyap/auctions/backend/config"
 	"github.com/jimsyyap/auctions/backend/database"
 	"github.com/jimsyyap/auctions/backend/handlers"
+	"github.com/jimsyyap/auctions/backend/jobs"
 	"github.com/jimsyyap/auctions/backend/middlewares"
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/payments"
 	"github.com/jimsyyap/auctions/backend/repositories"
 	"github.com/jimsyyap/auctions/backend/routes"
 	"github.com/jimsyyap/auctions/backend/services"
+	"github.com/jimsyyap/auctions/backend/storage"
 )
 
 func main() {
 	// Load environment variables
 	config.LoadEnv()
 
+	// Load JWT signing keys
+	if err := middlewares.LoadSigningKeys(); err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+
 	// Connect to database
 	database.Connect()
 
@@ -33,18 +42,124 @@ func main() {
 	listingRepo := repositories.NewListingRepository()
 	bidRepo := repositories.NewBidRepository()
 	categoryRepo := repositories.NewCategoryRepository()
+	refreshTokenRepo := repositories.NewRefreshTokenRepository()
+	oneTimeTokenRepo := repositories.NewOneTimeTokenRepository()
+	recoveryCodeRepo := repositories.NewRecoveryCodeRepository()
+	loginThrottleRepo := repositories.NewLoginThrottleRepository()
+	externalIdentityRepo := repositories.NewExternalIdentityRepository()
+	oidcStateRepo := repositories.NewOIDCStateRepository()
+	roleRepo := repositories.NewRoleRepository()
+	notificationRepo := repositories.NewNotificationRepository()
+	verificationRepo := repositories.NewVerificationRepository()
+	sessionRepo := repositories.NewSessionRepository()
+	accountRepo := repositories.NewAccountRepository()
+	addressRepo := repositories.NewAddressRepository()
+	reputationRepo := repositories.NewReputationRepository()
+	ratingRepo := repositories.NewRatingRepository()
+	messageRepo := repositories.NewMessageRepository()
+	questionRepo := repositories.NewQuestionRepository()
+	moderationRepo := repositories.NewModerationRepository()
+	transactionRepo := repositories.NewTransactionRepository()
+	paymentRepo := repositories.NewPaymentRepository()
+	exchangeRateRepo := repositories.NewExchangeRateRepository()
+	feeRepo := repositories.NewFeeRepository()
+	ledgerRepo := repositories.NewLedgerRepository()
+	disputeRepo := repositories.NewDisputeRepository()
+	settingRepo := repositories.NewSettingRepository()
+	invoiceRepo := repositories.NewInvoiceRepository()
+
+	// Initialize storage. Verification documents live outside any public path.
+	storageConfig := config.GetStorageConfig()
+	privateStorage := storage.NewLocalStorage(storageConfig.PrivateDir, "")
+	publicStorage := storage.NewLocalStorage(storageConfig.PublicDir, storageConfig.PublicURLPath)
+
+	// Initialize the payment provider
+	paymentProvider, err := newPaymentProvider(config.GetPaymentConfig())
+	if err != nil {
+		log.Fatalf("Failed to set up payment provider: %v", err)
+	}
 
 	// Initialize services
-	userService := services.NewUserService(userRepo)
-	listingService := services.NewListingService(listingRepo, categoryRepo)
+	emailService := services.NewEmailService()
+	exchangeRateService := services.NewExchangeRateService(exchangeRateRepo)
+	policyService := services.NewPolicyService(roleRepo, exchangeRateService)
+	roleService := services.NewRoleService(roleRepo, policyService)
+	imageService := services.NewImageService(publicStorage)
+	reputationService := services.NewReputationService(reputationRepo)
+	moderationService := services.NewModerationService(moderationRepo)
+	notificationService := services.NewNotificationService(notificationRepo, userRepo, emailService)
+	feeService := services.NewFeeService(feeRepo, categoryRepo)
+	ledgerService := services.NewLedgerService(ledgerRepo, feeService, notificationService)
+	listingService := services.NewListingService(listingRepo, categoryRepo, policyService, moderationService, exchangeRateService, ledgerService)
 	bidService := services.NewBidService(bidRepo, listingRepo, userRepo)
-	authService := services.NewAuthService(userRepo)
+	twoFactorService := services.NewTwoFactorService(userRepo, recoveryCodeRepo, exchangeRateService)
+	loginThrottleService := services.NewLoginThrottleService(loginThrottleRepo, userRepo, emailService)
+	sessionService := services.NewSessionService(sessionRepo, refreshTokenRepo)
+	authService := services.NewAuthService(userRepo, refreshTokenRepo, oneTimeTokenRepo, emailService, twoFactorService, loginThrottleService, sessionService)
+	userService := services.NewUserService(userRepo, imageService, reputationService, authService)
+	oidcService := services.NewOIDCService(authService, userRepo, externalIdentityRepo, oidcStateRepo)
+	verificationService := services.NewVerificationService(verificationRepo, roleRepo, policyService, notificationService, privateStorage)
+	addressService := services.NewAddressService(addressRepo)
+	accountService := services.NewAccountService(accountRepo, userRepo, verificationRepo, sessionService, emailService, imageService, privateStorage)
+	feedbackService := services.NewFeedbackService(ratingRepo, listingRepo, transactionRepo, notificationService)
+	// Message attachments go through the image pipeline but stay private
+	messageService := services.NewMessageService(messageRepo, listingRepo, bidRepo, transactionRepo, notificationService, moderationService, services.NewImageService(privateStorage))
+	questionService := services.NewQuestionService(questionRepo, listingRepo, notificationService, moderationService)
+	paymentService := services.NewPaymentService(paymentProvider, paymentRepo, transactionRepo, ledgerService, notificationService)
+	transactionService := services.NewTransactionService(transactionRepo, listingRepo, bidRepo, addressService, paymentService, feeService, ledgerService, notificationService)
+	disputeService := services.NewDisputeService(disputeRepo, transactionRepo, paymentService, ledgerService, reputationService, notificationService, privateStorage)
+	settingService := services.NewSettingService(settingRepo)
+	invoiceService := services.NewInvoiceService(invoiceRepo, transactionRepo, paymentRepo, addressRepo, settingService, privateStorage)
+
+	// Held content is released or removed by the service that owns it
+	moderationService.RegisterTarget(models.ContentListing, listingService)
+	moderationService.RegisterTarget(models.ContentMessage, messageService)
+	moderationService.RegisterTarget(models.ContentQuestion, questionService)
+	moderationService.RegisterTarget(models.ContentAnswer, questionService)
 
 	// Initialize handlers
 	userHandler := handlers.NewUserHandler(userService)
-	listingHandler := handlers.NewListingHandler(listingService)
+	listingHandler := handlers.NewListingHandler(listingService, questionService)
 	bidHandler := handlers.NewBidHandler(bidService)
 	authHandler := handlers.NewAuthHandler(authService)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
+	oidcHandler := handlers.NewOIDCHandler(oidcService)
+	lockoutHandler := handlers.NewLockoutHandler(loginThrottleService)
+	roleHandler := handlers.NewRoleHandler(roleService)
+	wellKnownHandler := handlers.NewWellKnownHandler()
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	verificationHandler := handlers.NewVerificationHandler(verificationService)
+	sessionHandler := handlers.NewSessionHandler(sessionService)
+	accountHandler := handlers.NewAccountHandler(accountService)
+	addressHandler := handlers.NewAddressHandler(addressService)
+	feedbackHandler := handlers.NewFeedbackHandler(feedbackService)
+	messageHandler := handlers.NewMessageHandler(messageService)
+	questionHandler := handlers.NewQuestionHandler(questionService)
+	moderationHandler := handlers.NewModerationHandler(moderationService)
+	transactionHandler := handlers.NewTransactionHandler(transactionService)
+	paymentHandler := handlers.NewPaymentHandler(paymentService)
+	exchangeRateHandler := handlers.NewExchangeRateHandler(exchangeRateService)
+	feeHandler := handlers.NewFeeHandler(feeService)
+	ledgerHandler := handlers.NewLedgerHandler(ledgerService)
+	disputeHandler := handlers.NewDisputeHandler(disputeService)
+	settingHandler := handlers.NewSettingHandler(settingService)
+	invoiceHandler := handlers.NewInvoiceHandler(invoiceService)
+
+	// Route-level permission checks resolve through the policy layer
+	middlewares.SetPermissionChecker(policyService)
+
+	// Access tokens from signed-out sessions are rejected before they expire
+	middlewares.SetSessionChecker(sessionService)
+
+	// Start background jobs
+	jobs.Every("account-deletion", time.Hour, accountService.ProcessDueDeletions)
+	jobs.Every("reputation-prune", 24*time.Hour, reputationService.PruneDaily)
+	jobs.Every("auction-close", time.Minute, transactionService.CloseEndedAuctions)
+	jobs.Every("escrow-release", time.Hour, transactionService.ReleaseDueEscrow)
+	jobs.Every("dispute-deadlines", time.Hour, disputeService.EscalateOverdue)
+	jobs.Every("exchange-rates", config.GetExchangeRateConfig().RefreshInterval, exchangeRateService.RefreshRates)
+	jobs.Every("payouts", config.GetMarketplaceConfig().PayoutInterval, ledgerService.RunPayouts)
+	jobs.Every("ledger-reconcile", 24*time.Hour, ledgerService.CheckReconciliation)
 
 	// Initialize Gin router
 	router := gin.Default()
@@ -63,52 +178,39 @@ func main() {
 	router.Use(middlewares.Logger())
 	router.Use(middlewares.Recovery())
 
+	// Serve uploaded public images such as avatars
+	router.Static(storageConfig.PublicURLPath, publicStorage.Root())
+
 	// Set up API routes
-	api := router.Group("/api")
-	{
-		// Auth routes
-		auth := api.Group("/auth")
-		{
-			auth.POST("/register", authHandler.Register)
-			auth.POST("/login", authHandler.Login)
-			auth.POST("/refresh", authHandler.RefreshToken)
-		}
-
-		// User routes
-		users := api.Group("/users")
-		users.Use(middlewares.Auth()) // Require authentication
-		{
-			users.GET("/me", userHandler.GetProfile)
-			users.PUT("/me", userHandler.UpdateProfile)
-			users.GET("/:id", userHandler.GetUser)
-			users.GET("/:id/listings", userHandler.GetUserListings)
-			users.GET("/:id/bids", userHandler.GetUserBids)
-		}
-
-		// Listing routes
-		listings := api.Group("/listings")
-		{
-			listings.GET("", listingHandler.GetListings)
-			listings.GET("/:id", listingHandler.GetListing)
-			
-			// Protected routes
-			authenticated := listings.Group("")
-			authenticated.Use(middlewares.Auth())
-			{
-				authenticated.POST("", listingHandler.CreateListing)
-				authenticated.PUT("/:id", listingHandler.UpdateListing)
-				authenticated.DELETE("/:id", listingHandler.DeleteListing)
-				authenticated.POST("/:id/bids", bidHandler.PlaceBid)
-			}
-		}
-
-		// Category routes
-		categories := api.Group("/categories")
-		{
-			categories.GET("", listingHandler.GetCategories)
-			categories.GET("/:id/listings", listingHandler.GetListingsByCategory)
-		}
-	}
+	routes.SetupRoutes(router, &handlers.Handlers{
+		AuthHandler:      authHandler,
+		UserHandler:      userHandler,
+		ListingHandler:   listingHandler,
+		BidHandler:       bidHandler,
+		TwoFactorHandler: twoFactorHandler,
+		OIDCHandler:      oidcHandler,
+		LockoutHandler:   lockoutHandler,
+		RoleHandler:      roleHandler,
+		WellKnownHandler: wellKnownHandler,
+
+		NotificationHandler: notificationHandler,
+		VerificationHandler: verificationHandler,
+		SessionHandler:      sessionHandler,
+		AccountHandler:      accountHandler,
+		AddressHandler:      addressHandler,
+		FeedbackHandler:     feedbackHandler,
+		MessageHandler:      messageHandler,
+		QuestionHandler:     questionHandler,
+		ModerationHandler:   moderationHandler,
+		TransactionHandler:  transactionHandler,
+		PaymentHandler:      paymentHandler,
+		ExchangeRateHandler: exchangeRateHandler,
+		FeeHandler:          feeHandler,
+		LedgerHandler:       ledgerHandler,
+		DisputeHandler:      disputeHandler,
+		SettingHandler:      settingHandler,
+		InvoiceHandler:      invoiceHandler,
+	})
 
 	// Add health check endpoint
 	router.GET("/health", func(c *gin.Context) {
@@ -128,3 +230,13 @@ func main() {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// newPaymentProvider creates the configured payment gateway
+func newPaymentProvider(cfg *config.PaymentConfig) (payments.PaymentProvider, error) {
+	switch cfg.Provider {
+	case "fake":
+		return payments.NewFakeProvider(cfg.WebhookSecret, cfg.WebhookTolerance)
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", cfg.Provider)
+	}
+}
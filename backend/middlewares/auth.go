@@ -9,10 +9,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/jimsyyap/auctions/backend/models"
 )
 
-var jwtKey = []byte("your_secret_key") // In production, use an environment variable
-
 // Auth middleware for JWT token validation
 func Auth() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -35,10 +34,20 @@ func Auth() gin.HandlerFunc {
 		tokenString := parts[1]
 		claims := &Claims{}
 
-		// Parse and validate the token
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return jwtKey, nil
-		})
+		if keys == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Token verification is not configured"})
+			c.Abort()
+			return
+		}
+
+		// Parse and validate the token, pinning the algorithm to the key's
+		// and enforcing issuer and audience
+		token, err := jwt.ParseWithClaims(tokenString, claims, keys.lookupKey,
+			jwt.WithValidMethods(keys.validMethods()),
+			jwt.WithIssuer(keys.issuer),
+			jwt.WithAudience(keys.audience),
+			jwt.WithExpirationRequired(),
+		)
 
 		if err != nil {
 			if errors.Is(err, jwt.ErrSignatureInvalid) {
@@ -64,38 +73,153 @@ func Auth() gin.HandlerFunc {
 			return
 		}
 
+		// Reject tokens from sessions the user has signed out remotely
+		if claims.SessionID != 0 && sessionChecker != nil {
+			if sessionChecker.IsSessionRevoked(claims.SessionID) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has been signed out"})
+				c.Abort()
+				return
+			}
+			sessionChecker.RecordActivity(claims.SessionID, c.ClientIP())
+		}
+
 		// Set claims data to context
 		c.Set("user_id", claims.UserID)
+		c.Set("session_id", claims.SessionID)
 		c.Set("username", claims.Username)
 		c.Set("is_admin", claims.IsAdmin)
+		c.Set("email_verified", claims.EmailVerified)
+		c.Set("two_factor_setup_required", claims.TwoFactorSetupRequired)
+
+		c.Next()
+	}
+}
+
+// SessionChecker answers whether a login session has been revoked. It is
+// consulted on every authenticated request, so implementations must answer
+// from memory rather than querying the database each time.
+type SessionChecker interface {
+	IsSessionRevoked(sessionID uint) bool
+	RecordActivity(sessionID uint, ip string)
+}
+
+var sessionChecker SessionChecker
+
+// SetSessionChecker installs the checker used by Auth. It must be called
+// once at startup.
+func SetSessionChecker(checker SessionChecker) {
+	sessionChecker = checker
+}
+
+// RequireVerifiedEmail blocks accounts that have not confirmed their email.
+// It must run after Auth.
+func RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool("email_verified") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Please verify your email address first"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// PermissionChecker resolves a user's current permissions. Checking on each
+// request, rather than trusting token claims, lets role changes apply
+// without waiting for tokens to expire.
+type PermissionChecker interface {
+	HasPermission(userID uint, permission string) (bool, error)
+}
+
+var permissionChecker PermissionChecker
+
+// SetPermissionChecker installs the checker used by RequirePermission. It
+// must be called once at startup.
+func SetPermissionChecker(checker PermissionChecker) {
+	permissionChecker = checker
+}
+
+// RequirePermission restricts a route to users holding the given permission.
+// It must run after Auth.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if permissionChecker == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Authorization is not configured"})
+			c.Abort()
+			return
+		}
+
+		allowed, err := permissionChecker.HasPermission(c.GetUint("user_id"), permission)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+			c.Abort()
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to perform this action"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
 
+// RequireTwoFactorCompliance blocks accounts that must enrol in 2FA under
+// the security policy but have not done so yet. It must run after Auth.
+func RequireTwoFactorCompliance() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetBool("two_factor_setup_required") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Two-factor authentication is required for your account"})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
 
 // Claims represents JWT claims
 type Claims struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
-	IsAdmin  bool   `json:"is_admin"`
+	UserID        uint   `json:"user_id"`
+	Username      string `json:"username"`
+	IsAdmin       bool   `json:"is_admin"`
+	EmailVerified bool   `json:"email_verified"`
+	// The login session the token was issued for
+	SessionID uint `json:"sid,omitempty"`
+	// Set when policy requires 2FA but the user has not enrolled yet
+	TwoFactorSetupRequired bool `json:"tfa_setup_required,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken creates a new JWT token
-func GenerateToken(userID uint, username string, isAdmin bool) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour) // Token valid for 24 hours
+// TokenOptions carries per-login details that are not stored on the user
+type TokenOptions struct {
+	SessionID              uint
+	TwoFactorSetupRequired bool
+}
+
+// GenerateToken creates a new JWT access token that expires at expirationTime
+func GenerateToken(user *models.User, opts TokenOptions, expirationTime time.Time) (string, error) {
+	if keys == nil {
+		return "", errors.New("signing keys not loaded")
+	}
 
 	claims := &Claims{
-		UserID:   userID,
-		Username: username,
-		IsAdmin:  isAdmin,
+		UserID:                 user.ID,
+		Username:               user.Username,
+		IsAdmin:                user.IsAdmin,
+		EmailVerified:          user.IsEmailVerified,
+		SessionID:              opts.SessionID,
+		TwoFactorSetupRequired: opts.TwoFactorSetupRequired,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    keys.issuer,
+			Audience:  jwt.ClaimStrings{keys.audience},
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtKey)
+	token := jwt.NewWithClaims(keys.active.Method, claims)
+	token.Header["kid"] = keys.active.ID
+	return token.SignedString(keys.active.SignKey)
 }
@@ -0,0 +1,217 @@
+// middlewares/jwt_keys.go
+package middlewares
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jimsyyap/auctions/backend/config"
+)
+
+// signingKey is a single entry in the key ring
+type signingKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	SignKey   interface{} // nil for verify-only keys
+	VerifyKey interface{}
+}
+
+// keyRing holds every key that may verify tokens and the one used to sign them
+type keyRing struct {
+	issuer   string
+	audience string
+	active   *signingKey
+	keys     map[string]*signingKey
+}
+
+var keys *keyRing
+
+// LoadSigningKeys reads signing keys from configuration. It must be called
+// once at startup before tokens are issued or verified.
+func LoadSigningKeys() error {
+	cfg, err := config.GetJWTConfig()
+	if err != nil {
+		return err
+	}
+
+	ring := &keyRing{
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		keys:     make(map[string]*signingKey),
+	}
+
+	if len(cfg.Keys) == 0 {
+		// Without configured keys, fall back to an ephemeral secret so that we
+		// never sign with a guessable key. Tokens will not survive a restart.
+		log.Printf("Warning: no JWT keys configured, using an ephemeral HS256 key")
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+		key := &signingKey{ID: "ephemeral", Method: jwt.SigningMethodHS256, SignKey: secret, VerifyKey: secret}
+		ring.keys[key.ID] = key
+		ring.active = key
+		keys = ring
+		return nil
+	}
+
+	for _, kc := range cfg.Keys {
+		key, err := parseSigningKey(kc)
+		if err != nil {
+			return fmt.Errorf("jwt key %q: %w", kc.ID, err)
+		}
+		if _, dup := ring.keys[key.ID]; dup {
+			return fmt.Errorf("duplicate jwt key id %q", key.ID)
+		}
+		ring.keys[key.ID] = key
+	}
+
+	active, ok := ring.keys[cfg.ActiveKeyID]
+	if !ok {
+		return fmt.Errorf("active jwt key %q is not configured", cfg.ActiveKeyID)
+	}
+	if active.SignKey == nil {
+		return fmt.Errorf("active jwt key %q has no private key", cfg.ActiveKeyID)
+	}
+	ring.active = active
+
+	keys = ring
+	return nil
+}
+
+// parseSigningKey turns a configured key into signing and verification keys
+func parseSigningKey(kc config.JWTKeyConfig) (*signingKey, error) {
+	key := &signingKey{ID: kc.ID}
+
+	switch kc.Algorithm {
+	case "HS256":
+		if len(kc.Source) < 32 {
+			return nil, errors.New("HS256 secret must be at least 32 bytes")
+		}
+		key.Method = jwt.SigningMethodHS256
+		key.SignKey = []byte(kc.Source)
+		key.VerifyKey = []byte(kc.Source)
+
+	case "RS256":
+		pem, err := os.ReadFile(kc.Source)
+		if err != nil {
+			return nil, err
+		}
+		key.Method = jwt.SigningMethodRS256
+		if private, err := jwt.ParseRSAPrivateKeyFromPEM(pem); err == nil {
+			key.SignKey = private
+			key.VerifyKey = &private.PublicKey
+		} else if public, err := jwt.ParseRSAPublicKeyFromPEM(pem); err == nil {
+			key.VerifyKey = public
+		} else {
+			return nil, errors.New("file does not contain an RSA key")
+		}
+
+	case "EdDSA":
+		pem, err := os.ReadFile(kc.Source)
+		if err != nil {
+			return nil, err
+		}
+		key.Method = jwt.SigningMethodEdDSA
+		if private, err := jwt.ParseEdPrivateKeyFromPEM(pem); err == nil {
+			key.SignKey = private
+			key.VerifyKey = private.(crypto.Signer).Public()
+		} else if public, err := jwt.ParseEdPublicKeyFromPEM(pem); err == nil {
+			key.VerifyKey = public
+		} else {
+			return nil, errors.New("file does not contain an Ed25519 key")
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", kc.Algorithm)
+	}
+
+	return key, nil
+}
+
+// lookupKey is the jwt.Keyfunc used by Auth. It selects the key by kid and
+// rejects tokens whose alg header does not match that key.
+func (r *keyRing) lookupKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := r.keys[kid]
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.VerifyKey, nil
+}
+
+// validMethods lists the algorithms of all configured keys
+func (r *keyRing) validMethods() []string {
+	seen := make(map[string]bool)
+	var methods []string
+	for _, key := range r.keys {
+		if alg := key.Method.Alg(); !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+	return methods
+}
+
+// JWK is a single JSON Web Key as published in the JWKS document
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet is the document served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWKS returns the public halves of all asymmetric keys. Shared
+// secrets are never published.
+func PublicJWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	if keys == nil {
+		return set
+	}
+
+	for _, key := range keys.keys {
+		switch public := key.VerifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA",
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				Kid: key.ID,
+				N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP",
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				Kid: key.ID,
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+
+	return set
+}
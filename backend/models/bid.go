@@ -2,6 +2,7 @@
 package models
 
 import (
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
@@ -9,7 +10,7 @@ import (
 
 type Bid struct {
 	gorm.Model
-	Amount      float64   `gorm:"not null"`
+	Amount      Money     `gorm:"embedded;embeddedPrefix:amount_"`
 	PlacedAt    time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
 	
 	// Relationships
@@ -19,8 +20,17 @@ type Bid struct {
 	Listing     Listing   `gorm:"foreignKey:ListingID"`
 }
 
-// BeforeCreate sets the PlacedAt field to the current time
+// BeforeCreate sets the PlacedAt field to the current time and makes sure
+// the bid is in the listing's currency
 func (b *Bid) BeforeCreate(tx *gorm.DB) error {
 	b.PlacedAt = time.Now()
+
+	var currency string
+	if err := tx.Model(&Listing{}).Select("currency").Where("id = ?", b.ListingID).Scan(&currency).Error; err != nil {
+		return err
+	}
+	if b.Amount.Currency != currency {
+		return fmt.Errorf("bids on this listing must be in %s", currency)
+	}
 	return nil
 }
@@ -0,0 +1,86 @@
+// models/dispute.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Dispute reasons
+const (
+	DisputeNotReceived    = "item_not_received"
+	DisputeNotAsDescribed = "not_as_described"
+	DisputeDamaged        = "damaged"
+	DisputeOther          = "other"
+)
+
+// Dispute statuses
+const (
+	DisputeAwaitingSeller = "awaiting_seller" // the seller has until SellerRespondBy to respond
+	DisputeUnderReview    = "under_review"    // waiting for an admin to resolve it
+	DisputeResolved       = "resolved"
+)
+
+// Dispute resolutions
+const (
+	DisputeFullRefund    = "full_refund"
+	DisputePartialRefund = "partial_refund"
+	DisputeNoAction      = "no_action"
+)
+
+// Who wrote a dispute message. System messages record what happened to
+// the case.
+const (
+	DisputeAuthorBuyer  = "buyer"
+	DisputeAuthorSeller = "seller"
+	DisputeAuthorAdmin  = "admin"
+	DisputeAuthorSystem = "system"
+)
+
+// Dispute is a case the buyer opens when a sale goes wrong. The seller
+// responds, then an admin decides whether the buyer gets their money back.
+// While the case is open the buyer's payment is frozen.
+type Dispute struct {
+	gorm.Model
+	Reason            string    `gorm:"size:30;not null"`
+	Status            string    `gorm:"size:20;index;not null;default:'awaiting_seller'"`
+	SellerRespondBy   time.Time `gorm:"index"`
+	SellerRespondedAt *time.Time
+
+	// Set when an admin resolves the case. RefundAmount is what the buyer
+	// got back.
+	Resolution     string `gorm:"size:20"`
+	RefundAmount   Money  `gorm:"embedded;embeddedPrefix:refund_"`
+	ResolutionNote string `gorm:"type:text"`
+	ResolvedAt     *time.Time
+	ResolvedByID   *uint
+
+	// Relationships
+	TransactionID uint             `gorm:"uniqueIndex;not null"` // a sale can be disputed once
+	Transaction   Transaction      `gorm:"foreignKey:TransactionID" json:"-"`
+	Messages      []DisputeMessage `gorm:"foreignKey:DisputeID"`
+}
+
+// DisputeMessage is one entry in a dispute's timeline
+type DisputeMessage struct {
+	gorm.Model
+	AuthorRole string `gorm:"size:10;not null"`
+	AuthorID   *uint  // nil for system messages
+	Body       string `gorm:"type:text"`
+
+	DisputeID uint              `gorm:"index;not null"`
+	Evidence  []DisputeEvidence `gorm:"foreignKey:MessageID"`
+}
+
+// DisputeEvidence is a photo or document sent with a dispute message,
+// kept in private storage
+type DisputeEvidence struct {
+	gorm.Model
+	StorageKey   string `gorm:"not null" json:"-"`
+	OriginalName string
+	ContentType  string
+	Size         int64
+
+	MessageID uint `gorm:"index;not null"`
+}
@@ -0,0 +1,24 @@
+// models/exchange_rate.go
+package models
+
+import (
+	"time"
+)
+
+// Sources of exchange rates
+const (
+	RateSourceAdmin = "admin"
+	RateSourceFeed  = "feed"
+)
+
+// ExchangeRate is how many units of a currency one unit of DefaultCurrency
+// buys. Rates are only used to show approximate prices and to compare
+// amounts against limits set in DefaultCurrency; stored amounts are never
+// converted.
+type ExchangeRate struct {
+	Currency    string    `gorm:"primaryKey;size:3" json:"currency"`
+	Rate        float64   `gorm:"not null" json:"rate"`
+	Source      string    `gorm:"size:10;not null" json:"source"` // admin or feed
+	UpdatedByID *uint     `json:"updated_by_id,omitempty"`        // the admin who last set it
+	UpdatedAt   time.Time `json:"updated_at"`
+}
@@ -0,0 +1,18 @@
+// models/external_identity.go
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// ExternalIdentity links an account at an OpenID Connect provider to a user
+type ExternalIdentity struct {
+	gorm.Model
+	Provider string `gorm:"uniqueIndex:idx_external_identity;not null"`
+	Subject  string `gorm:"uniqueIndex:idx_external_identity;not null"` // the provider's "sub" claim
+	Email    string
+
+	// Relationships
+	UserID uint `gorm:"index;not null"`
+	User   User `gorm:"foreignKey:UserID"`
+}
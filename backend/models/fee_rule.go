@@ -0,0 +1,48 @@
+// models/fee_rule.go
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// Fee kinds
+const (
+	FeeInsertion  = "insertion"   // charged to the seller when a listing goes live
+	FeeFinalValue = "final_value" // taken from the seller's proceeds of a sale
+)
+
+// FeeRule sets a fee for prices in a band, in one currency, optionally for
+// one category. The fee is Rate of the price plus FixedFee. When several
+// rules match, a category rule beats one for every category, and a
+// narrower band beats a wider one.
+type FeeRule struct {
+	gorm.Model
+	Kind       string  `gorm:"size:20;not null;index" json:"kind"` // insertion or final_value
+	CategoryID *uint   `gorm:"index" json:"category_id"`           // nil for every category
+	MinPrice   Money   `gorm:"embedded;embeddedPrefix:min_price_" json:"min_price"`
+	MaxPrice   Money   `gorm:"embedded;embeddedPrefix:max_price_" json:"max_price"` // zero for no upper bound
+	Rate       float64 `gorm:"not null;default:0" json:"rate"`
+	FixedFee   Money   `gorm:"embedded;embeddedPrefix:fixed_fee_" json:"fixed_fee"`
+
+	// Relationships
+	Category *Category `gorm:"foreignKey:CategoryID" json:"-"`
+}
+
+// Currency returns the currency of the rule's band and fixed fee
+func (r *FeeRule) Currency() string {
+	return r.MinPrice.Currency
+}
+
+// Matches reports whether the rule applies to a price. The band includes
+// its minimum and excludes its maximum.
+func (r *FeeRule) Matches(price Money) bool {
+	if price.Currency != r.Currency() || price.Cmp(r.MinPrice) < 0 {
+		return false
+	}
+	return r.MaxPrice.IsZero() || price.Cmp(r.MaxPrice) < 0
+}
+
+// Fee returns the rule's fee on a price
+func (r *FeeRule) Fee(price Money) Money {
+	return price.ApplyRate(r.Rate).Add(r.FixedFee)
+}
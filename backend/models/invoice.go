@@ -0,0 +1,33 @@
+// models/invoice.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Invoice is the PDF invoice issued for a completed sale. The PDF is
+// generated once and kept in storage; Checksum lets every later download
+// prove it is the document that was issued.
+type Invoice struct {
+	gorm.Model
+	Number     string    `gorm:"size:50;not null"`
+	Sequence   int64     `gorm:"not null;uniqueIndex:idx_invoice_seller_sequence"` // numbers each seller's invoices 1, 2, 3...
+	IssuedAt   time.Time `gorm:"not null"`
+	StorageKey string    `gorm:"not null"`
+	Checksum   string    `gorm:"size:64;not null"` // hex SHA-256 of the PDF
+	Size       int64     `gorm:"not null"`
+
+	// Relationships
+	TransactionID uint        `gorm:"uniqueIndex;not null"` // a sale is invoiced once
+	Transaction   Transaction `gorm:"foreignKey:TransactionID" json:"-"`
+	SellerID      uint        `gorm:"not null;uniqueIndex:idx_invoice_seller_sequence"`
+	BuyerID       uint        `gorm:"index;not null"`
+}
+
+// InvoiceSequence holds the last invoice sequence used for a seller
+type InvoiceSequence struct {
+	SellerID uint  `gorm:"primaryKey;autoIncrement:false"`
+	Last     int64 `gorm:"not null;default:0"`
+}
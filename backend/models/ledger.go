@@ -0,0 +1,92 @@
+// models/ledger.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Ledger account types. Buyer and seller accounts belong to a user; the
+// others belong to the platform. Money enters the ledger from buyer
+// accounts and leaves it through the payouts account, so buyer balances
+// are negative and the payouts balance is positive.
+const (
+	AccountBuyer           = "buyer"            // payments received from a buyer, less refunds
+	AccountSeller          = "seller"           // what the platform owes a seller
+	AccountEscrow          = "escrow"           // buyer payments held until the sale settles
+	AccountPlatformRevenue = "platform_revenue" // fees earned
+	AccountTax             = "tax"              // sales tax collected and owed on
+	AccountPayouts         = "payouts"          // money paid out to sellers
+)
+
+// Ledger entry kinds
+const (
+	EntryPayment      = "payment"
+	EntryRefund       = "refund"
+	EntrySettlement   = "settlement"
+	EntryInsertionFee = "insertion_fee"
+	EntryPayout       = "payout"
+)
+
+// LedgerAccount is one account in the double-entry ledger, in one
+// currency. Its balance is kept alongside its postings so it can be read
+// without summing them; reconciliation checks that the two agree.
+type LedgerAccount struct {
+	gorm.Model
+	Type         string `gorm:"size:20;not null;uniqueIndex:idx_ledger_account"`
+	UserID       uint   `gorm:"not null;default:0;uniqueIndex:idx_ledger_account"` // 0 for platform accounts
+	Currency     string `gorm:"size:3;not null;uniqueIndex:idx_ledger_account"`
+	BalanceMinor int64  `gorm:"not null;default:0"`
+}
+
+// Balance returns the account's balance
+func (a *LedgerAccount) Balance() Money {
+	return NewMoney(a.BalanceMinor, a.Currency)
+}
+
+// LedgerEntry records one movement of money as a set of postings that sum
+// to zero in each currency. Reference is unique, so an event that is
+// recorded twice only moves money once.
+type LedgerEntry struct {
+	gorm.Model
+	Kind          string `gorm:"size:20;not null;index"`
+	Reference     string `gorm:"size:100;uniqueIndex;not null"` // such as payment:12
+	Description   string
+	TransactionID *uint `gorm:"index"`
+	ListingID     *uint `gorm:"index"`
+	PayoutID      *uint `gorm:"index"`
+
+	Postings []LedgerPosting `gorm:"foreignKey:EntryID"`
+}
+
+// LedgerPosting adds Amount to an account. Money moves from one account to
+// another as a negative posting to the first and a positive one to the
+// second.
+type LedgerPosting struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	EntryID   uint          `gorm:"index;not null"`
+	AccountID uint          `gorm:"index;not null"`
+	Account   LedgerAccount `gorm:"foreignKey:AccountID"`
+	Amount    Money         `gorm:"embedded;embeddedPrefix:amount_"`
+}
+
+// Payout statuses
+const (
+	PayoutPaid = "paid"
+)
+
+// Payout is a transfer of a seller's balance to the seller. Each payout
+// batches the transactions settled since the seller's previous one.
+type Payout struct {
+	gorm.Model
+	Amount Money  `gorm:"embedded;embeddedPrefix:amount_"`
+	Status string `gorm:"size:20;not null;default:'paid'"`
+	PaidAt *time.Time
+
+	// Relationships
+	SellerID     uint          `gorm:"index;not null"`
+	Seller       User          `gorm:"foreignKey:SellerID" json:"-"`
+	Transactions []Transaction `gorm:"foreignKey:PayoutID"`
+}
@@ -11,9 +11,11 @@ type Listing struct {
 	gorm.Model
 	Title        string    `gorm:"not null"`
 	Description  string    `gorm:"type:text"`
-	StartPrice   float64   `gorm:"not null"`
-	ReservePrice float64
-	BuyNowPrice  float64
+	Currency     string    `gorm:"size:3;not null;default:'USD'"` // every price and bid is in this currency
+	StartPrice   Money     `gorm:"embedded;embeddedPrefix:start_price_"`
+	ReservePrice Money     `gorm:"embedded;embeddedPrefix:reserve_price_"` // zero for no reserve
+	BuyNowPrice  Money     `gorm:"embedded;embeddedPrefix:buy_now_price_"` // zero when Buy Now is not offered
+	ShippingPrice Money    `gorm:"embedded;embeddedPrefix:shipping_price_"`
 	Status       string    `gorm:"default:'active'"`  // active, ended, sold
 	EndTime      time.Time
 	
@@ -22,14 +24,14 @@ type Listing struct {
 	
 	// Relationships
 	UserID       uint
-	User         User       `gorm:"foreignKey:UserID"`
+	User         User       `gorm:"foreignKey:UserID" json:"-"`
 	Bids         []Bid      `gorm:"foreignKey:ListingID"`
 	Images       []Image    `gorm:"foreignKey:ListingID"`
 	Ratings      []Rating   `gorm:"foreignKey:ListingID"`
 }
 
 // GetCurrentPrice returns the current highest bid amount or the start price if no bids
-func (l *Listing) GetCurrentPrice() float64 {
+func (l *Listing) GetCurrentPrice() Money {
 	if len(l.Bids) == 0 {
 		return l.StartPrice
 	}
@@ -37,7 +39,7 @@ func (l *Listing) GetCurrentPrice() float64 {
 	// Find the highest bid
 	highestBid := l.Bids[0]
 	for _, bid := range l.Bids {
-		if bid.Amount > highestBid.Amount {
+		if bid.Amount.Cmp(highestBid.Amount) > 0 {
 			highestBid = bid
 		}
 	}
@@ -48,5 +50,5 @@ func (l *Listing) GetCurrentPrice() float64 {
 // IsReserveReached checks if the reserve price has been reached
 func (l *Listing) IsReserveReached() bool {
 	currentPrice := l.GetCurrentPrice()
-	return currentPrice >= l.ReservePrice
+	return currentPrice.Cmp(l.ReservePrice) >= 0
 }
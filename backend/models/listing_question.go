@@ -0,0 +1,32 @@
+// models/listing_question.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ListingQuestion is a question a user asked a seller about a listing. The
+// seller can publish answered questions on the listing page.
+type ListingQuestion struct {
+	gorm.Model
+	Question    string `gorm:"type:text;not null"`
+	Answer      string `gorm:"type:text"`
+	AnsweredAt  *time.Time
+	IsPublished bool `gorm:"default:false"`
+	PublishedAt *time.Time
+	// Held while the question or the latest answer awaits moderation
+	ModerationStatus string `gorm:"size:10;default:''"`
+
+	// Relationships
+	ListingID uint    `gorm:"index;not null"`
+	Listing   Listing `gorm:"foreignKey:ListingID" json:"-"`
+	AskerID   uint    `gorm:"index;not null"`
+	Asker     User    `gorm:"foreignKey:AskerID" json:"-"`
+}
+
+// IsAnswered reports whether the seller has answered the question
+func (q *ListingQuestion) IsAnswered() bool {
+	return q.AnsweredAt != nil
+}
@@ -0,0 +1,32 @@
+// models/login_throttle.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Kinds of login throttle keys
+const (
+	ThrottleKindUsername = "username"
+	ThrottleKindIP       = "ip"
+)
+
+// LoginThrottle tracks failed login attempts for one username or client IP.
+// Usernames are tracked whether or not an account exists, so throttling
+// behaves identically for both and cannot reveal registered usernames.
+type LoginThrottle struct {
+	gorm.Model
+	Kind          string `gorm:"uniqueIndex:idx_login_throttle_key;not null"`
+	Value         string `gorm:"uniqueIndex:idx_login_throttle_key;not null"`
+	Failures      int    `gorm:"default:0"`
+	LastFailureAt *time.Time
+	NextAttemptAt *time.Time // progressive delay between attempts
+	LockedUntil   *time.Time
+}
+
+// IsLocked reports whether the key is currently locked out
+func (t *LoginThrottle) IsLocked(now time.Time) bool {
+	return t.LockedUntil != nil && now.Before(*t.LockedUntil)
+}
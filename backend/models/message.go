@@ -0,0 +1,70 @@
+// models/message.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MessageThread is a private conversation about a listing between its
+// seller and one other user
+type MessageThread struct {
+	gorm.Model
+	LastMessageAt time.Time `gorm:"index"`
+
+	// Set when a participant blocks further messages
+	BlockedByID *uint
+	BlockedAt   *time.Time
+
+	// Set when a participant reports the thread to the admins
+	ReportedByID *uint
+	ReportedAt   *time.Time `gorm:"index"`
+	ReportReason string     `gorm:"type:text"`
+
+	// Relationships
+	ListingID uint    `gorm:"not null;uniqueIndex:idx_thread_listing_buyer"`
+	Listing   Listing `gorm:"foreignKey:ListingID" json:"-"`
+	SellerID  uint    `gorm:"index;not null"`
+	Seller    User    `gorm:"foreignKey:SellerID" json:"-"`
+	BuyerID   uint    `gorm:"not null;uniqueIndex:idx_thread_listing_buyer"` // the prospective or actual buyer
+	Buyer     User    `gorm:"foreignKey:BuyerID" json:"-"`
+}
+
+// HasParticipant reports whether a user is the seller or buyer of the thread
+func (t *MessageThread) HasParticipant(userID uint) bool {
+	return userID == t.SellerID || userID == t.BuyerID
+}
+
+// OtherParticipant returns the participant who is not userID
+func (t *MessageThread) OtherParticipant(userID uint) uint {
+	if userID == t.SellerID {
+		return t.BuyerID
+	}
+	return t.SellerID
+}
+
+// Message is one message in a thread
+type Message struct {
+	gorm.Model
+	Body             string `gorm:"type:text"`
+	ReadAt           *time.Time
+	ModerationStatus string `gorm:"size:10;default:''"` // held messages are only shown to the sender
+
+	// Relationships
+	ThreadID    uint                `gorm:"index;not null"`
+	Thread      MessageThread       `gorm:"foreignKey:ThreadID" json:"-"`
+	SenderID    uint                `gorm:"not null"`
+	Sender      User                `gorm:"foreignKey:SenderID" json:"-"`
+	RecipientID uint                `gorm:"index;not null"`
+	Recipient   User                `gorm:"foreignKey:RecipientID" json:"-"`
+	Attachments []MessageAttachment `gorm:"foreignKey:MessageID"`
+}
+
+// MessageAttachment is an image sent with a message, kept in private storage
+type MessageAttachment struct {
+	gorm.Model
+	StorageKey string `gorm:"not null" json:"-"`
+
+	MessageID uint `gorm:"index;not null"`
+}
@@ -0,0 +1,93 @@
+// models/moderation.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Kinds of user-written content that go through moderation
+const (
+	ContentListing  = "listing"  // listing descriptions
+	ContentMessage  = "message"  // messages between buyers and sellers
+	ContentQuestion = "question" // questions asked about listings
+	ContentAnswer   = "answer"   // sellers' answers to questions
+)
+
+// Moderation checks
+const (
+	CheckBannedWords     = "banned_words"
+	CheckContactDetails  = "contact_details"
+	CheckSuspiciousLinks = "suspicious_links"
+)
+
+// Moderation actions, from least to most severe. Allow is only recorded
+// in decisions; rules choose between mask, hold and reject.
+const (
+	ModerationAllow  = "allow"
+	ModerationMask   = "mask"   // the matches are replaced before the content is stored
+	ModerationHold   = "hold"   // the content is stored but hidden until reviewed
+	ModerationReject = "reject" // the content is refused
+)
+
+// Moderation status of stored content. Visible content has an empty status.
+const (
+	ModerationStatusHeld     = "held"
+	ModerationStatusRejected = "rejected"
+)
+
+// Review outcomes of held content
+const (
+	ModerationApproved = "approved"
+	ModerationRejected = "rejected"
+)
+
+// DefaultModerationRules is the action each check starts with for each
+// kind of content, by content type and check
+var DefaultModerationRules = map[string]map[string]string{
+	ContentListing:  {CheckBannedWords: ModerationHold, CheckContactDetails: ModerationMask, CheckSuspiciousLinks: ModerationHold},
+	ContentMessage:  {CheckBannedWords: ModerationMask, CheckContactDetails: ModerationMask, CheckSuspiciousLinks: ModerationHold},
+	ContentQuestion: {CheckBannedWords: ModerationMask, CheckContactDetails: ModerationMask, CheckSuspiciousLinks: ModerationReject},
+	ContentAnswer:   {CheckBannedWords: ModerationMask, CheckContactDetails: ModerationMask, CheckSuspiciousLinks: ModerationHold},
+}
+
+// ModerationRule sets what happens when a check matches a kind of content
+type ModerationRule struct {
+	gorm.Model
+	ContentType string `gorm:"not null;uniqueIndex:idx_moderation_rule"`
+	Check       string `gorm:"column:check_name;not null;uniqueIndex:idx_moderation_rule"`
+	Action      string `gorm:"not null"`
+	Enabled     bool
+}
+
+// BannedWord is a word or phrase the banned words check looks for
+type BannedWord struct {
+	gorm.Model
+	Word string `gorm:"uniqueIndex;not null"`
+}
+
+// ModerationDecision is the audit record of one moderation decision
+type ModerationDecision struct {
+	gorm.Model
+	ContentType string `gorm:"index;not null"`
+	ContentID   uint   `gorm:"index"` // 0 when rejected content was never stored
+	Action      string `gorm:"index;not null"`
+	Findings    string `gorm:"type:text"` // JSON list of what each check matched
+	Content     string `gorm:"type:text"` // the text as submitted, before masking
+
+	// Review of held content
+	Resolution   string `gorm:"index"` // approved or rejected
+	ReviewedAt   *time.Time
+	ReviewedByID *uint
+
+	// Relationships
+	AuthorID uint `gorm:"index;not null"`
+	Author   User `gorm:"foreignKey:AuthorID" json:"-"`
+}
+
+// IsPendingReview reports whether the decision held content that has not
+// been reviewed yet
+func (d *ModerationDecision) IsPendingReview() bool {
+	return d.Action == ModerationHold && d.ReviewedAt == nil
+}
@@ -0,0 +1,208 @@
+// models/money.go
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used for amounts that do not name a currency
+const DefaultCurrency = "USD"
+
+// Currencies whose minor unit is not a hundredth. All others have two
+// decimal places.
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// CurrencyExponent returns the number of decimal places of a currency
+func CurrencyExponent(currency string) int {
+	if exponent, ok := currencyExponents[currency]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// Money is an exact amount in a currency, held in the currency's minor
+// unit (cents for USD). It is embedded in models with a column prefix,
+// for example `gorm:"embedded;embeddedPrefix:start_price_"`, and appears
+// in JSON as {"amount": "12.34", "currency": "USD"} so clients never parse
+// prices as floating point.
+type Money struct {
+	Minor    int64  `gorm:"not null;default:0"`
+	Currency string `gorm:"size:3;not null;default:'USD'"`
+}
+
+// NewMoney returns an amount of minor units in a currency
+func NewMoney(minor int64, currency string) Money {
+	return Money{Minor: minor, Currency: currency}
+}
+
+// ParseMoney reads a decimal amount such as "12.34" in a currency. It is
+// exact: amounts with more decimal places than the currency has are
+// rejected rather than rounded.
+func ParseMoney(amount, currency string) (Money, error) {
+	amount = strings.TrimSpace(amount)
+	exponent := CurrencyExponent(currency)
+
+	negative := strings.HasPrefix(amount, "-")
+	amount = strings.TrimPrefix(amount, "-")
+	whole, fraction, _ := strings.Cut(amount, ".")
+	if whole == "" && fraction == "" {
+		return Money{}, fmt.Errorf("%q is not an amount", amount)
+	}
+	if len(fraction) > exponent {
+		return Money{}, fmt.Errorf("%s amounts have at most %d decimal places", currency, exponent)
+	}
+	for _, r := range whole + fraction {
+		if r < '0' || r > '9' {
+			return Money{}, fmt.Errorf("%q is not an amount", amount)
+		}
+	}
+
+	digits := whole + fraction + strings.Repeat("0", exponent-len(fraction))
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%q is too large", amount)
+	}
+	if negative {
+		minor = -minor
+	}
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+// Decimal formats the amount without its currency, such as "12.34"
+func (m Money) Decimal() string {
+	exponent := CurrencyExponent(m.Currency)
+	minor := m.Minor
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	if exponent == 0 {
+		return sign + strconv.FormatInt(minor, 10)
+	}
+
+	digits := fmt.Sprintf("%0*d", exponent+1, minor)
+	split := len(digits) - exponent
+	return sign + digits[:split] + "." + digits[split:]
+}
+
+// String formats the amount with its currency, such as "12.34 USD"
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+func (m Money) IsZero() bool {
+	return m.Minor == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.Minor > 0
+}
+
+// Add returns m + other. Both must be in the same currency; a zero amount
+// without a currency takes the other's.
+func (m Money) Add(other Money) Money {
+	currency := m.sameCurrency(other)
+	return Money{Minor: m.Minor + other.Minor, Currency: currency}
+}
+
+// Sub returns m - other, under the same rules as Add
+func (m Money) Sub(other Money) Money {
+	currency := m.sameCurrency(other)
+	return Money{Minor: m.Minor - other.Minor, Currency: currency}
+}
+
+// Cmp compares two amounts in the same currency, returning -1, 0 or +1
+func (m Money) Cmp(other Money) int {
+	m.sameCurrency(other)
+	switch {
+	case m.Minor < other.Minor:
+		return -1
+	case m.Minor > other.Minor:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// ApplyRate returns the share of the amount given by rate, such as 0.05
+// for 5%. The rate is honoured to a hundredth of a percent and the result
+// is rounded half away from zero to the nearest minor unit. The product is
+// computed exactly, so large amounts cannot overflow; a share too large to
+// hold, which takes a rate above 1, is a programming error and panics.
+func (m Money) ApplyRate(rate float64) Money {
+	basisPoints := big.NewInt(int64(math.Round(rate * 10000)))
+	product := new(big.Int).Mul(big.NewInt(m.Minor), basisPoints)
+
+	// QuoRem truncates towards zero, like / and % on integers
+	minor, remainder := new(big.Int).QuoRem(product, big.NewInt(10000), new(big.Int))
+	if remainder.Int64() >= 5000 {
+		minor.Add(minor, big.NewInt(1))
+	} else if remainder.Int64() <= -5000 {
+		minor.Sub(minor, big.NewInt(1))
+	}
+	if !minor.IsInt64() {
+		panic(fmt.Sprintf("%s at a rate of %v is out of range", m, rate))
+	}
+	return Money{Minor: minor.Int64(), Currency: m.Currency}
+}
+
+// Convert returns the amount in another currency at rate, the number of
+// units of that currency one unit of this one buys. The result is rounded
+// to the nearest minor unit and is only an approximation: it is for
+// display, never for storing.
+func (m Money) Convert(currency string, rate float64) Money {
+	scale := math.Pow10(CurrencyExponent(currency) - CurrencyExponent(m.Currency))
+	return Money{Minor: int64(math.Round(float64(m.Minor) * rate * scale)), Currency: currency}
+}
+
+// sameCurrency returns the currency shared by two amounts. Mixing
+// currencies is a programming error, so it panics rather than returning a
+// wrong amount.
+func (m Money) sameCurrency(other Money) string {
+	switch {
+	case m.Currency == other.Currency:
+		return m.Currency
+	case m.Currency == "" && m.Minor == 0:
+		return other.Currency
+	case other.Currency == "" && other.Minor == 0:
+		return m.Currency
+	}
+	panic(fmt.Sprintf("money: cannot combine %s and %s amounts", m.Currency, other.Currency))
+}
+
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Decimal(), Currency: m.Currency})
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var value moneyJSON
+	if err := json.Unmarshal(data, &value); err != nil {
+		return errors.New(`money must be an object such as {"amount": "12.34", "currency": "USD"}`)
+	}
+	if value.Currency == "" {
+		value.Currency = DefaultCurrency
+	}
+
+	parsed, err := ParseMoney(value.Amount, value.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,34 @@
+// models/notification.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Notification types
+const (
+	NotificationSecurity     = "security"
+	NotificationVerification = "verification"
+	NotificationFeedback     = "feedback"
+	NotificationMessage      = "message"
+	NotificationQuestion     = "question"
+	NotificationTransaction  = "transaction"
+	NotificationPayout       = "payout"
+	NotificationDispute      = "dispute"
+)
+
+type Notification struct {
+	gorm.Model
+	Type      string `gorm:"index;not null"`
+	Title     string `gorm:"not null"`
+	Content   string `gorm:"type:text;not null"`
+	RelatedID *uint  // ID of the entity the notification is about, if any
+	IsRead    bool   `gorm:"default:false"`
+	ReadAt    *time.Time
+
+	// Relationships
+	UserID uint `gorm:"index;not null"`
+	User   User `gorm:"foreignKey:UserID" json:"-"`
+}
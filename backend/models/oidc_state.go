@@ -0,0 +1,19 @@
+// models/oidc_state.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OIDCState holds the per-attempt secrets of an authorization-code login
+// between redirecting to the provider and handling its callback
+type OIDCState struct {
+	gorm.Model
+	StateHash    string    `gorm:"uniqueIndex;not null"`
+	Provider     string    `gorm:"not null"`
+	Nonce        string    `gorm:"not null"`
+	CodeVerifier string    `gorm:"not null"` // PKCE verifier
+	ExpiresAt    time.Time `gorm:"not null"`
+}
@@ -0,0 +1,37 @@
+// models/one_time_token.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Purposes a one-time token can be issued for
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposeTwoFactorLogin    = "two_factor_login"
+)
+
+// OneTimeToken is a single-use, expiring token sent to a user by email.
+// Only the SHA-256 hash of the token is stored.
+type OneTimeToken struct {
+	gorm.Model
+	Purpose   string    `gorm:"index;not null"`
+	TokenHash string    `gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+
+	// Wrong codes entered against a 2FA login challenge
+	FailedAttempts int `gorm:"not null;default:0"`
+
+	// Relationships
+	UserID uint `gorm:"index;not null"`
+	User   User `gorm:"foreignKey:UserID"`
+}
+
+// IsUsable reports whether the token is unused and unexpired
+func (t *OneTimeToken) IsUsable() bool {
+	return t.UsedAt == nil && time.Now().Before(t.ExpiresAt)
+}
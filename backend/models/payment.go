@@ -0,0 +1,52 @@
+// models/payment.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Payment statuses
+const (
+	PaymentPending   = "pending"
+	PaymentCompleted = "completed"
+	PaymentFailed    = "failed"
+	PaymentRefunded  = "refunded"
+	// A refund has been claimed and is being made with the provider
+	PaymentRefunding = "refunding"
+	// Part of the payment was returned after a dispute; the rest went to
+	// the seller
+	PaymentPartiallyRefunded = "partially_refunded"
+)
+
+// Payment is one attempt by a buyer to pay for a transaction through the
+// payment provider. A failed attempt can be followed by another.
+type Payment struct {
+	gorm.Model
+	Provider       string `gorm:"size:20;not null"`
+	ProviderRef    string `gorm:"uniqueIndex;not null"` // the provider's intent ID
+	Method         string `gorm:"size:20;not null"`
+	Amount         Money  `gorm:"embedded;embeddedPrefix:amount_"`
+	Escrow         bool   `gorm:"not null;default:false"` // hold the payment until delivery
+	Status         string `gorm:"size:20;index;not null;default:'pending'"`
+	FailureReason  string
+	PaidAt         *time.Time
+	RefundRef      string
+	RefundedAmount Money `gorm:"embedded;embeddedPrefix:refunded_"`
+	RefundedAt     *time.Time
+
+	// Relationships
+	TransactionID uint        `gorm:"index;not null"`
+	Transaction   Transaction `gorm:"foreignKey:TransactionID" json:"-"`
+}
+
+// PaymentEvent is a webhook from the payment provider that has been
+// processed. Providers retry webhooks, so each event is handled once.
+type PaymentEvent struct {
+	gorm.Model
+	Provider string `gorm:"size:20;not null;uniqueIndex:idx_payment_event"`
+	EventID  string `gorm:"not null;uniqueIndex:idx_payment_event"`
+	Type     string `gorm:"size:50;not null"`
+	Payload  string `gorm:"type:text"`
+}
@@ -7,14 +7,25 @@ import (
 	"gorm.io/gorm"
 )
 
+// Roles a rated user can have had in the sale
+const (
+	RatedAsSeller = "seller"
+	RatedAsBuyer  = "buyer"
+)
+
 type Rating struct {
 	gorm.Model
 	Score        int       `gorm:"not null;check:score >= 1 AND score <= 5"`
 	Comment      string
+	RatedAs      string    `gorm:"index;size:10"` // seller or buyer
 	CreatedAt    time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
 	
-	// Who gave the rating
-	RaterUserID  uint
+	// The rated user's one public reply
+	Reply        string    `gorm:"type:text"`
+	RepliedAt    *time.Time
+	
+	// Who gave the rating. Each party rates a sale at most once.
+	RaterUserID  uint      `gorm:"uniqueIndex:idx_rating_listing_rater"`
 	RaterUser    User      `gorm:"foreignKey:RaterUserID"`
 	
 	// Who received the rating
@@ -22,10 +33,22 @@ type Rating struct {
 	RatedUser    User      `gorm:"foreignKey:RatedUserID"`
 	
 	// Related to which listing
-	ListingID    uint
+	ListingID    uint      `gorm:"uniqueIndex:idx_rating_listing_rater"`
 	Listing      Listing   `gorm:"foreignKey:ListingID"`
 }
 
+// Sentiment classifies a score as positive (4-5), neutral (3) or negative (1-2)
+func Sentiment(score int) string {
+	switch {
+	case score >= 4:
+		return "positive"
+	case score == 3:
+		return "neutral"
+	default:
+		return "negative"
+	}
+}
+
 // BeforeCreate validates the rating
 func (r *Rating) BeforeCreate(tx *gorm.DB) error {
 	// Ensure score is between 1 and 5
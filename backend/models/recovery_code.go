@@ -0,0 +1,20 @@
+// models/recovery_code.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCode is a single-use backup code for two-factor authentication.
+// Only the SHA-256 hash of the code is stored.
+type RecoveryCode struct {
+	gorm.Model
+	CodeHash string `gorm:"index;not null"`
+	UsedAt   *time.Time
+
+	// Relationships
+	UserID uint `gorm:"index;not null"`
+	User   User `gorm:"foreignKey:UserID"`
+}
@@ -0,0 +1,29 @@
+// models/refresh_token.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken is a server-side record of an opaque refresh token.
+// Only the SHA-256 hash of the token is stored. Tokens issued from the same
+// login share a FamilyID so the whole chain can be revoked on reuse.
+type RefreshToken struct {
+	gorm.Model
+	TokenHash string    `gorm:"uniqueIndex;not null"`
+	FamilyID  string    `gorm:"index;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	RotatedAt *time.Time
+	RevokedAt *time.Time
+
+	// Relationships
+	UserID uint `gorm:"index;not null"`
+	User   User `gorm:"foreignKey:UserID"`
+}
+
+// IsActive reports whether the token can still be exchanged
+func (t *RefreshToken) IsActive() bool {
+	return t.RotatedAt == nil && t.RevokedAt == nil && time.Now().Before(t.ExpiresAt)
+}
@@ -0,0 +1,54 @@
+// models/reputation.go
+package models
+
+import (
+	"time"
+)
+
+// ReputationCounts holds rating totals by sentiment
+type ReputationCounts struct {
+	Positive int64 `gorm:"not null;default:0"`
+	Neutral  int64 `gorm:"not null;default:0"`
+	Negative int64 `gorm:"not null;default:0"`
+	ScoreSum int64 `gorm:"not null;default:0"` // sum of 1-5 scores, for averages
+}
+
+// Total returns the number of ratings counted
+func (c ReputationCounts) Total() int64 {
+	return c.Positive + c.Neutral + c.Negative
+}
+
+// Add adds another set of counts to this one
+func (c *ReputationCounts) Add(other ReputationCounts) {
+	c.Positive += other.Positive
+	c.Neutral += other.Neutral
+	c.Negative += other.Negative
+	c.ScoreSum += other.ScoreSum
+}
+
+// UserReputation holds the lifetime rating totals a user has received in
+// one role. It is updated whenever a rating is added, so reading a
+// reputation never scans the ratings table.
+type UserReputation struct {
+	UserID uint   `gorm:"primaryKey;autoIncrement:false"`
+	Role   string `gorm:"primaryKey;size:10"` // seller or buyer
+	ReputationCounts
+	// Disputes resolved with a refund to the buyer, counted against sellers
+	DisputesLost int64 `gorm:"not null;default:0"`
+	UpdatedAt    time.Time
+}
+
+// ReputationDaily holds one day's rating totals for a user in one role.
+// Rolling windows (30 days, 12 months) are the sum of at most a year of
+// these rows; older rows are pruned.
+type ReputationDaily struct {
+	UserID uint      `gorm:"primaryKey;autoIncrement:false"`
+	Role   string    `gorm:"primaryKey;size:10"`
+	Day    time.Time `gorm:"primaryKey;type:date"`
+	ReputationCounts
+}
+
+// TableName keeps the table name readable
+func (ReputationDaily) TableName() string {
+	return "reputation_daily"
+}
@@ -0,0 +1,51 @@
+// models/role.go
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// Built-in role names
+const (
+	RoleUser      = "user" // implicit for every account
+	RoleSeller    = "seller"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Permission names checked by middlewares.RequirePermission and the policy layer
+const (
+	PermListingCreate      = "listing:create"
+	PermListingModerate    = "listing:moderate"
+	PermBidPlace           = "bid:place"
+	PermLockoutManage      = "lockout:manage"
+	PermRoleManage         = "role:manage"
+	PermVerificationReview = "verification:review"
+	PermMessageModerate    = "message:moderate"
+	PermContentReview      = "content:review"
+	PermModerationManage   = "moderation:manage"
+	PermRatesManage        = "rates:manage"
+	PermFinanceManage      = "finance:manage"
+	PermDisputeResolve     = "dispute:resolve"
+	PermSettingsManage     = "settings:manage"
+)
+
+// DefaultRolePermissions is the permission set each built-in role is seeded with
+var DefaultRolePermissions = map[string][]string{
+	RoleUser:      {PermListingCreate, PermBidPlace},
+	RoleSeller:    {PermListingCreate, PermBidPlace},
+	RoleModerator: {PermListingCreate, PermBidPlace, PermListingModerate, PermVerificationReview, PermMessageModerate, PermContentReview, PermDisputeResolve},
+	RoleAdmin:     {PermListingCreate, PermBidPlace, PermListingModerate, PermLockoutManage, PermRoleManage, PermVerificationReview, PermMessageModerate, PermContentReview, PermModerationManage, PermRatesManage, PermFinanceManage, PermDisputeResolve, PermSettingsManage},
+}
+
+type Role struct {
+	gorm.Model
+	Name        string `gorm:"uniqueIndex;not null"`
+	Description string
+	Permissions []Permission `gorm:"many2many:role_permissions;"`
+}
+
+type Permission struct {
+	gorm.Model
+	Name string `gorm:"uniqueIndex;not null"`
+}
@@ -0,0 +1,30 @@
+// models/session.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Session is one login on one device. It owns a refresh token family and is
+// what users see, and can revoke, in their list of active sessions.
+type Session struct {
+	gorm.Model
+	FamilyID   string     `gorm:"uniqueIndex;not null" json:"-"`
+	Device     string     `json:"device"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt  *time.Time `json:"-"`
+
+	// Relationships
+	UserID uint `gorm:"index;not null" json:"-"`
+	User   User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// IsActive reports whether the session can still be used
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
@@ -0,0 +1,40 @@
+// models/system_setting.go
+package models
+
+import (
+	"time"
+)
+
+// System setting keys
+const (
+	SettingBrandName     = "brand.name"
+	SettingBrandAddress  = "brand.address"
+	SettingBrandEmail    = "brand.email"
+	SettingBrandColor    = "brand.color"
+	SettingBrandTaxID    = "brand.tax_id"
+	SettingInvoicePrefix = "invoice.number_prefix"
+	SettingInvoiceFooter = "invoice.footer"
+)
+
+// DefaultSystemSettings is the value of each setting until an admin changes it
+var DefaultSystemSettings = map[string]string{
+	SettingBrandName:     "AuctionHub",
+	SettingBrandAddress:  "",
+	SettingBrandEmail:    "",
+	SettingBrandColor:    "#1F4E79",
+	SettingBrandTaxID:    "",
+	SettingInvoicePrefix: "INV",
+	SettingInvoiceFooter: "Thank you for trading on our marketplace.",
+}
+
+// SystemSetting is a value admins can change at runtime, such as the
+// branding printed on invoices
+type SystemSetting struct {
+	Key         string    `gorm:"primaryKey;size:100" json:"key"`
+	Value       string    `gorm:"type:text;not null" json:"value"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	UpdatedByID *uint     `json:"updated_by_id,omitempty"`
+
+	// Relationships
+	UpdatedBy *User `gorm:"foreignKey:UpdatedByID" json:"-"`
+}
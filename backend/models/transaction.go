@@ -0,0 +1,163 @@
+// models/transaction.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Transaction types
+const (
+	TransactionAuctionWin = "auction_win"
+	TransactionBuyNow     = "buy_now"
+)
+
+// Transaction statuses
+const (
+	TransactionAwaitingPayment = "awaiting_payment"
+	TransactionPaid            = "paid"
+	TransactionShipped         = "shipped"
+	TransactionCompleted       = "completed"
+	TransactionCancelled       = "cancelled"
+	TransactionDisputed        = "disputed"
+)
+
+// transactionTransitions lists the statuses each status can move to
+var transactionTransitions = map[string][]string{
+	TransactionAwaitingPayment: {TransactionPaid, TransactionCancelled},
+	TransactionPaid:            {TransactionShipped, TransactionCancelled, TransactionDisputed},
+	TransactionShipped:         {TransactionCompleted, TransactionDisputed},
+	TransactionCompleted:       {TransactionDisputed},
+}
+
+// disputeOutcomes lists the statuses a disputed sale can end in. Only an
+// admin resolving the dispute moves a sale on, so these are kept apart
+// from the transitions buyers and sellers can make.
+var disputeOutcomes = []string{TransactionCompleted, TransactionCancelled}
+
+// Transaction records what a buyer owes a seller for a sold listing. It is
+// created when an auction ends with a winner or an item is bought with Buy
+// Now, and follows the sale through payment and delivery.
+type Transaction struct {
+	gorm.Model
+	Type   string `gorm:"size:20;not null"` // auction_win, buy_now
+	Status string `gorm:"size:20;index;not null;default:'awaiting_payment'"`
+
+	// Amounts, all in the listing's currency. The buyer pays Amount (item,
+	// shipping and tax); the platform fee is taken from the seller's share.
+	ItemPrice    Money `gorm:"embedded;embeddedPrefix:item_price_"` // the hammer or Buy Now price
+	ShippingCost Money `gorm:"embedded;embeddedPrefix:shipping_cost_"`
+	PlatformFee  Money `gorm:"embedded;embeddedPrefix:platform_fee_"`
+	TaxAmount    Money `gorm:"embedded;embeddedPrefix:tax_amount_"`
+	Amount       Money `gorm:"embedded;embeddedPrefix:amount_"`
+
+	// Where the item ships to, copied from the buyer's address book
+	ShippingAddress AddressSnapshot `gorm:"embedded;embeddedPrefix:shipping_"`
+
+	TrackingNumber string `gorm:"size:100"`
+	TrackingURL    string `gorm:"size:255"`
+
+	PaidAt        *time.Time
+	ShippedAt     *time.Time
+	CompletedAt   *time.Time
+	CancelledAt   *time.Time
+	CancelReason  string `gorm:"type:text"`
+	DisputedAt    *time.Time
+	DisputeReason string `gorm:"type:text"`
+
+	// Escrow holds the buyer's payment until they confirm delivery or
+	// EscrowReleaseAt passes; without it the payment is released to the
+	// seller as soon as it is taken
+	Escrow          bool       `gorm:"not null;default:false"`
+	EscrowReleaseAt *time.Time `gorm:"index"`
+
+	// When the buyer's payment was released to the seller, and the payout
+	// that paid the seller for it
+	SettledAt *time.Time
+	PayoutID  *uint `gorm:"index"`
+
+	// Relationships
+	ListingID uint    `gorm:"uniqueIndex;not null"` // a listing sells once
+	Listing   Listing `gorm:"foreignKey:ListingID" json:"-"`
+	SellerID  uint    `gorm:"index;not null"`
+	Seller    User    `gorm:"foreignKey:SellerID" json:"-"`
+	BuyerID   uint    `gorm:"index;not null"`
+	Buyer     User    `gorm:"foreignKey:BuyerID" json:"-"`
+}
+
+// CanMoveTo reports whether the transaction may move to a status
+func (t *Transaction) CanMoveTo(status string) bool {
+	for _, next := range transactionTransitions[t.Status] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// CanResolveTo reports whether resolving the sale's dispute may move it to
+// a status
+func (t *Transaction) CanResolveTo(status string) bool {
+	if t.Status != TransactionDisputed {
+		return false
+	}
+	for _, outcome := range disputeOutcomes {
+		if outcome == status {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOpen reports whether the sale still needs action from either party
+func (t *Transaction) IsOpen() bool {
+	return t.Status != TransactionCompleted && t.Status != TransactionCancelled
+}
+
+// HasShippingAddress reports whether the buyer has chosen where to ship to
+func (t *Transaction) HasShippingAddress() bool {
+	return t.ShippingAddress.Country != ""
+}
+
+// Where the buyer's payment is, as reported by FundsStatus
+const (
+	FundsHeld     = "held"     // in escrow
+	FundsFrozen   = "frozen"   // under dispute; neither released nor paid out
+	FundsReleased = "released" // released to the seller
+	FundsRefunded = "refunded" // returned to the buyer
+)
+
+// FundsStatus reports where the buyer's payment is, or "" before payment
+func (t *Transaction) FundsStatus() string {
+	switch {
+	case t.PaidAt == nil:
+		return ""
+	case t.Status == TransactionCancelled:
+		return FundsRefunded
+	case t.Status == TransactionDisputed:
+		return FundsFrozen
+	case t.SettledAt != nil:
+		return FundsReleased
+	default:
+		return FundsHeld
+	}
+}
+
+// SellerProceeds is what the seller receives once the platform fee is taken
+func (t *Transaction) SellerProceeds() Money {
+	return t.ItemPrice.Add(t.ShippingCost).Sub(t.PlatformFee)
+}
+
+// HasParticipant reports whether a user is the buyer or seller
+func (t *Transaction) HasParticipant(userID uint) bool {
+	return userID == t.BuyerID || userID == t.SellerID
+}
+
+// OtherParty returns the participant who is not userID
+func (t *Transaction) OtherParty(userID uint) uint {
+	if userID == t.SellerID {
+		return t.BuyerID
+	}
+	return t.SellerID
+}
@@ -2,7 +2,7 @@
 package models
 
 import (
-	_ "time"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -11,16 +11,50 @@ type User struct {
 	gorm.Model
 	Username    string `gorm:"uniqueIndex;not null"`
 	Email       string `gorm:"uniqueIndex;not null"`
-	Password    string `gorm:"not null"`
+	Password    string `gorm:"not null" json:"-"`
 	FirstName   string
 	LastName    string
 	PhoneNumber string
 	Address     string
+	Bio             string `gorm:"type:text"`
+	ProfileImageURL string
+	ProfileImageKey string `json:"-"` // storage key of the avatar
 	IsAdmin     bool `gorm:"default:false"`
+	IsEmailVerified bool `gorm:"default:false"`
+	IsSellerVerified bool `gorm:"default:false"`
+	
+	// Two-factor authentication (TOTP)
+	TwoFactorEnabled  bool   `gorm:"default:false"`
+	TwoFactorSecret   string `json:"-"` // base32 secret, set at enrolment
+	TwoFactorLastStep int64  `json:"-"` // last accepted time step, prevents code replay
+	
+	// Account deletion. The account is anonymised once the cooling-off
+	// period after DeletionRequestedAt has passed.
+	DeletionRequestedAt *time.Time `json:",omitempty"`
+	DeletionScheduledAt *time.Time `json:",omitempty"`
+	AnonymizedAt        *time.Time `json:",omitempty"`
 	
 	// Relationships
 	Listings    []Listing `gorm:"foreignKey:UserID"`
 	Bids        []Bid     `gorm:"foreignKey:UserID"`
 	Ratings     []Rating  `gorm:"foreignKey:RatedUserID"`
 	GivenRatings []Rating `gorm:"foreignKey:RaterUserID"`
+	Roles       []Role    `gorm:"many2many:user_roles;" json:",omitempty"`
+}
+
+// HasRole reports whether the user has been assigned the named role.
+// Roles must be preloaded.
+func (u *User) HasRole(name string) bool {
+	for _, role := range u.Roles {
+		if role.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAnonymized reports whether the account has been deleted and its
+// personal data removed
+func (u *User) IsAnonymized() bool {
+	return u.AnonymizedAt != nil
 }
@@ -0,0 +1,47 @@
+// models/user_address.go
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// Address types
+const (
+	AddressTypeBilling  = "billing"
+	AddressTypeShipping = "shipping"
+	AddressTypeBoth     = "both"
+)
+
+// UserAddress is one of a user's saved postal addresses
+type UserAddress struct {
+	gorm.Model
+	AddressType string `gorm:"not null"` // billing, shipping, both
+	IsDefault   bool   `gorm:"default:false"`
+	AddressSnapshot
+
+	// Relationships
+	UserID uint `gorm:"index;not null"`
+	User   User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// AddressSnapshot holds the fields of a postal address. It is embedded in
+// UserAddress and copied onto records, such as transactions, that must keep
+// the address as it was even if the user later edits or deletes it.
+type AddressSnapshot struct {
+	StreetAddress1 string `gorm:"not null"`
+	StreetAddress2 string
+	City           string `gorm:"not null"`
+	State          string
+	PostalCode     string `gorm:"size:20"`
+	Country        string `gorm:"size:2;not null"` // ISO 3166-1 alpha-2
+}
+
+// CanShip reports whether the address may be used for delivery
+func (a *UserAddress) CanShip() bool {
+	return a.AddressType == AddressTypeShipping || a.AddressType == AddressTypeBoth
+}
+
+// CanBill reports whether the address may be used for billing
+func (a *UserAddress) CanBill() bool {
+	return a.AddressType == AddressTypeBilling || a.AddressType == AddressTypeBoth
+}
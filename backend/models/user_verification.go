@@ -0,0 +1,48 @@
+// models/user_verification.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Verification types and statuses
+const (
+	VerificationTypeSeller   = "seller"
+	VerificationTypeIdentity = "identity"
+	VerificationTypeAddress  = "address"
+
+	VerificationPending  = "pending"
+	VerificationApproved = "approved"
+	VerificationRejected = "rejected"
+)
+
+// UserVerification is a request by a user to be verified, reviewed by an admin
+type UserVerification struct {
+	gorm.Model
+	VerificationType string    `gorm:"not null"`
+	Status           string    `gorm:"index;default:'pending'"`
+	ReviewNotes      string    `gorm:"type:text"`
+	SubmittedAt      time.Time `gorm:"not null"`
+	ReviewedAt       *time.Time
+
+	// Relationships
+	UserID       uint `gorm:"index;not null"`
+	User         User `gorm:"foreignKey:UserID" json:",omitempty"`
+	ReviewedByID *uint
+	ReviewedBy   *User                  `gorm:"foreignKey:ReviewedByID" json:",omitempty"`
+	Documents    []VerificationDocument `gorm:"foreignKey:VerificationID"`
+}
+
+// VerificationDocument is an uploaded file kept in private storage
+type VerificationDocument struct {
+	gorm.Model
+	DocumentType string `gorm:"not null"` // e.g. passport, drivers_license, utility_bill
+	StorageKey   string `gorm:"not null" json:"-"`
+	OriginalName string
+	ContentType  string
+	Size         int64
+
+	VerificationID uint `gorm:"index;not null"`
+}
@@ -0,0 +1,252 @@
+// payments/fake.go
+package payments
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeSignatureHeader carries the fake provider's webhook signature, in the
+// form "t=<unix time>,v1=<hex HMAC-SHA256 of "<t>.<payload>">"
+const FakeSignatureHeader = "Fake-Signature"
+
+// FakeProvider is an in-memory gateway for development and tests. Nothing
+// leaves the process: Confirm stands in for the buyer completing checkout
+// and returns the signed webhook a real gateway would send. Intents are
+// lost when the process restarts.
+type FakeProvider struct {
+	secret    []byte
+	tolerance time.Duration
+
+	mu       sync.Mutex
+	intents  map[string]*Intent
+	refunded map[string]int64
+	captures map[string]string  // intent IDs by idempotency key
+	refunds  map[string]*Refund // by idempotency key
+}
+
+// NewFakeProvider creates a fake gateway that signs webhooks with secret.
+// Webhooks older than tolerance are rejected as replays.
+func NewFakeProvider(secret string, tolerance time.Duration) (*FakeProvider, error) {
+	if secret == "" {
+		// Never sign with a guessable key; webhooks signed before a restart
+		// will no longer verify
+		log.Printf("Warning: no payment webhook secret configured, using an ephemeral secret")
+		ephemeral, err := randomHex(32)
+		if err != nil {
+			return nil, err
+		}
+		secret = ephemeral
+	}
+
+	return &FakeProvider{
+		secret:    []byte(secret),
+		tolerance: tolerance,
+		intents:   map[string]*Intent{},
+		refunded:  map[string]int64{},
+		captures:  map[string]string{},
+		refunds:   map[string]*Refund{},
+	}, nil
+}
+
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakeProvider) Methods() []string {
+	return []string{"card", "bank_transfer"}
+}
+
+func (p *FakeProvider) CreateIntent(req IntentRequest) (*Intent, error) {
+	if req.Amount <= 0 {
+		return nil, errors.New("amount must be greater than zero")
+	}
+
+	id, err := randomHex(12)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomHex(12)
+	if err != nil {
+		return nil, err
+	}
+	intent := &Intent{
+		ID:           "pi_fake_" + id,
+		Status:       IntentRequiresPayment,
+		Amount:       req.Amount,
+		Currency:     req.Currency,
+		ClientSecret: "pi_fake_" + id + "_secret_" + secret,
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.intents[intent.ID] = intent
+	copied := *intent
+	return &copied, nil
+}
+
+// Capture collects an authorized intent. Capturing twice is not an error.
+func (p *FakeProvider) Capture(intentID string, idempotencyKey string) (*Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if previous, ok := p.captures[idempotencyKey]; ok && previous != intentID {
+		return nil, errors.New("idempotency key was used for a different capture")
+	}
+
+	intent, ok := p.intents[intentID]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+	switch intent.Status {
+	case IntentAuthorized:
+		intent.Status = IntentSucceeded
+		p.captures[idempotencyKey] = intentID
+	case IntentSucceeded:
+	default:
+		return nil, fmt.Errorf("cannot capture a payment that is %s", strings.ReplaceAll(intent.Status, "_", " "))
+	}
+	copied := *intent
+	return &copied, nil
+}
+
+func (p *FakeProvider) Refund(intentID string, amount int64, idempotencyKey string) (*Refund, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if previous, ok := p.refunds[idempotencyKey]; ok {
+		if previous.IntentID != intentID || previous.Amount != amount {
+			return nil, errors.New("idempotency key was used for a different refund")
+		}
+		copied := *previous
+		return &copied, nil
+	}
+
+	intent, ok := p.intents[intentID]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+	if intent.Status != IntentSucceeded {
+		return nil, errors.New("only captured payments can be refunded")
+	}
+	if amount <= 0 || p.refunded[intentID]+amount > intent.Amount {
+		return nil, errors.New("refund exceeds the amount paid")
+	}
+
+	id, err := randomHex(12)
+	if err != nil {
+		return nil, err
+	}
+	p.refunded[intentID] += amount
+	refund := &Refund{ID: "re_fake_" + id, IntentID: intentID, Amount: amount}
+	p.refunds[idempotencyKey] = refund
+	copied := *refund
+	return &copied, nil
+}
+
+func (p *FakeProvider) VerifyWebhook(payload []byte, header http.Header) (*Event, error) {
+	var timestamp, signature string
+	for _, part := range strings.Split(header.Get(FakeSignatureHeader), ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signature = value
+		}
+	}
+
+	sent, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || signature == "" {
+		return nil, ErrInvalidSignature
+	}
+	if age := time.Since(time.Unix(sent, 0)); age > p.tolerance || age < -p.tolerance {
+		return nil, ErrInvalidSignature
+	}
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, p.sign(timestamp, payload)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	return &event, nil
+}
+
+// Confirm simulates the buyer completing (or failing) checkout for an
+// intent. It returns the signed webhook the gateway sends as a result.
+func (p *FakeProvider) Confirm(intentID string, succeed bool) ([]byte, http.Header, error) {
+	eventID, err := randomHex(12)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p.mu.Lock()
+	intent, ok := p.intents[intentID]
+	if !ok {
+		p.mu.Unlock()
+		return nil, nil, ErrIntentNotFound
+	}
+	if intent.Status != IntentRequiresPayment {
+		p.mu.Unlock()
+		return nil, nil, errors.New("this payment has already been completed")
+	}
+
+	event := Event{
+		ID:       "evt_fake_" + eventID,
+		Type:     EventPaymentAuthorized,
+		IntentID: intent.ID,
+		Amount:   intent.Amount,
+		Created:  time.Now().Unix(),
+	}
+	intent.Status = IntentAuthorized
+	if !succeed {
+		intent.Status = IntentFailed
+		event.Type = EventPaymentFailed
+		event.Reason = "card_declined"
+	}
+	p.mu.Unlock()
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, nil, err
+	}
+	return payload, p.SignatureHeader(payload), nil
+}
+
+// SignatureHeader signs a payload as the fake gateway would
+func (p *FakeProvider) SignatureHeader(payload []byte) http.Header {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	header := http.Header{}
+	header.Set(FakeSignatureHeader, "t="+timestamp+",v1="+hex.EncodeToString(p.sign(timestamp, payload)))
+	return header
+}
+
+func (p *FakeProvider) sign(timestamp string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
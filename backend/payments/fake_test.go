@@ -0,0 +1,139 @@
+// payments/fake_test.go
+package payments
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newTestProvider(t *testing.T) *FakeProvider {
+	t.Helper()
+	provider, err := NewFakeProvider("test-secret", 5*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+// authorize creates an intent and completes its checkout, returning the
+// intent and the signed webhook the provider sent
+func authorize(t *testing.T, provider *FakeProvider, amount int64) (*Intent, []byte, http.Header) {
+	t.Helper()
+	intent, err := provider.CreateIntent(IntentRequest{Amount: amount, Currency: "USD", Method: "card", Reference: "transaction-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, header, err := provider.Confirm(intent.ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return intent, payload, header
+}
+
+func TestVerifyWebhookAcceptsSignedEvent(t *testing.T) {
+	provider := newTestProvider(t)
+	intent, payload, header := authorize(t, provider, 2500)
+
+	event, err := provider.VerifyWebhook(payload, header)
+	if err != nil {
+		t.Fatalf("VerifyWebhook: %v", err)
+	}
+	if event.Type != EventPaymentAuthorized || event.IntentID != intent.ID || event.Amount != 2500 {
+		t.Errorf("got event %+v, want %s for %s of 2500", event, EventPaymentAuthorized, intent.ID)
+	}
+}
+
+func TestVerifyWebhookRejectsBadSignatures(t *testing.T) {
+	provider := newTestProvider(t)
+	_, payload, header := authorize(t, provider, 2500)
+
+	other, err := NewFakeProvider("another-secret", 5*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	staleTime := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	stale := http.Header{}
+	stale.Set(FakeSignatureHeader, "t="+staleTime+",v1="+hex.EncodeToString(provider.sign(staleTime, payload)))
+
+	tests := []struct {
+		name    string
+		payload []byte
+		header  http.Header
+	}{
+		{"tampered payload", bytes.Replace(payload, []byte("2500"), []byte("9999"), 1), header},
+		{"signed with another secret", payload, other.SignatureHeader(payload)},
+		{"missing signature", payload, http.Header{}},
+		{"stale timestamp", payload, stale},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := provider.VerifyWebhook(tt.payload, tt.header); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("got %v, want %v", err, ErrInvalidSignature)
+			}
+		})
+	}
+}
+
+func TestCaptureIsIdempotent(t *testing.T) {
+	provider := newTestProvider(t)
+	intent, _, _ := authorize(t, provider, 2500)
+
+	for i := 0; i < 2; i++ {
+		captured, err := provider.Capture(intent.ID, "capture-payment-1")
+		if err != nil {
+			t.Fatalf("capture %d: %v", i+1, err)
+		}
+		if captured.Status != IntentSucceeded {
+			t.Errorf("capture %d: status %s, want %s", i+1, captured.Status, IntentSucceeded)
+		}
+	}
+
+	second, _, _ := authorize(t, provider, 1000)
+	if _, err := provider.Capture(second.ID, "capture-payment-1"); err == nil {
+		t.Error("reusing a capture key for another intent succeeded")
+	}
+}
+
+func TestRefundInFull(t *testing.T) {
+	provider := newTestProvider(t)
+	intent, _, _ := authorize(t, provider, 2500)
+	if _, err := provider.Capture(intent.ID, "capture-payment-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	refund, err := provider.Refund(intent.ID, 2500, "refund-payment-1")
+	if err != nil {
+		t.Fatalf("Refund: %v", err)
+	}
+	if refund.IntentID != intent.ID || refund.Amount != 2500 {
+		t.Errorf("got refund %+v, want 2500 of %s", refund, intent.ID)
+	}
+
+	// A retry with the same key returns the same refund rather than
+	// refunding twice
+	retried, err := provider.Refund(intent.ID, 2500, "refund-payment-1")
+	if err != nil {
+		t.Fatalf("retried Refund: %v", err)
+	}
+	if retried.ID != refund.ID {
+		t.Errorf("retry made refund %s, want %s", retried.ID, refund.ID)
+	}
+
+	if _, err := provider.Refund(intent.ID, 1, "refund-payment-2"); err == nil {
+		t.Error("refunding more than was paid succeeded")
+	}
+}
+
+func TestRefundRequiresCapture(t *testing.T) {
+	provider := newTestProvider(t)
+	intent, _, _ := authorize(t, provider, 2500)
+
+	if _, err := provider.Refund(intent.ID, 2500, "refund-payment-1"); err == nil {
+		t.Error("refunding an uncaptured payment succeeded")
+	}
+}
@@ -0,0 +1,86 @@
+// payments/payments.go
+package payments
+
+import (
+	"errors"
+	"net/http"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrIntentNotFound   = errors.New("payment intent not found")
+)
+
+// Intent statuses
+const (
+	IntentRequiresPayment = "requires_payment" // waiting for the buyer
+	IntentAuthorized      = "authorized"       // the buyer paid; funds are held until captured
+	IntentSucceeded       = "succeeded"        // captured
+	IntentFailed          = "failed"
+)
+
+// Webhook event types
+const (
+	EventPaymentAuthorized = "payment.authorized"
+	EventPaymentFailed     = "payment.failed"
+	EventRefundSucceeded   = "refund.succeeded"
+)
+
+// PaymentProvider is a payment gateway. Payments start as an intent the
+// buyer completes with the gateway; the gateway reports the outcome with a
+// signed webhook, after which the funds are captured.
+type PaymentProvider interface {
+	// Name identifies the provider in webhook URLs and stored payments
+	Name() string
+	// Methods lists the payment methods buyers can choose from
+	Methods() []string
+	CreateIntent(req IntentRequest) (*Intent, error)
+	// Capture collects an authorized intent. Calls with the same
+	// idempotency key capture once, so a capture whose outcome was lost can
+	// safely be retried.
+	Capture(intentID string, idempotencyKey string) (*Intent, error)
+	// Refund returns amount of a captured payment to the buyer. Calls with
+	// the same idempotency key make one refund, so a refund whose outcome
+	// was lost can safely be retried.
+	Refund(intentID string, amount int64, idempotencyKey string) (*Refund, error)
+	// VerifyWebhook checks a webhook's signature and parses its event
+	VerifyWebhook(payload []byte, header http.Header) (*Event, error)
+}
+
+// IntentRequest asks the provider to collect an amount. Like most
+// gateways, providers take amounts in the currency's minor unit (cents for
+// USD). Reference ties the intent back to our records.
+type IntentRequest struct {
+	Amount    int64
+	Currency  string
+	Method    string
+	Reference string
+}
+
+// Intent is a payment being collected by the provider. The buyer's client
+// uses ClientSecret to complete it with the provider.
+type Intent struct {
+	ID           string
+	Status       string
+	Amount       int64
+	Currency     string
+	ClientSecret string
+}
+
+// Refund is money returned for a captured intent
+type Refund struct {
+	ID       string
+	IntentID string
+	Amount   int64
+}
+
+// Event is a verified webhook from the provider. IDs are unique per
+// provider, and providers may deliver the same event more than once.
+type Event struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	IntentID string `json:"intent_id"`
+	Amount   int64  `json:"amount"`
+	Reason   string `json:"reason,omitempty"`
+	Created  int64  `json:"created"`
+}
@@ -0,0 +1,104 @@
+// pdf/fonts.go
+package pdf
+
+import "strings"
+
+// Font is one of the standard fonts every PDF reader has
+type Font int
+
+const (
+	Helvetica Font = iota
+	HelveticaBold
+)
+
+func (f Font) resource() string {
+	if f == HelveticaBold {
+		return "F2"
+	}
+	return "F1"
+}
+
+// Glyph widths of printable ASCII (32 to 126) in thousandths of the font
+// size, from the fonts' Adobe metrics
+var (
+	helveticaWidths = [95]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	helveticaBoldWidths = [95]int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+)
+
+// TextWidth returns the width of s in points. Characters outside ASCII
+// are measured as an average letter, which is close enough for layout.
+func TextWidth(font Font, size float64, s string) float64 {
+	widths := &helveticaWidths
+	if font == HelveticaBold {
+		widths = &helveticaBoldWidths
+	}
+
+	total := 0
+	for _, r := range s {
+		if r >= 32 && r <= 126 {
+			total += widths[r-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// WrapText splits s into lines no wider than width, breaking at spaces.
+// A word wider than the line is left whole on its own line.
+func WrapText(font Font, size, width float64, s string) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if line != "" && TextWidth(font, size, candidate) > width {
+				lines = append(lines, line)
+				candidate = word
+			}
+			line = candidate
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// winAnsiSpecials are the characters WinAnsiEncoding places between 128
+// and 159, where Latin-1 has control codes
+var winAnsiSpecials = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91,
+	'’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98,
+	'™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// winAnsi returns the WinAnsiEncoding byte of a character
+func winAnsi(r rune) byte {
+	switch {
+	case r >= 32 && r <= 126, r >= 160 && r <= 255:
+		return byte(r)
+	case r == '\n' || r == '\r' || r == '\t':
+		return byte(r)
+	}
+	if c, ok := winAnsiSpecials[r]; ok {
+		return c
+	}
+	return '?'
+}
@@ -0,0 +1,212 @@
+// pdf/pdf.go
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Page sizes in points (1/72 inch)
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// Document is a PDF made of pages of text, lines and filled rectangles,
+// using the standard Helvetica fonts so nothing has to be embedded. The
+// output depends only on what was drawn, so the same drawing always
+// produces the same bytes.
+type Document struct {
+	title   string
+	author  string
+	created time.Time
+	pages   []*Page
+}
+
+// New creates an empty document
+func New() *Document {
+	return &Document{}
+}
+
+// SetInfo sets the title, author and creation time shown by PDF readers
+func (d *Document) SetInfo(title, author string, created time.Time) {
+	d.title = title
+	d.author = author
+	d.created = created
+}
+
+// AddPage appends a page of the given size. Coordinates on the page are in
+// points from its bottom-left corner.
+func (d *Document) AddPage(width, height float64) *Page {
+	page := &Page{width: width, height: height}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// Page is one page of a document
+type Page struct {
+	width   float64
+	height  float64
+	content bytes.Buffer
+}
+
+func (p *Page) Width() float64  { return p.width }
+func (p *Page) Height() float64 { return p.height }
+
+// SetColor sets the color of the text, lines and rectangles drawn after it
+func (p *Page) SetColor(c Color) {
+	r, g, b := c.components()
+	fmt.Fprintf(&p.content, "%s %s %s rg %s %s %s RG\n", num(r), num(g), num(b), num(r), num(g), num(b))
+}
+
+// Text draws s with its baseline starting at (x, y)
+func (p *Page) Text(x, y float64, font Font, size float64, s string) {
+	fmt.Fprintf(&p.content, "BT /%s %s Tf 1 0 0 1 %s %s Tm %s Tj ET\n", font.resource(), num(size), num(x), num(y), literal(s))
+}
+
+// TextRight draws s so that it ends at x
+func (p *Page) TextRight(x, y float64, font Font, size float64, s string) {
+	p.Text(x-TextWidth(font, size, s), y, font, size, s)
+}
+
+// Rect fills a rectangle whose bottom-left corner is (x, y)
+func (p *Page) Rect(x, y, width, height float64) {
+	fmt.Fprintf(&p.content, "%s %s %s %s re f\n", num(x), num(y), num(width), num(height))
+}
+
+// Line draws a straight line
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n", num(width), num(x1), num(y1), num(x2), num(y2))
+}
+
+// Color is an RGB color
+type Color struct {
+	R, G, B uint8
+}
+
+var (
+	Black = Color{0, 0, 0}
+	White = Color{255, 255, 255}
+)
+
+// ParseColor reads a color written as "#RRGGBB"
+func ParseColor(s string) (Color, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) != 6 {
+		return Color{}, fmt.Errorf("%q is not a color such as #1F4E79", s)
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return Color{}, fmt.Errorf("%q is not a color such as #1F4E79", s)
+	}
+	return Color{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value)}, nil
+}
+
+// Tint mixes the color with white; 0 keeps it and 1 gives white
+func (c Color) Tint(amount float64) Color {
+	mix := func(v uint8) uint8 {
+		return uint8(float64(v) + (255-float64(v))*amount + 0.5)
+	}
+	return Color{R: mix(c.R), G: mix(c.G), B: mix(c.B)}
+}
+
+func (c Color) components() (float64, float64, float64) {
+	return float64(c.R) / 255, float64(c.G) / 255, float64(c.B) / 255
+}
+
+// WriteTo writes the document as a PDF file
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var out bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1 to 5 are fixed; each page then takes two, itself and its
+	// content stream
+	const firstPage = 6
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Title %s /Author %s /Producer (auctions) /CreationDate (%s) >>",
+		literal(d.title), literal(d.author), d.created.UTC().Format("D:20060102150405Z")))
+
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			num(page.width), num(page.height), firstPage+2*i+1))
+
+		var stream bytes.Buffer
+		zw := zlib.NewWriter(&stream)
+		if _, err := zw.Write(page.content.Bytes()); err != nil {
+			return 0, err
+		}
+		if err := zw.Close(); err != nil {
+			return 0, err
+		}
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", stream.Len(), stream.Bytes()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	n, err := w.Write(out.Bytes())
+	return int64(n), err
+}
+
+// Bytes returns the document as a PDF file
+func (d *Document) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := d.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// num formats a number for a content stream
+func num(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
+
+// literal encodes s as a PDF string in the fonts' WinAnsi encoding.
+// Characters the encoding lacks become "?".
+func literal(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range s {
+		c := winAnsi(r)
+		switch c {
+		case '(', ')', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n', '\r', '\t':
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
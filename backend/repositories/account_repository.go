@@ -0,0 +1,179 @@
+// repositories/account_repository.go
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+)
+
+// AccountRepository holds the queries that span a user's whole footprint:
+// data exports and account deletion
+type AccountRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountRepository() *AccountRepository {
+	return &AccountRepository{
+		db: database.DB,
+	}
+}
+
+// FindListings returns every listing a user created, with categories
+func (r *AccountRepository) FindListings(userID uint) ([]models.Listing, error) {
+	var listings []models.Listing
+	err := r.db.Preload("Categories").
+		Where("user_id = ?", userID).
+		Order("created_at").
+		Find(&listings).Error
+	return listings, err
+}
+
+// FindBids returns every bid a user placed, with the listing bid on
+func (r *AccountRepository) FindBids(userID uint) ([]models.Bid, error) {
+	var bids []models.Bid
+	err := r.db.Preload("Listing").
+		Where("user_id = ?", userID).
+		Order("placed_at").
+		Find(&bids).Error
+	return bids, err
+}
+
+// FindRatings returns ratings a user received and gave
+func (r *AccountRepository) FindRatings(userID uint) ([]models.Rating, error) {
+	var ratings []models.Rating
+	err := r.db.Where("rated_user_id = ? OR rater_user_id = ?", userID, userID).
+		Order("created_at").
+		Find(&ratings).Error
+	return ratings, err
+}
+
+// FindNotifications returns every notification sent to a user
+func (r *AccountRepository) FindNotifications(userID uint) ([]models.Notification, error) {
+	var notifications []models.Notification
+	err := r.db.Where("user_id = ?", userID).
+		Order("created_at").
+		Find(&notifications).Error
+	return notifications, err
+}
+
+// FindMessages returns every message a user sent or received, with the
+// thread it belongs to
+func (r *AccountRepository) FindMessages(userID uint) ([]models.Message, error) {
+	var messages []models.Message
+	err := r.db.Preload("Thread").Preload("Attachments").
+		Where("sender_id = ? OR recipient_id = ?", userID, userID).
+		Order("created_at").
+		Find(&messages).Error
+	return messages, err
+}
+
+// FindTransactions returns every sale a user bought or sold in, with the
+// listing sold
+func (r *AccountRepository) FindTransactions(userID uint) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	err := r.db.Preload("Listing").
+		Where("buyer_id = ? OR seller_id = ?", userID, userID).
+		Order("created_at").
+		Find(&transactions).Error
+	return transactions, err
+}
+
+// CountOpenTransactions counts sales a user bought or sold in that are not
+// yet completed or cancelled
+func (r *AccountRepository) CountOpenTransactions(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Transaction{}).
+		Where("buyer_id = ? OR seller_id = ?", userID, userID).
+		Where("status NOT IN ?", []string{models.TransactionCompleted, models.TransactionCancelled}).
+		Count(&count).Error
+	return count, err
+}
+
+// CountOpenAuctions counts active listings a user is selling and active
+// listings on which the user has bid. Deleting an account in the middle of
+// an auction would leave the other party without a counterpart.
+func (r *AccountRepository) CountOpenAuctions(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Listing{}).
+		Where("status = ?", "active").
+		Where("user_id = ? OR id IN (?)", userID,
+			r.db.Model(&models.Bid{}).Select("listing_id").Where("user_id = ?", userID)).
+		Count(&count).Error
+	return count, err
+}
+
+// FindDueDeletions returns accounts whose cooling-off period has ended
+func (r *AccountRepository) FindDueDeletions(now time.Time) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Where("deletion_scheduled_at <= ? AND anonymized_at IS NULL", now).
+		Find(&users).Error
+	return users, err
+}
+
+// Anonymize replaces a user's personal data with placeholders and removes
+// everything tied to signing in. The user row itself is kept so listings,
+// bids, ratings and messages still point at a (now anonymous) account;
+// messages stay readable by the other participant.
+func (r *AccountRepository) Anonymize(userID uint, unusablePassword string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"username":             fmt.Sprintf("deleted-user-%d", userID),
+			"email":                fmt.Sprintf("deleted-user-%d@deleted.invalid", userID),
+			"password":             unusablePassword,
+			"first_name":           "",
+			"last_name":            "",
+			"phone_number":         "",
+			"address":              "",
+			"bio":                  "",
+			"profile_image_url":    "",
+			"profile_image_key":    "",
+			"is_admin":             false,
+			"is_email_verified":    false,
+			"two_factor_enabled":   false,
+			"two_factor_secret":    "",
+			"two_factor_last_step": 0,
+			"anonymized_at":        now,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Exec("DELETE FROM user_roles WHERE user_id = ?", userID).Error; err != nil {
+			return err
+		}
+
+		// Sign-in material and private records have no further use
+		for _, model := range []interface{}{
+			&models.RefreshToken{},
+			&models.OneTimeToken{},
+			&models.RecoveryCode{},
+			&models.ExternalIdentity{},
+			&models.Notification{},
+			&models.UserAddress{},
+		} {
+			if err := tx.Unscoped().Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		// Identity documents are deleted from storage by the caller
+		if err := tx.Unscoped().
+			Where("verification_id IN (SELECT id FROM user_verifications WHERE user_id = ?)", userID).
+			Delete(&models.VerificationDocument{}).Error; err != nil {
+			return err
+		}
+
+		// Sessions have already been revoked; keep the rows so other
+		// instances still see the revocation, but drop where they came from
+		return tx.Model(&models.Session{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+			"device":     "",
+			"user_agent": "",
+			"ip_address": "",
+		}).Error
+	})
+}
@@ -0,0 +1,98 @@
+// repositories/address_repository.go
+package repositories
+
+import (
+	"errors"
+
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+)
+
+type AddressRepository struct {
+	db *gorm.DB
+}
+
+func NewAddressRepository() *AddressRepository {
+	return &AddressRepository{
+		db: database.DB,
+	}
+}
+
+func (r *AddressRepository) FindByID(id uint) (*models.UserAddress, error) {
+	var address models.UserAddress
+	err := r.db.First(&address, id).Error
+	return &address, err
+}
+
+// FindByUser lists a user's addresses, default first
+func (r *AddressRepository) FindByUser(userID uint) ([]models.UserAddress, error) {
+	var addresses []models.UserAddress
+	err := r.db.Where("user_id = ?", userID).
+		Order("is_default DESC, created_at DESC").
+		Find(&addresses).Error
+	return addresses, err
+}
+
+// FindDefaultShipping returns the address a user's purchases ship to when
+// they have not picked one
+func (r *AddressRepository) FindDefaultShipping(userID uint) (*models.UserAddress, error) {
+	var address models.UserAddress
+	err := r.db.Where("user_id = ? AND address_type IN ?", userID, []string{models.AddressTypeShipping, models.AddressTypeBoth}).
+		Order("is_default DESC, created_at DESC").
+		First(&address).Error
+	return &address, err
+}
+
+// FindDefaultBilling returns the address a user's invoices are addressed to
+func (r *AddressRepository) FindDefaultBilling(userID uint) (*models.UserAddress, error) {
+	var address models.UserAddress
+	err := r.db.Where("user_id = ? AND address_type IN ?", userID, []string{models.AddressTypeBilling, models.AddressTypeBoth}).
+		Order("is_default DESC, created_at DESC").
+		First(&address).Error
+	return &address, err
+}
+
+func (r *AddressRepository) CountByUser(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.UserAddress{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// Save creates or updates an address. When it is the default, the user's
+// other addresses lose the flag in the same transaction.
+func (r *AddressRepository) Save(address *models.UserAddress) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if address.IsDefault {
+			if err := tx.Model(&models.UserAddress{}).
+				Where("user_id = ? AND id <> ?", address.UserID, address.ID).
+				Update("is_default", false).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Omit("User").Save(address).Error
+	})
+}
+
+// Delete removes an address. If it was the default, the most recently
+// added remaining address becomes the default.
+func (r *AddressRepository) Delete(address *models.UserAddress) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(address).Error; err != nil {
+			return err
+		}
+		if !address.IsDefault {
+			return nil
+		}
+
+		var next models.UserAddress
+		err := tx.Where("user_id = ?", address.UserID).Order("created_at DESC").First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&next).Update("is_default", true).Error
+	})
+}
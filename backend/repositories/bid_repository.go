@@ -5,6 +5,7 @@ import (
 	"github.com/jimsyyap/auctions/backend/database"
 	"github.com/jimsyyap/auctions/backend/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type BidRepository struct {
@@ -21,6 +22,35 @@ func (r *BidRepository) Create(bid *models.Bid) error {
 	return r.db.Create(bid).Error
 }
 
+// Place stores a bid if check accepts it. The listing is locked while check
+// looks at it and its highest bid, so two bids cannot both beat the same
+// highest bid.
+func (r *BidRepository) Place(bid *models.Bid, check func(listing *models.Listing, highest *models.Bid) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var listing models.Listing
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&listing, bid.ListingID).Error; err != nil {
+			return err
+		}
+
+		var bids []models.Bid
+		err := tx.Where("listing_id = ?", bid.ListingID).
+			Order("amount_minor DESC").
+			Limit(1).Find(&bids).Error
+		if err != nil {
+			return err
+		}
+		var highest *models.Bid
+		if len(bids) > 0 {
+			highest = &bids[0]
+		}
+
+		if err := check(&listing, highest); err != nil {
+			return err
+		}
+		return tx.Omit(clause.Associations).Create(bid).Error
+	})
+}
+
 func (r *BidRepository) FindByID(id uint) (*models.Bid, error) {
 	var bid models.Bid
 	err := r.db.Preload("User").Preload("Listing").First(&bid, id).Error
@@ -31,7 +61,7 @@ func (r *BidRepository) FindByListing(listingID uint) ([]models.Bid, error) {
 	var bids []models.Bid
 	err := r.db.Where("listing_id = ?", listingID).
 		Preload("User").
-		Order("amount DESC").
+		Order("amount_minor DESC").
 		Find(&bids).Error
 	return bids, err
 }
@@ -39,7 +69,28 @@ func (r *BidRepository) FindByListing(listingID uint) ([]models.Bid, error) {
 func (r *BidRepository) GetHighestBid(listingID uint) (*models.Bid, error) {
 	var bid models.Bid
 	err := r.db.Where("listing_id = ?", listingID).
-		Order("amount DESC").
+		Order("amount_minor DESC").
 		First(&bid).Error
 	return &bid, err
 }
+
+// FindHighestBid returns a listing's highest bid, or nil if it has none
+func (r *BidRepository) FindHighestBid(listingID uint) (*models.Bid, error) {
+	var bids []models.Bid
+	err := r.db.Where("listing_id = ?", listingID).
+		Order("amount_minor DESC").
+		Limit(1).Find(&bids).Error
+	if err != nil || len(bids) == 0 {
+		return nil, err
+	}
+	return &bids[0], nil
+}
+
+// HasBid reports whether a user has bid on a listing
+func (r *BidRepository) HasBid(listingID, userID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Bid{}).
+		Where("listing_id = ? AND user_id = ?", listingID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,125 @@
+// repositories/dispute_repository.go
+package repositories
+
+import (
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+)
+
+type DisputeRepository struct {
+	db *gorm.DB
+}
+
+func NewDisputeRepository() *DisputeRepository {
+	return &DisputeRepository{
+		db: database.DB,
+	}
+}
+
+// Open records a dispute with its first message and marks the transaction
+// disputed, in one step. It reports false, creating nothing, if the
+// transaction's status is no longer fromStatus.
+func (r *DisputeRepository) Open(dispute *models.Dispute, transaction *models.Transaction, fromStatus string) (bool, error) {
+	opened := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Transaction{}).
+			Where("id = ? AND status = ?", transaction.ID, fromStatus).
+			Updates(map[string]interface{}{
+				"status":         models.TransactionDisputed,
+				"disputed_at":    transaction.DisputedAt,
+				"dispute_reason": transaction.DisputeReason,
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		if err := tx.Omit("Transaction").Create(dispute).Error; err != nil {
+			return err
+		}
+		opened = true
+		return nil
+	})
+	return opened, err
+}
+
+// FindByID returns a dispute with its transaction and timeline
+func (r *DisputeRepository) FindByID(id uint) (*models.Dispute, error) {
+	var dispute models.Dispute
+	err := r.withTimeline(r.db).First(&dispute, id).Error
+	return &dispute, err
+}
+
+// FindByTransaction returns the dispute about a transaction with its timeline
+func (r *DisputeRepository) FindByTransaction(transactionID uint) (*models.Dispute, error) {
+	var dispute models.Dispute
+	err := r.withTimeline(r.db).Where("transaction_id = ?", transactionID).First(&dispute).Error
+	return &dispute, err
+}
+
+// FindAll lists disputes, optionally with one status, oldest first so the
+// longest-waiting cases are seen first
+func (r *DisputeRepository) FindAll(status string, page, limit int) ([]models.Dispute, int64, error) {
+	var disputes []models.Dispute
+	var count int64
+
+	offset := (page - 1) * limit
+	query := r.db.Model(&models.Dispute{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Transaction").Preload("Transaction.Listing").
+		Order("created_at ASC").
+		Offset(offset).Limit(limit).
+		Find(&disputes).Error
+	return disputes, count, err
+}
+
+// FindOverdue lists disputes whose seller did not respond in time
+func (r *DisputeRepository) FindOverdue(now time.Time) ([]models.Dispute, error) {
+	var disputes []models.Dispute
+	err := r.db.Preload("Transaction").Preload("Transaction.Listing").
+		Where("status = ? AND seller_respond_by <= ?", models.DisputeAwaitingSeller, now).
+		Find(&disputes).Error
+	return disputes, err
+}
+
+// FindEvidence returns a piece of evidence sent in a dispute
+func (r *DisputeRepository) FindEvidence(disputeID, evidenceID uint) (*models.DisputeEvidence, error) {
+	var evidence models.DisputeEvidence
+	err := r.db.Joins("JOIN dispute_messages ON dispute_messages.id = dispute_evidences.message_id").
+		Where("dispute_evidences.id = ? AND dispute_messages.dispute_id = ?", evidenceID, disputeID).
+		First(&evidence).Error
+	return &evidence, err
+}
+
+// AddMessage adds a message and its evidence to a dispute's timeline
+func (r *DisputeRepository) AddMessage(message *models.DisputeMessage) error {
+	return r.db.Create(message).Error
+}
+
+// MoveStatus changes a dispute's status and other fields, but only if its
+// status is still fromStatus. It reports false if another request changed
+// the dispute first.
+func (r *DisputeRepository) MoveStatus(id uint, fromStatus string, fields map[string]interface{}) (bool, error) {
+	result := r.db.Model(&models.Dispute{}).
+		Where("id = ? AND status = ?", id, fromStatus).
+		Updates(fields)
+	return result.RowsAffected > 0, result.Error
+}
+
+// withTimeline preloads what a dispute is shown with
+func (r *DisputeRepository) withTimeline(db *gorm.DB) *gorm.DB {
+	return db.Preload("Transaction").Preload("Transaction.Listing").
+		Preload("Messages", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		Preload("Messages.Evidence")
+}
@@ -0,0 +1,45 @@
+// repositories/exchange_rate_repository.go
+package repositories
+
+import (
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ExchangeRateRepository struct {
+	db *gorm.DB
+}
+
+func NewExchangeRateRepository() *ExchangeRateRepository {
+	return &ExchangeRateRepository{
+		db: database.DB,
+	}
+}
+
+func (r *ExchangeRateRepository) FindAll() ([]models.ExchangeRate, error) {
+	var rates []models.ExchangeRate
+	err := r.db.Order("currency").Find(&rates).Error
+	return rates, err
+}
+
+// Save creates or replaces the rate for a currency
+func (r *ExchangeRateRepository) Save(rate *models.ExchangeRate) error {
+	return r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(rate).Error
+}
+
+// Delete removes the rate for a currency. It returns false if there was none.
+func (r *ExchangeRateRepository) Delete(currency string) (bool, error) {
+	result := r.db.Where("currency = ?", currency).Delete(&models.ExchangeRate{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// CountListingsIn counts the listings that are still open in a currency
+func (r *ExchangeRateRepository) CountListingsIn(currency string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Listing{}).
+		Where("currency = ? AND status IN ?", currency, []string{"active", models.ModerationStatusHeld}).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,36 @@
+// repositories/external_identity_repository.go
+package repositories
+
+import (
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+)
+
+type ExternalIdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewExternalIdentityRepository() *ExternalIdentityRepository {
+	return &ExternalIdentityRepository{
+		db: database.DB,
+	}
+}
+
+func (r *ExternalIdentityRepository) Create(identity *models.ExternalIdentity) error {
+	return r.db.Create(identity).Error
+}
+
+func (r *ExternalIdentityRepository) FindByProviderSubject(provider, subject string) (*models.ExternalIdentity, error) {
+	var identity models.ExternalIdentity
+	err := r.db.Preload("User").
+		Where("provider = ? AND subject = ?", provider, subject).
+		First(&identity).Error
+	return &identity, err
+}
+
+func (r *ExternalIdentityRepository) FindByUser(userID uint) ([]models.ExternalIdentity, error) {
+	var identities []models.ExternalIdentity
+	err := r.db.Where("user_id = ?", userID).Find(&identities).Error
+	return identities, err
+}
@@ -0,0 +1,63 @@
+// repositories/fee_repository.go
+package repositories
+
+import (
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+)
+
+type FeeRepository struct {
+	db *gorm.DB
+}
+
+func NewFeeRepository() *FeeRepository {
+	return &FeeRepository{
+		db: database.DB,
+	}
+}
+
+// FindRules lists the fee rules, optionally of one kind
+func (r *FeeRepository) FindRules(kind string) ([]models.FeeRule, error) {
+	var rules []models.FeeRule
+	query := r.db.Preload("Category")
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	err := query.Order("kind, min_price_currency, min_price_minor").Find(&rules).Error
+	return rules, err
+}
+
+// FindMatchingRules lists the rules of a kind for prices in a currency
+// that apply to every category or to one of categoryIDs
+func (r *FeeRepository) FindMatchingRules(kind, currency string, categoryIDs []uint) ([]models.FeeRule, error) {
+	var rules []models.FeeRule
+	query := r.db.Where("kind = ? AND min_price_currency = ?", kind, currency)
+	if len(categoryIDs) > 0 {
+		query = query.Where("category_id IS NULL OR category_id IN ?", categoryIDs)
+	} else {
+		query = query.Where("category_id IS NULL")
+	}
+	err := query.Find(&rules).Error
+	return rules, err
+}
+
+func (r *FeeRepository) FindRuleByID(id uint) (*models.FeeRule, error) {
+	var rule models.FeeRule
+	err := r.db.Preload("Category").First(&rule, id).Error
+	return &rule, err
+}
+
+func (r *FeeRepository) CreateRule(rule *models.FeeRule) error {
+	return r.db.Omit("Category").Create(rule).Error
+}
+
+func (r *FeeRepository) UpdateRule(rule *models.FeeRule) error {
+	return r.db.Omit("Category").Save(rule).Error
+}
+
+// DeleteRule removes a fee rule. It returns false if there was none.
+func (r *FeeRepository) DeleteRule(id uint) (bool, error) {
+	result := r.db.Delete(&models.FeeRule{}, id)
+	return result.RowsAffected > 0, result.Error
+}
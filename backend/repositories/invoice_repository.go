@@ -0,0 +1,74 @@
+// repositories/invoice_repository.go
+package repositories
+
+import (
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InvoiceRepository struct {
+	db *gorm.DB
+}
+
+func NewInvoiceRepository() *InvoiceRepository {
+	return &InvoiceRepository{
+		db: database.DB,
+	}
+}
+
+// FindByTransaction returns the invoice issued for a sale
+func (r *InvoiceRepository) FindByTransaction(transactionID uint) (*models.Invoice, error) {
+	var invoice models.Invoice
+	err := r.db.Where("transaction_id = ?", transactionID).First(&invoice).Error
+	return &invoice, err
+}
+
+// Issue gives an invoice the seller's next sequence number, lets render
+// produce and store the document, and saves the invoice. The seller's
+// counter is locked until the invoice is saved and only moves forward
+// with it, so each seller's invoices are numbered without gaps.
+func (r *InvoiceRepository) Issue(invoice *models.Invoice, render func(*models.Invoice) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// A seller's first invoice needs the counter row to exist before it
+		// can be locked; concurrent first invoices all insert it and then
+		// queue on the lock
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.InvoiceSequence{SellerID: invoice.SellerID}).Error
+		if err != nil {
+			return err
+		}
+
+		var sequence models.InvoiceSequence
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("seller_id = ?", invoice.SellerID).
+			First(&sequence).Error
+		if err != nil {
+			return err
+		}
+
+		sequence.Last++
+		invoice.Sequence = sequence.Last
+		if err := render(invoice); err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Create(invoice).Error; err != nil {
+			return err
+		}
+		return tx.Save(&sequence).Error
+	})
+}
+
+// MoveDocument points an issued invoice at a new copy of its document. It
+// returns false if the invoice no longer points where it did.
+func (r *InvoiceRepository) MoveDocument(invoice *models.Invoice, storageKey string) (bool, error) {
+	result := r.db.Model(&models.Invoice{}).
+		Where("id = ? AND storage_key = ?", invoice.ID, invoice.StorageKey).
+		Update("storage_key", storageKey)
+	if result.Error != nil || result.RowsAffected != 1 {
+		return false, result.Error
+	}
+	invoice.StorageKey = storageKey
+	return true, nil
+}
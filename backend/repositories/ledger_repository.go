@@ -0,0 +1,316 @@
+// repositories/ledger_repository.go
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errPayoutClaimed rolls back a payout whose transactions another payout took
+var errPayoutClaimed = errors.New("transactions already paid out")
+
+type LedgerRepository struct {
+	db *gorm.DB
+}
+
+func NewLedgerRepository() *LedgerRepository {
+	return &LedgerRepository{
+		db: database.DB,
+	}
+}
+
+// EntryImbalance is a ledger entry whose postings do not sum to zero in a
+// currency
+type EntryImbalance struct {
+	EntryID   uint
+	Reference string
+	Currency  string
+	Total     int64
+}
+
+// BalanceDrift is an account whose stored balance differs from the sum of
+// its postings
+type BalanceDrift struct {
+	AccountID    uint
+	Type         string
+	UserID       uint
+	Currency     string
+	BalanceMinor int64
+	PostingMinor int64
+}
+
+// CurrencyTotal is an amount in minor units in one currency
+type CurrencyTotal struct {
+	Currency string
+	Minor    int64
+}
+
+// Post records a ledger entry and adds its postings to the account
+// balances, creating accounts the first time they are used. It reports
+// false, changing nothing, if an entry with the same reference exists.
+func (r *LedgerRepository) Post(entry *models.LedgerEntry) (bool, error) {
+	posted := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		posted, err = post(tx, entry)
+		return err
+	})
+	return posted, err
+}
+
+// Settle marks a paid transaction settled and records its settlement in
+// one step. It reports false if the transaction was already settled, or
+// is unpaid, cancelled or disputed.
+func (r *LedgerRepository) Settle(transactionID uint, entry *models.LedgerEntry, now time.Time) (bool, error) {
+	settled := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		statuses := []string{models.TransactionPaid, models.TransactionShipped, models.TransactionCompleted}
+		result := tx.Model(&models.Transaction{}).
+			Where("id = ? AND status IN ? AND settled_at IS NULL", transactionID, statuses).
+			Update("settled_at", now)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		var err error
+		settled, err = post(tx, entry)
+		return err
+	})
+	return settled, err
+}
+
+// CreatePayout records a payout, claims the transactions it pays for and
+// posts its ledger entry, in one step. The entry's reference is set from
+// the payout. It reports false, creating nothing, if another payout
+// claimed any of the transactions first.
+func (r *LedgerRepository) CreatePayout(payout *models.Payout, transactionIDs []uint, entry *models.LedgerEntry) (bool, error) {
+	created := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(payout).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.Transaction{}).
+			Where("id IN ? AND payout_id IS NULL", transactionIDs).
+			Update("payout_id", payout.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != int64(len(transactionIDs)) {
+			return errPayoutClaimed
+		}
+
+		entry.PayoutID = &payout.ID
+		entry.Reference = fmt.Sprintf("payout:%d", payout.ID)
+		var err error
+		created, err = post(tx, entry)
+		return err
+	})
+	if errors.Is(err, errPayoutClaimed) {
+		return false, nil
+	}
+	return created, err
+}
+
+// post records an entry inside a database transaction
+func post(tx *gorm.DB, entry *models.LedgerEntry) (bool, error) {
+	postings := entry.Postings
+	entry.Postings = nil
+	defer func() { entry.Postings = postings }()
+
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(entry)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+
+	for i := range postings {
+		posting := &postings[i]
+		account := models.LedgerAccount{Type: posting.Account.Type, UserID: posting.Account.UserID, Currency: posting.Amount.Currency}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&account).Error; err != nil {
+			return false, err
+		}
+		if err := tx.Where("type = ? AND user_id = ? AND currency = ?", account.Type, account.UserID, account.Currency).
+			First(&account).Error; err != nil {
+			return false, err
+		}
+
+		posting.EntryID = entry.ID
+		posting.AccountID = account.ID
+		posting.Account = account
+		if err := tx.Omit("Account").Create(posting).Error; err != nil {
+			return false, err
+		}
+		if err := tx.Model(&models.LedgerAccount{}).Where("id = ?", account.ID).
+			Update("balance_minor", gorm.Expr("balance_minor + ?", posting.Amount.Minor)).Error; err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// FindAccounts lists ledger accounts, optionally of one type or one user
+func (r *LedgerRepository) FindAccounts(accountType string, userID uint, page, limit int) ([]models.LedgerAccount, int64, error) {
+	var accounts []models.LedgerAccount
+	var count int64
+
+	offset := (page - 1) * limit
+	query := r.db.Model(&models.LedgerAccount{})
+	if accountType != "" {
+		query = query.Where("type = ?", accountType)
+	}
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("type, user_id, currency").Offset(offset).Limit(limit).Find(&accounts).Error
+	return accounts, count, err
+}
+
+// FindEntries lists the entries that posted to an account, newest first
+func (r *LedgerRepository) FindEntries(accountID uint, page, limit int) ([]models.LedgerEntry, int64, error) {
+	var entries []models.LedgerEntry
+	var count int64
+
+	offset := (page - 1) * limit
+	query := r.db.Model(&models.LedgerEntry{}).
+		Where("id IN (?)", r.db.Model(&models.LedgerPosting{}).Select("entry_id").Where("account_id = ?", accountID))
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Postings.Account").
+		Order("created_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&entries).Error
+	return entries, count, err
+}
+
+// FindUnsettled lists sales whose payment should have been released to the
+// seller but has not been settled: completed sales, and paid sales without
+// escrow. Disputed sales are left out.
+func (r *LedgerRepository) FindUnsettled() ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	err := r.db.Where("settled_at IS NULL").
+		Where("status = ? OR (status IN ? AND escrow = ?)",
+			models.TransactionCompleted, []string{models.TransactionPaid, models.TransactionShipped}, false).
+		Order("id").
+		Find(&transactions).Error
+	return transactions, err
+}
+
+// FindUnpaidSettled lists settled transactions that no payout has paid for yet
+func (r *LedgerRepository) FindUnpaidSettled() ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	err := r.db.Where("settled_at IS NOT NULL AND payout_id IS NULL").
+		Order("seller_id, settled_at").
+		Find(&transactions).Error
+	return transactions, err
+}
+
+// FindPayouts lists payouts, optionally for one seller, newest first
+func (r *LedgerRepository) FindPayouts(sellerID uint, page, limit int) ([]models.Payout, int64, error) {
+	var payouts []models.Payout
+	var count int64
+
+	offset := (page - 1) * limit
+	query := r.db.Model(&models.Payout{})
+	if sellerID != 0 {
+		query = query.Where("seller_id = ?", sellerID)
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Transactions").Preload("Transactions.Listing").
+		Order("created_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&payouts).Error
+	return payouts, count, err
+}
+
+// FindUnbalancedEntries lists entries whose postings do not sum to zero
+func (r *LedgerRepository) FindUnbalancedEntries() ([]EntryImbalance, error) {
+	var imbalances []EntryImbalance
+	err := r.db.Raw(`
+		SELECT ledger_entries.id AS entry_id, ledger_entries.reference, ledger_postings.amount_currency AS currency,
+			SUM(ledger_postings.amount_minor) AS total
+		FROM ledger_postings
+		JOIN ledger_entries ON ledger_entries.id = ledger_postings.entry_id
+		GROUP BY ledger_entries.id, ledger_entries.reference, ledger_postings.amount_currency
+		HAVING SUM(ledger_postings.amount_minor) <> 0`).Scan(&imbalances).Error
+	return imbalances, err
+}
+
+// FindBalanceDrift lists accounts whose balance is not the sum of their postings
+func (r *LedgerRepository) FindBalanceDrift() ([]BalanceDrift, error) {
+	var drift []BalanceDrift
+	err := r.db.Raw(`
+		SELECT ledger_accounts.id AS account_id, ledger_accounts.type, ledger_accounts.user_id, ledger_accounts.currency,
+			ledger_accounts.balance_minor, COALESCE(SUM(ledger_postings.amount_minor), 0) AS posting_minor
+		FROM ledger_accounts
+		LEFT JOIN ledger_postings ON ledger_postings.account_id = ledger_accounts.id
+		GROUP BY ledger_accounts.id
+		HAVING ledger_accounts.balance_minor <> COALESCE(SUM(ledger_postings.amount_minor), 0)`).Scan(&drift).Error
+	return drift, err
+}
+
+// SumBalances totals the balances of every account of a type, by currency
+func (r *LedgerRepository) SumBalances(accountType string) ([]CurrencyTotal, error) {
+	var totals []CurrencyTotal
+	err := r.db.Model(&models.LedgerAccount{}).
+		Select("currency, SUM(balance_minor) AS minor").
+		Where("type = ?", accountType).
+		Group("currency").
+		Scan(&totals).Error
+	return totals, err
+}
+
+// SumHeldPayments totals, by currency, the payments taken for
+// transactions that have not settled: the money escrow should be holding.
+// Partial refunds come out of the seller's share, not out of escrow.
+func (r *LedgerRepository) SumHeldPayments() ([]CurrencyTotal, error) {
+	var totals []CurrencyTotal
+	err := r.db.Model(&models.Payment{}).
+		Select("payments.amount_currency AS currency, SUM(payments.amount_minor) AS minor").
+		Joins("JOIN transactions ON transactions.id = payments.transaction_id").
+		Where("payments.status IN ? AND transactions.settled_at IS NULL",
+			[]string{models.PaymentCompleted, models.PaymentRefunding, models.PaymentPartiallyRefunded}).
+		Group("payments.amount_currency").
+		Scan(&totals).Error
+	return totals, err
+}
+
+// FindMissingEntries lists the references of ledger entries that should
+// exist but do not: payments taken, refunds made and transactions settled
+// without a matching entry
+func (r *LedgerRepository) FindMissingEntries() ([]string, error) {
+	var references []string
+	err := r.db.Raw(`
+		SELECT expected.reference FROM (
+			SELECT 'payment:' || id AS reference FROM payments WHERE status IN (?, ?, ?, ?) AND deleted_at IS NULL
+			UNION ALL
+			SELECT 'refund:' || id FROM payments WHERE status = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT 'partial_refund:' || id FROM payments WHERE status = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT 'settlement:' || id FROM transactions WHERE settled_at IS NOT NULL AND deleted_at IS NULL
+		) AS expected
+		LEFT JOIN ledger_entries ON ledger_entries.reference = expected.reference
+		WHERE ledger_entries.id IS NULL
+		ORDER BY expected.reference`,
+		models.PaymentCompleted, models.PaymentRefunding, models.PaymentRefunded, models.PaymentPartiallyRefunded,
+		models.PaymentRefunded, models.PaymentPartiallyRefunded).Scan(&references).Error
+	return references, err
+}
@@ -2,11 +2,32 @@
 package repositories
 
 import (
+    "time"
+
     "github.com/jimsyyap/auctions/backend/database"
     "github.com/jimsyyap/auctions/backend/models"
     "gorm.io/gorm"
 )
 
+// ListingFilter narrows a listing search. Amounts in different currencies
+// cannot be compared directly, so price bounds are given once for each
+// currency they apply to; listings in any other currency are left out.
+type ListingFilter struct {
+    PriceRanges []PriceRange
+}
+
+// PriceRange bounds the current price, in minor units, of listings in one
+// currency. A nil bound is open.
+type PriceRange struct {
+    Currency string
+    Min      *int64
+    Max      *int64
+}
+
+// currentPriceSQL is a listing's highest bid, or its start price before any bids
+const currentPriceSQL = `COALESCE((SELECT MAX(bids.amount_minor) FROM bids
+    WHERE bids.listing_id = listings.id AND bids.deleted_at IS NULL), listings.start_price_minor)`
+
 type ListingRepository struct {
     db *gorm.DB
 }
@@ -23,7 +44,7 @@ func (r *ListingRepository) Create(listing *models.Listing) error {
 
 func (r *ListingRepository) FindByID(id uint) (*models.Listing, error) {
     var listing models.Listing
-    err := r.db.Preload("User").Preload("Categories").Preload("Images").First(&listing, id).Error
+    err := r.db.Preload("User").Preload("Categories").Preload("Images").Preload("Bids.User").First(&listing, id).Error
     return &listing, err
 }
 
@@ -44,10 +65,66 @@ func (r *ListingRepository) FindAll(page, limit int) ([]models.Listing, int64, e
     return listings, count, err
 }
 
+// Search returns active listings that match the filter, newest first
+func (r *ListingRepository) Search(filter ListingFilter, page, limit int) ([]models.Listing, int64, error) {
+    var listings []models.Listing
+    var count int64
+
+    query := r.db.Model(&models.Listing{}).Where("status = ?", "active")
+    if len(filter.PriceRanges) > 0 {
+        prices := r.db.Where(r.priceCondition(filter.PriceRanges[0]))
+        for _, price := range filter.PriceRanges[1:] {
+            prices = prices.Or(r.priceCondition(price))
+        }
+        query = query.Where(prices)
+    }
+
+    if err := query.Count(&count).Error; err != nil {
+        return nil, 0, err
+    }
+
+    offset := (page - 1) * limit
+    err := query.Preload("Categories").Preload("Images").Preload("Bids.User").
+           Order("created_at DESC").Offset(offset).Limit(limit).Find(&listings).Error
+
+    return listings, count, err
+}
+
+// priceCondition matches listings in a price range
+func (r *ListingRepository) priceCondition(price PriceRange) *gorm.DB {
+    condition := r.db.Where("listings.currency = ?", price.Currency)
+    if price.Min != nil {
+        condition = condition.Where(currentPriceSQL+" >= ?", *price.Min)
+    }
+    if price.Max != nil {
+        condition = condition.Where(currentPriceSQL+" <= ?", *price.Max)
+    }
+    return condition
+}
+
 func (r *ListingRepository) Update(listing *models.Listing) error {
     return r.db.Save(listing).Error
 }
 
+// UpdateStatus changes only the status of a listing
+func (r *ListingRepository) UpdateStatus(id uint, status string) error {
+    return r.db.Model(&models.Listing{}).Where("id = ?", id).Update("status", status).Error
+}
+
+// FindDueToClose returns active listings whose end time has passed
+func (r *ListingRepository) FindDueToClose(now time.Time) ([]models.Listing, error) {
+    var listings []models.Listing
+    err := r.db.Preload("Categories").Where("status = ? AND end_time <= ?", "active", now).Find(&listings).Error
+    return listings, err
+}
+
+// CloseIfActive changes the status of a listing that is still active. It
+// reports false if the listing had already closed.
+func (r *ListingRepository) CloseIfActive(id uint, status string) (bool, error) {
+    result := r.db.Model(&models.Listing{}).Where("id = ? AND status = ?", id, "active").Update("status", status)
+    return result.RowsAffected > 0, result.Error
+}
+
 func (r *ListingRepository) Delete(id uint) error {
     return r.db.Delete(&models.Listing{}, id).Error
 }
@@ -0,0 +1,81 @@
+// repositories/login_throttle_repository.go
+package repositories
+
+import (
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LoginThrottleRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginThrottleRepository() *LoginThrottleRepository {
+	return &LoginThrottleRepository{
+		db: database.DB,
+	}
+}
+
+func (r *LoginThrottleRepository) FindByID(id uint) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	err := r.db.First(&throttle, id).Error
+	return &throttle, err
+}
+
+// Find returns the throttle for a key, or a zero value if none exists yet
+func (r *LoginThrottleRepository) Find(kind, value string) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	err := r.db.Where("kind = ? AND value = ?", kind, value).
+		Limit(1).Find(&throttle).Error
+	return &throttle, err
+}
+
+// Modify loads (or creates) the throttle for a key under a row lock, applies
+// fn and saves the result, so concurrent failures are counted correctly
+func (r *LoginThrottleRepository) Modify(kind, value string, fn func(*models.LoginThrottle)) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where(models.LoginThrottle{Kind: kind, Value: value}).
+			FirstOrCreate(&throttle).Error
+		if err != nil {
+			return err
+		}
+		fn(&throttle)
+		return tx.Save(&throttle).Error
+	})
+	return &throttle, err
+}
+
+// Reset clears the failure history for a key
+func (r *LoginThrottleRepository) Reset(kind, value string) error {
+	return r.db.Unscoped().Where("kind = ? AND value = ?", kind, value).
+		Delete(&models.LoginThrottle{}).Error
+}
+
+func (r *LoginThrottleRepository) Delete(id uint) error {
+	return r.db.Unscoped().Delete(&models.LoginThrottle{}, id).Error
+}
+
+// FindLocked returns all keys that are locked out at the given time
+func (r *LoginThrottleRepository) FindLocked(now time.Time, page, limit int) ([]models.LoginThrottle, int64, error) {
+	var throttles []models.LoginThrottle
+	var count int64
+
+	offset := (page - 1) * limit
+	query := r.db.Model(&models.LoginThrottle{}).Where("locked_until > ?", now)
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("locked_until DESC").
+		Offset(offset).Limit(limit).
+		Find(&throttles).Error
+
+	return throttles, count, err
+}
@@ -0,0 +1,178 @@
+// repositories/message_repository.go
+package repositories
+
+import (
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+)
+
+type MessageRepository struct {
+	db *gorm.DB
+}
+
+func NewMessageRepository() *MessageRepository {
+	return &MessageRepository{
+		db: database.DB,
+	}
+}
+
+// FindOrCreateThread returns the thread between a listing's seller and a
+// buyer, creating it if it does not exist yet
+func (r *MessageRepository) FindOrCreateThread(listingID, sellerID, buyerID uint) (*models.MessageThread, error) {
+	var thread models.MessageThread
+	err := r.db.Where(models.MessageThread{ListingID: listingID, BuyerID: buyerID}).
+		Attrs(models.MessageThread{SellerID: sellerID, LastMessageAt: time.Now()}).
+		FirstOrCreate(&thread).Error
+	if err != nil {
+		return nil, err
+	}
+	return r.FindThreadByID(thread.ID)
+}
+
+func (r *MessageRepository) FindThreadByID(id uint) (*models.MessageThread, error) {
+	var thread models.MessageThread
+	err := r.db.Preload("Listing").Preload("Seller").Preload("Buyer").First(&thread, id).Error
+	return &thread, err
+}
+
+// FindThreadsByUser lists the threads a user takes part in, most recently
+// active first
+func (r *MessageRepository) FindThreadsByUser(userID uint, page, limit int) ([]models.MessageThread, int64, error) {
+	var threads []models.MessageThread
+	var count int64
+
+	offset := (page - 1) * limit
+	query := r.db.Model(&models.MessageThread{}).Where("seller_id = ? OR buyer_id = ?", userID, userID)
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Listing").Preload("Seller").Preload("Buyer").
+		Order("last_message_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&threads).Error
+
+	return threads, count, err
+}
+
+// FindReportedThreads lists reported threads, oldest report first
+func (r *MessageRepository) FindReportedThreads(page, limit int) ([]models.MessageThread, int64, error) {
+	var threads []models.MessageThread
+	var count int64
+
+	offset := (page - 1) * limit
+	query := r.db.Model(&models.MessageThread{}).Where("reported_at IS NOT NULL")
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Listing").Preload("Seller").Preload("Buyer").
+		Order("reported_at ASC").
+		Offset(offset).Limit(limit).
+		Find(&threads).Error
+
+	return threads, count, err
+}
+
+func (r *MessageRepository) UpdateThread(thread *models.MessageThread) error {
+	return r.db.Omit("Listing", "Seller", "Buyer").Save(thread).Error
+}
+
+// CountUnread returns the number of unread messages a user has in each of
+// the given threads
+func (r *MessageRepository) CountUnread(userID uint, threadIDs []uint) (map[uint]int64, error) {
+	var rows []struct {
+		ThreadID uint
+		Count    int64
+	}
+	err := r.db.Model(&models.Message{}).
+		Select("thread_id, COUNT(*) AS count").
+		Where("recipient_id = ? AND read_at IS NULL AND moderation_status = '' AND thread_id IN ?", userID, threadIDs).
+		Group("thread_id").
+		Scan(&rows).Error
+
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.ThreadID] = row.Count
+	}
+	return counts, err
+}
+
+// CountAllUnread returns the number of unread messages a user has overall
+func (r *MessageRepository) CountAllUnread(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Message{}).
+		Where("recipient_id = ? AND read_at IS NULL AND moderation_status = ''", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// CreateMessage stores a message with its attachments and moves the thread
+// to the top of both participants' inboxes
+func (r *MessageRepository) CreateMessage(message *models.Message) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Thread", "Sender", "Recipient").Create(message).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.MessageThread{}).
+			Where("id = ?", message.ThreadID).
+			Update("last_message_at", message.CreatedAt).Error
+	})
+}
+
+// FindMessages lists a thread's messages, newest first. Messages held by
+// moderation are only included for their sender, or for everyone when
+// viewerID is 0.
+func (r *MessageRepository) FindMessages(threadID, viewerID uint, page, limit int) ([]models.Message, int64, error) {
+	var messages []models.Message
+	var count int64
+
+	offset := (page - 1) * limit
+	query := r.db.Model(&models.Message{}).Where("thread_id = ?", threadID)
+	if viewerID != 0 {
+		query = query.Where("moderation_status = '' OR sender_id = ?", viewerID)
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Attachments").
+		Order("created_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&messages).Error
+
+	return messages, count, err
+}
+
+// MarkRead marks every message a user received in a thread as read
+func (r *MessageRepository) MarkRead(threadID, recipientID uint) error {
+	return r.db.Model(&models.Message{}).
+		Where("thread_id = ? AND recipient_id = ? AND read_at IS NULL AND moderation_status = ''", threadID, recipientID).
+		Update("read_at", time.Now()).Error
+}
+
+func (r *MessageRepository) FindMessageByID(id uint) (*models.Message, error) {
+	var message models.Message
+	err := r.db.Preload("Thread.Listing").Preload("Sender").First(&message, id).Error
+	return &message, err
+}
+
+// SetModerationStatus changes whether a message is visible to its recipient
+func (r *MessageRepository) SetModerationStatus(id uint, status string) error {
+	return r.db.Model(&models.Message{}).Where("id = ?", id).Update("moderation_status", status).Error
+}
+
+// FindAttachment returns an attachment of a message in the given thread
+func (r *MessageRepository) FindAttachment(threadID, attachmentID uint) (*models.MessageAttachment, error) {
+	var attachment models.MessageAttachment
+	err := r.db.Joins("JOIN messages ON messages.id = message_attachments.message_id").
+		Where("messages.thread_id = ?", threadID).
+		First(&attachment, attachmentID).Error
+	return &attachment, err
+}
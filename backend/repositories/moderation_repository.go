@@ -0,0 +1,113 @@
+// repositories/moderation_repository.go
+package repositories
+
+import (
+	"errors"
+
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+)
+
+// DecisionFilter narrows the moderation audit log
+type DecisionFilter struct {
+	ContentType string
+	Action      string
+	PendingOnly bool // only held content that has not been reviewed
+}
+
+type ModerationRepository struct {
+	db *gorm.DB
+}
+
+func NewModerationRepository() *ModerationRepository {
+	return &ModerationRepository{
+		db: database.DB,
+	}
+}
+
+func (r *ModerationRepository) FindRules() ([]models.ModerationRule, error) {
+	var rules []models.ModerationRule
+	err := r.db.Order("content_type, check_name").Find(&rules).Error
+	return rules, err
+}
+
+// SaveRule creates or replaces the rule for a content type and check
+func (r *ModerationRepository) SaveRule(rule *models.ModerationRule) error {
+	var existing models.ModerationRule
+	err := r.db.Where("content_type = ? AND check_name = ?", rule.ContentType, rule.Check).First(&existing).Error
+	if err == nil {
+		rule.ID = existing.ID
+		rule.CreatedAt = existing.CreatedAt
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return r.db.Save(rule).Error
+}
+
+func (r *ModerationRepository) FindBannedWords() ([]models.BannedWord, error) {
+	var words []models.BannedWord
+	err := r.db.Order("word").Find(&words).Error
+	return words, err
+}
+
+func (r *ModerationRepository) CreateBannedWord(word *models.BannedWord) error {
+	return r.db.Create(word).Error
+}
+
+// DeleteBannedWord removes a banned word. It returns false if there was none.
+func (r *ModerationRepository) DeleteBannedWord(id uint) (bool, error) {
+	result := r.db.Unscoped().Delete(&models.BannedWord{}, id)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *ModerationRepository) CreateDecision(decision *models.ModerationDecision) error {
+	return r.db.Omit("Author").Create(decision).Error
+}
+
+func (r *ModerationRepository) FindDecisionByID(id uint) (*models.ModerationDecision, error) {
+	var decision models.ModerationDecision
+	err := r.db.First(&decision, id).Error
+	return &decision, err
+}
+
+// FindDecisions lists moderation decisions, newest first
+func (r *ModerationRepository) FindDecisions(filter DecisionFilter, page, limit int) ([]models.ModerationDecision, int64, error) {
+	var decisions []models.ModerationDecision
+	var count int64
+
+	offset := (page - 1) * limit
+	query := r.db.Model(&models.ModerationDecision{})
+	if filter.ContentType != "" {
+		query = query.Where("content_type = ?", filter.ContentType)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.PendingOnly {
+		query = query.Where("action = ? AND reviewed_at IS NULL", models.ModerationHold)
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&decisions).Error
+
+	return decisions, count, err
+}
+
+// ResolveDecision records the review of held content. It returns false if
+// the decision was already reviewed.
+func (r *ModerationRepository) ResolveDecision(decision *models.ModerationDecision) (bool, error) {
+	result := r.db.Model(&models.ModerationDecision{}).
+		Where("id = ? AND reviewed_at IS NULL", decision.ID).
+		Updates(map[string]interface{}{
+			"resolution":     decision.Resolution,
+			"reviewed_at":    decision.ReviewedAt,
+			"reviewed_by_id": decision.ReviewedByID,
+		})
+	return result.RowsAffected == 1, result.Error
+}
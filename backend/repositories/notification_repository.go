@@ -0,0 +1,60 @@
+// repositories/notification_repository.go
+package repositories
+
+import (
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+)
+
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository() *NotificationRepository {
+	return &NotificationRepository{
+		db: database.DB,
+	}
+}
+
+func (r *NotificationRepository) Create(notification *models.Notification) error {
+	return r.db.Create(notification).Error
+}
+
+func (r *NotificationRepository) FindByUser(userID uint, unreadOnly bool, page, limit int) ([]models.Notification, int64, error) {
+	var notifications []models.Notification
+	var count int64
+
+	offset := (page - 1) * limit
+	query := r.db.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("is_read = ?", false)
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&notifications).Error
+
+	return notifications, count, err
+}
+
+// MarkRead marks one of a user's notifications as read
+func (r *NotificationRepository) MarkRead(userID, id uint) (bool, error) {
+	result := r.db.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Updates(map[string]interface{}{"is_read": true, "read_at": time.Now()})
+	return result.RowsAffected > 0, result.Error
+}
+
+// MarkAllRead marks every unread notification of a user as read
+func (r *NotificationRepository) MarkAllRead(userID uint) error {
+	return r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Updates(map[string]interface{}{"is_read": true, "read_at": time.Now()}).Error
+}
@@ -0,0 +1,41 @@
+// repositories/oidc_state_repository.go
+package repositories
+
+import (
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+)
+
+type OIDCStateRepository struct {
+	db *gorm.DB
+}
+
+func NewOIDCStateRepository() *OIDCStateRepository {
+	return &OIDCStateRepository{
+		db: database.DB,
+	}
+}
+
+func (r *OIDCStateRepository) Create(state *models.OIDCState) error {
+	return r.db.Create(state).Error
+}
+
+// Consume loads and deletes a state so it can only be used once
+func (r *OIDCStateRepository) Consume(stateHash string) (*models.OIDCState, error) {
+	var state models.OIDCState
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("state_hash = ?", stateHash).First(&state).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("id = ?", state.ID).Delete(&models.OIDCState{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	return &state, err
+}
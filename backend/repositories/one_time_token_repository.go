@@ -0,0 +1,102 @@
+// repositories/one_time_token_repository.go
+package repositories
+
+import (
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OneTimeTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewOneTimeTokenRepository() *OneTimeTokenRepository {
+	return &OneTimeTokenRepository{
+		db: database.DB,
+	}
+}
+
+func (r *OneTimeTokenRepository) Create(token *models.OneTimeToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *OneTimeTokenRepository) FindByHash(purpose, hash string) (*models.OneTimeToken, error) {
+	var token models.OneTimeToken
+	err := r.db.Preload("User").
+		Where("purpose = ? AND token_hash = ?", purpose, hash).
+		First(&token).Error
+	return &token, err
+}
+
+// MarkUsed consumes a token. It returns false if the token was already used.
+func (r *OneTimeTokenRepository) MarkUsed(id uint) (bool, error) {
+	result := r.db.Model(&models.OneTimeToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+// RecordFailedAttempt counts a wrong code entered against a token and
+// consumes the token once maxAttempts have been made. It returns true if the
+// token can no longer be used.
+func (r *OneTimeTokenRepository) RecordFailedAttempt(id uint, maxAttempts int) (bool, error) {
+	exhausted := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var token models.OneTimeToken
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND used_at IS NULL", id).
+			Limit(1).Find(&token).Error
+		if err != nil {
+			return err
+		}
+		if token.ID == 0 {
+			exhausted = true
+			return nil
+		}
+
+		updates := map[string]interface{}{"failed_attempts": token.FailedAttempts + 1}
+		if token.FailedAttempts+1 >= maxAttempts {
+			updates["used_at"] = time.Now()
+			exhausted = true
+		}
+		return tx.Model(&token).Updates(updates).Error
+	})
+	return exhausted, err
+}
+
+// ResetPassword consumes a password reset token and sets the user's new
+// password together, so a reset link is only used up by a reset that
+// happened. Other outstanding reset links are consumed too. It returns
+// false if the token was already used.
+func (r *OneTimeTokenRepository) ResetPassword(token *models.OneTimeToken, passwordHash string) (bool, error) {
+	used := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.OneTimeToken{}).
+			Where("id = ? AND used_at IS NULL", token.ID).
+			Update("used_at", now)
+		if result.Error != nil || result.RowsAffected != 1 {
+			return result.Error
+		}
+		used = true
+
+		if err := tx.Model(&models.User{}).Where("id = ?", token.UserID).Update("password", passwordHash).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.OneTimeToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", token.UserID, token.Purpose).
+			Update("used_at", now).Error
+	})
+	return used && err == nil, err
+}
+
+// InvalidateForUser consumes every outstanding token of a purpose for a user
+func (r *OneTimeTokenRepository) InvalidateForUser(userID uint, purpose string) error {
+	return r.db.Model(&models.OneTimeToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error
+}
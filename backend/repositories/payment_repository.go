@@ -0,0 +1,107 @@
+// repositories/payment_repository.go
+package repositories
+
+import (
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+)
+
+type PaymentRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepository() *PaymentRepository {
+	return &PaymentRepository{
+		db: database.DB,
+	}
+}
+
+func (r *PaymentRepository) Create(payment *models.Payment) error {
+	return r.db.Create(payment).Error
+}
+
+// FindByProviderRef returns the payment for a provider's intent
+func (r *PaymentRepository) FindByProviderRef(provider, ref string) (*models.Payment, error) {
+	var payment models.Payment
+	err := r.db.Preload("Transaction").Preload("Transaction.Listing").
+		Where("provider = ? AND provider_ref = ?", provider, ref).
+		First(&payment).Error
+	return &payment, err
+}
+
+// FindByTransaction lists the payment attempts for a transaction, newest first
+func (r *PaymentRepository) FindByTransaction(transactionID uint) ([]models.Payment, error) {
+	var payments []models.Payment
+	err := r.db.Where("transaction_id = ?", transactionID).
+		Order("created_at DESC").
+		Find(&payments).Error
+	return payments, err
+}
+
+// FindLatest returns the most recent payment attempt with a status
+func (r *PaymentRepository) FindLatest(transactionID uint, status string) (*models.Payment, error) {
+	var payment models.Payment
+	err := r.db.Where("transaction_id = ? AND status = ?", transactionID, status).
+		Order("created_at DESC").
+		First(&payment).Error
+	return &payment, err
+}
+
+// Complete records a captured payment and marks its transaction paid, in
+// escrow if the buyer asked for it, in one step. completed is false if the payment was no longer pending; paid
+// is false if the transaction was no longer awaiting payment, for example
+// because it was cancelled while the buyer was paying.
+func (r *PaymentRepository) Complete(payment *models.Payment, now time.Time) (completed, paid bool, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Payment{}).
+			Where("id = ? AND status = ?", payment.ID, models.PaymentPending).
+			Updates(map[string]interface{}{"status": models.PaymentCompleted, "paid_at": now})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		completed = true
+
+		result = tx.Model(&models.Transaction{}).
+			Where("id = ? AND status = ?", payment.TransactionID, models.TransactionAwaitingPayment).
+			Updates(map[string]interface{}{"status": models.TransactionPaid, "paid_at": now, "escrow": payment.Escrow})
+		if result.Error != nil {
+			return result.Error
+		}
+		paid = result.RowsAffected > 0
+		return nil
+	})
+	return completed, paid, err
+}
+
+// UpdateStatus saves a payment, but only if its status is still fromStatus.
+// It reports false if another request changed the payment first.
+func (r *PaymentRepository) UpdateStatus(payment *models.Payment, fromStatus string) (bool, error) {
+	result := r.db.Model(&models.Payment{}).
+		Where("id = ? AND status = ?", payment.ID, fromStatus).
+		Updates(map[string]interface{}{
+			"status":            payment.Status,
+			"failure_reason":    payment.FailureReason,
+			"refund_ref":        payment.RefundRef,
+			"refunded_minor":    payment.RefundedAmount.Minor,
+			"refunded_currency": payment.RefundedAmount.Currency,
+			"refunded_at":       payment.RefundedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// EventProcessed reports whether a provider's webhook event was handled before
+func (r *PaymentRepository) EventProcessed(provider, eventID string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.PaymentEvent{}).
+		Where("provider = ? AND event_id = ?", provider, eventID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// RecordEvent marks a webhook event as handled
+func (r *PaymentRepository) RecordEvent(event *models.PaymentEvent) error {
+	return r.db.Create(event).Error
+}
@@ -0,0 +1,64 @@
+// repositories/question_repository.go
+package repositories
+
+import (
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+)
+
+type QuestionRepository struct {
+	db *gorm.DB
+}
+
+func NewQuestionRepository() *QuestionRepository {
+	return &QuestionRepository{
+		db: database.DB,
+	}
+}
+
+func (r *QuestionRepository) Create(question *models.ListingQuestion) error {
+	return r.db.Omit("Listing", "Asker").Create(question).Error
+}
+
+func (r *QuestionRepository) Update(question *models.ListingQuestion) error {
+	return r.db.Omit("Listing", "Asker").Save(question).Error
+}
+
+func (r *QuestionRepository) FindByID(id uint) (*models.ListingQuestion, error) {
+	var question models.ListingQuestion
+	err := r.db.Preload("Listing").Preload("Asker").First(&question, id).Error
+	return &question, err
+}
+
+// FindByListing lists a listing's questions, oldest first. A non-zero
+// askerID limits the list to that user's questions.
+func (r *QuestionRepository) FindByListing(listingID, askerID uint) ([]models.ListingQuestion, error) {
+	var questions []models.ListingQuestion
+	query := r.db.Preload("Asker").Where("listing_id = ?", listingID)
+	if askerID != 0 {
+		query = query.Where("asker_id = ?", askerID)
+	}
+	err := query.Order("created_at").Find(&questions).Error
+	return questions, err
+}
+
+// FindPublished lists the questions shown on a listing's page
+func (r *QuestionRepository) FindPublished(listingID uint) ([]models.ListingQuestion, error) {
+	var questions []models.ListingQuestion
+	err := r.db.Where("listing_id = ? AND is_published = ? AND moderation_status = ''", listingID, true).
+		Order("published_at").
+		Find(&questions).Error
+	return questions, err
+}
+
+// CountSince counts the questions a user asked about a listing since a time
+func (r *QuestionRepository) CountSince(listingID, askerID uint, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.ListingQuestion{}).
+		Where("listing_id = ? AND asker_id = ? AND created_at >= ?", listingID, askerID, since).
+		Count(&count).Error
+	return count, err
+}
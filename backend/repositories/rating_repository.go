@@ -0,0 +1,106 @@
+// repositories/rating_repository.go
+package repositories
+
+import (
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+)
+
+// RatingFilter narrows a list of ratings
+type RatingFilter struct {
+	RatedAs   string    // seller or buyer; empty for both
+	Sentiment string    // positive, neutral or negative; empty for all
+	Since     time.Time // zero for all time
+}
+
+type RatingRepository struct {
+	db *gorm.DB
+}
+
+func NewRatingRepository() *RatingRepository {
+	return &RatingRepository{
+		db: database.DB,
+	}
+}
+
+// Create stores a rating and adds it to the rated user's reputation in the
+// same transaction
+func (r *RatingRepository) Create(rating *models.Rating) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("RaterUser", "RatedUser", "Listing").Create(rating).Error; err != nil {
+			return err
+		}
+		return applyReputation(tx, rating)
+	})
+}
+
+func (r *RatingRepository) FindByID(id uint) (*models.Rating, error) {
+	var rating models.Rating
+	err := r.db.Preload("RaterUser").Preload("RatedUser").Preload("Listing").First(&rating, id).Error
+	return &rating, err
+}
+
+// Exists reports whether a user has already rated the other party of a sale
+func (r *RatingRepository) Exists(listingID, raterID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Rating{}).
+		Where("listing_id = ? AND rater_user_id = ?", listingID, raterID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// SaveReply records the rated user's reply. It returns false if the rating
+// already had one.
+func (r *RatingRepository) SaveReply(id uint, reply string) (bool, error) {
+	result := r.db.Model(&models.Rating{}).
+		Where("id = ? AND replied_at IS NULL", id).
+		Updates(map[string]interface{}{"reply": reply, "replied_at": time.Now()})
+	return result.RowsAffected == 1, result.Error
+}
+
+// FindReceived lists ratings a user received, newest first
+func (r *RatingRepository) FindReceived(userID uint, filter RatingFilter, page, limit int) ([]models.Rating, int64, error) {
+	return r.find("rated_user_id = ?", userID, filter, page, limit)
+}
+
+// FindGiven lists ratings a user gave, newest first. RatedAs filters by the
+// role of the user who was rated.
+func (r *RatingRepository) FindGiven(userID uint, filter RatingFilter, page, limit int) ([]models.Rating, int64, error) {
+	return r.find("rater_user_id = ?", userID, filter, page, limit)
+}
+
+func (r *RatingRepository) find(owner string, userID uint, filter RatingFilter, page, limit int) ([]models.Rating, int64, error) {
+	var ratings []models.Rating
+	var count int64
+
+	offset := (page - 1) * limit
+	query := r.db.Model(&models.Rating{}).Where(owner, userID)
+	if filter.RatedAs != "" {
+		query = query.Where("rated_as = ?", filter.RatedAs)
+	}
+	switch filter.Sentiment {
+	case "positive":
+		query = query.Where("score >= 4")
+	case "neutral":
+		query = query.Where("score = 3")
+	case "negative":
+		query = query.Where("score <= 2")
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("RaterUser").Preload("RatedUser").Preload("Listing").
+		Order("created_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&ratings).Error
+
+	return ratings, count, err
+}
@@ -0,0 +1,54 @@
+// repositories/recovery_code_repository.go
+package repositories
+
+import (
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+)
+
+type RecoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewRecoveryCodeRepository() *RecoveryCodeRepository {
+	return &RecoveryCodeRepository{
+		db: database.DB,
+	}
+}
+
+// ReplaceForUser discards a user's existing codes and stores new ones
+func (r *RecoveryCodeRepository) ReplaceForUser(userID uint, hashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]models.RecoveryCode, len(hashes))
+		for i, hash := range hashes {
+			codes[i] = models.RecoveryCode{UserID: userID, CodeHash: hash}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *RecoveryCodeRepository) DeleteForUser(userID uint) error {
+	return r.db.Unscoped().Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+}
+
+// Consume marks an unused code as used. It returns false if no such code exists.
+func (r *RecoveryCodeRepository) Consume(userID uint, hash string) (bool, error) {
+	result := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *RecoveryCodeRepository) CountUnused(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
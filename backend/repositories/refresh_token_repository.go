@@ -0,0 +1,53 @@
+// repositories/refresh_token_repository.go
+package repositories
+
+import (
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+)
+
+type RefreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository() *RefreshTokenRepository {
+	return &RefreshTokenRepository{
+		db: database.DB,
+	}
+}
+
+func (r *RefreshTokenRepository) Create(token *models.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *RefreshTokenRepository) FindByHash(hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.Preload("User").Where("token_hash = ?", hash).First(&token).Error
+	return &token, err
+}
+
+// MarkRotated flags a token as used. It returns false if the token had
+// already been rotated or revoked, which lets callers detect concurrent reuse.
+func (r *RefreshTokenRepository) MarkRotated(id uint) (bool, error) {
+	result := r.db.Model(&models.RefreshToken{}).
+		Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", id).
+		Update("rotated_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+// RevokeFamily revokes every token descended from the same login
+func (r *RefreshTokenRepository) RevokeFamily(familyID string) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAllForUser revokes every outstanding token belonging to a user
+func (r *RefreshTokenRepository) RevokeAllForUser(userID uint) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
@@ -0,0 +1,124 @@
+// repositories/reputation_repository.go
+package repositories
+
+import (
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReputationRepository struct {
+	db *gorm.DB
+}
+
+func NewReputationRepository() *ReputationRepository {
+	return &ReputationRepository{
+		db: database.DB,
+	}
+}
+
+// applyReputation adds a rating to the rated user's lifetime and daily
+// totals inside a transaction. New ratings are counted by
+// RatingRepository.Create in the same transaction that stores them.
+func applyReputation(tx *gorm.DB, rating *models.Rating) error {
+	counts := models.ReputationCounts{ScoreSum: int64(rating.Score)}
+	switch models.Sentiment(rating.Score) {
+	case "positive":
+		counts.Positive = 1
+	case "neutral":
+		counts.Neutral = 1
+	default:
+		counts.Negative = 1
+	}
+
+	lifetime := &models.UserReputation{
+		UserID:           rating.RatedUserID,
+		Role:             rating.RatedAs,
+		ReputationCounts: counts,
+		UpdatedAt:        time.Now(),
+	}
+	if err := tx.Clauses(incrementOnConflict("user_reputations", []string{"user_id", "role"}, "updated_at")).Create(lifetime).Error; err != nil {
+		return err
+	}
+
+	day := rating.CreatedAt.UTC().Truncate(24 * time.Hour)
+	daily := &models.ReputationDaily{
+		UserID:           rating.RatedUserID,
+		Role:             rating.RatedAs,
+		Day:              day,
+		ReputationCounts: counts,
+	}
+	return tx.Clauses(incrementOnConflict("reputation_daily", []string{"user_id", "role", "day"})).Create(daily).Error
+}
+
+// incrementOnConflict turns an insert of counts into an increment of the
+// existing row's counts. Columns in overwrite take the inserted value.
+func incrementOnConflict(table string, key []string, overwrite ...string) clause.OnConflict {
+	set := clause.Set{}
+	for _, column := range []string{"positive", "neutral", "negative", "score_sum"} {
+		set = append(set, clause.Assignment{
+			Column: clause.Column{Name: column},
+			Value:  gorm.Expr(table + "." + column + " + excluded." + column),
+		})
+	}
+	set = append(set, clause.AssignmentColumns(overwrite)...)
+	columns := make([]clause.Column, len(key))
+	for i, name := range key {
+		columns[i] = clause.Column{Name: name}
+	}
+	return clause.OnConflict{Columns: columns, DoUpdates: set}
+}
+
+// AddDisputeLost counts a dispute resolved against a seller
+func (r *ReputationRepository) AddDisputeLost(sellerID uint) error {
+	reputation := &models.UserReputation{
+		UserID:       sellerID,
+		Role:         models.RatedAsSeller,
+		DisputesLost: 1,
+		UpdatedAt:    time.Now(),
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "role"}},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "disputes_lost"}, Value: gorm.Expr("user_reputations.disputes_lost + 1")},
+			{Column: clause.Column{Name: "updated_at"}, Value: gorm.Expr("excluded.updated_at")},
+		},
+	}).Create(reputation).Error
+}
+
+// FindLifetime returns a user's lifetime totals by role
+func (r *ReputationRepository) FindLifetime(userID uint) ([]models.UserReputation, error) {
+	var reputations []models.UserReputation
+	err := r.db.Where("user_id = ?", userID).Find(&reputations).Error
+	return reputations, err
+}
+
+// SumSince returns a user's totals by role for ratings on or after a day
+func (r *ReputationRepository) SumSince(userID uint, since time.Time) (map[string]models.ReputationCounts, error) {
+	var rows []struct {
+		Role string
+		models.ReputationCounts
+	}
+	err := r.db.Model(&models.ReputationDaily{}).
+		Select("role, SUM(positive) AS positive, SUM(neutral) AS neutral, SUM(negative) AS negative, SUM(score_sum) AS score_sum").
+		Where("user_id = ? AND day >= ?", userID, since.UTC().Truncate(24*time.Hour)).
+		Group("role").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	sums := make(map[string]models.ReputationCounts, len(rows))
+	for _, row := range rows {
+		sums[row.Role] = row.ReputationCounts
+	}
+	return sums, nil
+}
+
+// PruneDaily deletes daily rows older than any rolling window needs
+func (r *ReputationRepository) PruneDaily(before time.Time) error {
+	return r.db.Where("day < ?", before.UTC().Truncate(24*time.Hour)).Delete(&models.ReputationDaily{}).Error
+}
@@ -0,0 +1,54 @@
+// repositories/role_repository.go
+package repositories
+
+import (
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+)
+
+type RoleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository() *RoleRepository {
+	return &RoleRepository{
+		db: database.DB,
+	}
+}
+
+func (r *RoleRepository) FindAll() ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.Preload("Permissions").Order("name").Find(&roles).Error
+	return roles, err
+}
+
+func (r *RoleRepository) FindByName(name string) (*models.Role, error) {
+	var role models.Role
+	err := r.db.Preload("Permissions").Where("name = ?", name).First(&role).Error
+	return &role, err
+}
+
+func (r *RoleRepository) FindByNames(names []string) ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.Where("name IN ?", names).Find(&roles).Error
+	return roles, err
+}
+
+// GetUserWithRoles loads a user with their roles and each role's permissions
+func (r *RoleRepository) GetUserWithRoles(userID uint) (*models.User, error) {
+	var user models.User
+	err := r.db.Preload("Roles.Permissions").First(&user, userID).Error
+	return &user, err
+}
+
+// ReplaceUserRoles sets a user's roles and keeps the legacy IsAdmin flag in sync
+func (r *RoleRepository) ReplaceUserRoles(user *models.User, roles []models.Role) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Association("Roles").Replace(roles); err != nil {
+			return err
+		}
+		user.Roles = roles
+		return tx.Model(user).Update("is_admin", user.HasRole(models.RoleAdmin)).Error
+	})
+}
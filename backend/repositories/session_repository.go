@@ -0,0 +1,97 @@
+// repositories/session_repository.go
+package repositories
+
+import (
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+)
+
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository() *SessionRepository {
+	return &SessionRepository{
+		db: database.DB,
+	}
+}
+
+func (r *SessionRepository) Create(session *models.Session) error {
+	return r.db.Create(session).Error
+}
+
+func (r *SessionRepository) FindByFamily(familyID string) (*models.Session, error) {
+	var session models.Session
+	err := r.db.Where("family_id = ?", familyID).First(&session).Error
+	return &session, err
+}
+
+func (r *SessionRepository) FindByID(id uint) (*models.Session, error) {
+	var session models.Session
+	err := r.db.First(&session, id).Error
+	return &session, err
+}
+
+// FindActiveByUser lists a user's unrevoked, unexpired sessions, most
+// recently used first
+func (r *SessionRepository) FindActiveByUser(userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// FindRevokedSince lists sessions revoked at or after the given time
+func (r *SessionRepository) FindRevokedSince(since time.Time) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.Select("id", "revoked_at").
+		Where("revoked_at >= ?", since).
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *SessionRepository) Update(session *models.Session) error {
+	return r.db.Omit("User").Save(session).Error
+}
+
+// Touch records activity seen on a session between refreshes
+func (r *SessionRepository) Touch(id uint, ipAddress string, at time.Time) error {
+	return r.db.Model(&models.Session{}).
+		Where("id = ? AND last_seen_at < ?", id, at).
+		Updates(map[string]interface{}{"last_seen_at": at, "ip_address": ipAddress}).Error
+}
+
+// RevokeFamily revokes the session that owns a refresh token family and
+// returns the IDs of the sessions it revoked
+func (r *SessionRepository) RevokeFamily(familyID string) ([]uint, error) {
+	return r.revokeWhere("family_id = ?", familyID)
+}
+
+// RevokeAllForUser revokes every active session belonging to a user
+func (r *SessionRepository) RevokeAllForUser(userID uint) ([]uint, error) {
+	return r.revokeWhere("user_id = ?", userID)
+}
+
+// revokeWhere revokes the matching active sessions and returns their IDs
+func (r *SessionRepository) revokeWhere(query string, args ...interface{}) ([]uint, error) {
+	var ids []uint
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Session{}).
+			Where("revoked_at IS NULL").
+			Where(query, args...).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return tx.Model(&models.Session{}).
+			Where("id IN ?", ids).
+			Update("revoked_at", time.Now()).Error
+	})
+	return ids, err
+}
@@ -0,0 +1,31 @@
+// repositories/setting_repository.go
+package repositories
+
+import (
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SettingRepository struct {
+	db *gorm.DB
+}
+
+func NewSettingRepository() *SettingRepository {
+	return &SettingRepository{
+		db: database.DB,
+	}
+}
+
+// FindAll returns the settings admins have changed
+func (r *SettingRepository) FindAll() ([]models.SystemSetting, error) {
+	var settings []models.SystemSetting
+	err := r.db.Order("key").Find(&settings).Error
+	return settings, err
+}
+
+// Save creates or replaces a setting
+func (r *SettingRepository) Save(setting *models.SystemSetting) error {
+	return r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(setting).Error
+}
@@ -0,0 +1,118 @@
+// repositories/transaction_repository.go
+package repositories
+
+import (
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TransactionRepository struct {
+	db *gorm.DB
+}
+
+func NewTransactionRepository() *TransactionRepository {
+	return &TransactionRepository{
+		db: database.DB,
+	}
+}
+
+// TransactionFilter narrows a user's transactions. Role is the user's side
+// of the sale, buyer or seller; empty values match everything.
+type TransactionFilter struct {
+	Role   string
+	Status string
+}
+
+// CreateForSale marks an active listing sold and records its transaction
+// in one step. It reports false, creating nothing, if the listing was no
+// longer active, for example because someone else bought it first.
+func (r *TransactionRepository) CreateForSale(transaction *models.Transaction) (bool, error) {
+	created := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Listing{}).
+			Where("id = ? AND status = ?", transaction.ListingID, "active").
+			Update("status", "sold")
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := tx.Create(transaction).Error; err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	return created, err
+}
+
+func (r *TransactionRepository) FindByID(id uint) (*models.Transaction, error) {
+	var transaction models.Transaction
+	err := r.db.Preload("Listing").Preload("Seller").Preload("Buyer").First(&transaction, id).Error
+	return &transaction, err
+}
+
+// FindByListing returns the transaction for a sold listing
+func (r *TransactionRepository) FindByListing(listingID uint) (*models.Transaction, error) {
+	var transaction models.Transaction
+	err := r.db.Preload("Listing").Where("listing_id = ?", listingID).First(&transaction).Error
+	return &transaction, err
+}
+
+// FindByUser lists the transactions a user bought or sold in, newest first
+func (r *TransactionRepository) FindByUser(userID uint, filter TransactionFilter, page, limit int) ([]models.Transaction, int64, error) {
+	var transactions []models.Transaction
+	var count int64
+
+	offset := (page - 1) * limit
+	query := r.db.Model(&models.Transaction{})
+	switch filter.Role {
+	case "buyer":
+		query = query.Where("buyer_id = ?", userID)
+	case "seller":
+		query = query.Where("seller_id = ?", userID)
+	default:
+		query = query.Where("buyer_id = ? OR seller_id = ?", userID, userID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Listing").Preload("Seller").Preload("Buyer").
+		Order("created_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&transactions).Error
+
+	return transactions, count, err
+}
+
+// FindEscrowDue lists shipped escrow transactions whose auto-release time
+// has passed
+func (r *TransactionRepository) FindEscrowDue(now time.Time) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	err := r.db.Preload("Listing").
+		Where("status = ? AND escrow = ? AND escrow_release_at <= ?", models.TransactionShipped, true, now).
+		Find(&transactions).Error
+	return transactions, err
+}
+
+// Update saves a transaction, but only if its status is still fromStatus.
+// It reports false if another request changed the transaction first.
+// Settlement and payout are left alone; the ledger records those.
+func (r *TransactionRepository) Update(transaction *models.Transaction, fromStatus string) (bool, error) {
+	result := r.db.Model(&models.Transaction{}).
+		Where("id = ? AND status = ?", transaction.ID, fromStatus).
+		Select("*").Omit("id", "created_at", "deleted_at", "settled_at", "payout_id", clause.Associations).
+		Updates(transaction)
+	return result.RowsAffected > 0, result.Error
+}
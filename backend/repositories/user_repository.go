@@ -86,3 +86,36 @@ func (r *UserRepository) GetUserBids(userID uint, page, limit int) ([]models.Bid
 	
 	return bids, count, err
 }
+
+// GetActiveListings returns a seller's active listings, newest first
+func (r *UserRepository) GetActiveListings(userID uint, page, limit int) ([]models.Listing, int64, error) {
+	var listings []models.Listing
+	var count int64
+
+	offset := (page - 1) * limit
+	query := r.db.Model(&models.Listing{}).Where("user_id = ? AND status = ?", userID, "active")
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Categories").
+		Preload("Images").
+		Order("created_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&listings).Error
+
+	return listings, count, err
+}
+
+// GetSalesVolume returns the total item price of a user's sales, counting
+// every sale that was not cancelled, with one total per currency
+func (r *UserRepository) GetSalesVolume(userID uint) ([]models.Money, error) {
+	var totals []models.Money
+	err := r.db.Model(&models.Transaction{}).
+		Select("SUM(item_price_minor) AS minor, item_price_currency AS currency").
+		Where("seller_id = ? AND status <> ?", userID, models.TransactionCancelled).
+		Group("item_price_currency").
+		Scan(&totals).Error
+	return totals, err
+}
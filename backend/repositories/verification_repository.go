@@ -0,0 +1,90 @@
+// repositories/verification_repository.go
+package repositories
+
+import (
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"gorm.io/gorm"
+)
+
+type VerificationRepository struct {
+	db *gorm.DB
+}
+
+func NewVerificationRepository() *VerificationRepository {
+	return &VerificationRepository{
+		db: database.DB,
+	}
+}
+
+func (r *VerificationRepository) Create(verification *models.UserVerification) error {
+	return r.db.Create(verification).Error
+}
+
+func (r *VerificationRepository) FindByID(id uint) (*models.UserVerification, error) {
+	var verification models.UserVerification
+	err := r.db.Preload("User").Preload("ReviewedBy").Preload("Documents").
+		First(&verification, id).Error
+	return &verification, err
+}
+
+func (r *VerificationRepository) FindByUser(userID uint) ([]models.UserVerification, error) {
+	var verifications []models.UserVerification
+	err := r.db.Preload("Documents").
+		Where("user_id = ?", userID).
+		Order("submitted_at DESC").
+		Find(&verifications).Error
+	return verifications, err
+}
+
+func (r *VerificationRepository) HasPending(userID uint, verificationType string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.UserVerification{}).
+		Where("user_id = ? AND verification_type = ? AND status = ?", userID, verificationType, models.VerificationPending).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *VerificationRepository) FindByStatus(status string, page, limit int) ([]models.UserVerification, int64, error) {
+	var verifications []models.UserVerification
+	var count int64
+
+	offset := (page - 1) * limit
+	query := r.db.Model(&models.UserVerification{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("User").Preload("Documents").
+		Order("submitted_at ASC").
+		Offset(offset).Limit(limit).
+		Find(&verifications).Error
+
+	return verifications, count, err
+}
+
+func (r *VerificationRepository) Update(verification *models.UserVerification) error {
+	return r.db.Omit("User", "ReviewedBy", "Documents").Save(verification).Error
+}
+
+// Approve records the review and applies its effect on the user in one transaction
+func (r *VerificationRepository) Approve(verification *models.UserVerification, sellerRole *models.Role) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("User", "ReviewedBy", "Documents").Save(verification).Error; err != nil {
+			return err
+		}
+		if verification.VerificationType != models.VerificationTypeSeller {
+			return nil
+		}
+		user := &models.User{}
+		user.ID = verification.UserID
+		if err := tx.Model(user).Update("is_seller_verified", true).Error; err != nil {
+			return err
+		}
+		return tx.Model(user).Association("Roles").Append(sellerRole)
+	})
+}
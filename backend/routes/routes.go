@@ -5,19 +5,404 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/jimsyyap/auctions/backend/handlers"
 	"github.com/jimsyyap/auctions/backend/middlewares"
+	"github.com/jimsyyap/auctions/backend/models"
 )
 
 // SetupRoutes configures all API routes
 func SetupRoutes(router *gin.Engine, handlers *handlers.Handlers) {
 	// API group
 	api := router.Group("/api")
-	
+
 	// Setup route groups
 	setupAuthRoutes(api, handlers.AuthHandler)
+	setupTwoFactorRoutes(api, handlers.TwoFactorHandler)
+	setupOIDCRoutes(api, handlers.OIDCHandler)
 	setupUserRoutes(api, handlers.UserHandler)
+	setupNotificationRoutes(api, handlers.NotificationHandler)
+	setupVerificationRoutes(api, handlers.VerificationHandler)
+	setupSessionRoutes(api, handlers.SessionHandler)
+	setupAccountRoutes(api, handlers.AccountHandler)
+	setupAddressRoutes(api, handlers.AddressHandler)
 	setupListingRoutes(api, handlers.ListingHandler)
-	setupCategoryRoutes(api, handlers.CategoryHandler)
+	setupFeedbackRoutes(api, handlers.FeedbackHandler)
+	setupMessageRoutes(api, handlers.MessageHandler)
+	setupQuestionRoutes(api, handlers.QuestionHandler)
+	setupCategoryRoutes(api, handlers.ListingHandler)
 	setupBidRoutes(api, handlers.BidHandler)
+	setupTransactionRoutes(api, handlers.TransactionHandler)
+	setupPaymentRoutes(api, handlers.PaymentHandler)
+	setupExchangeRateRoutes(api, handlers.ExchangeRateHandler)
+	setupLedgerRoutes(api, handlers.LedgerHandler)
+	setupDisputeRoutes(api, handlers.DisputeHandler)
+	setupInvoiceRoutes(api, handlers.InvoiceHandler)
+	setupAdminRoutes(api, handlers)
+
+	// Discovery documents live outside the API group
+	setupWellKnownRoutes(router, handlers.WellKnownHandler)
+}
+
+// setupAdminRoutes registers administration routes
+func setupAdminRoutes(api *gin.RouterGroup, handlers *handlers.Handlers) {
+	admin := api.Group("/admin")
+	admin.Use(middlewares.Auth(), middlewares.RequireTwoFactorCompliance())
+	{
+		lockouts := admin.Group("/lockouts")
+		lockouts.Use(middlewares.RequirePermission(models.PermLockoutManage))
+		{
+			lockouts.GET("", handlers.LockoutHandler.GetLockouts)
+			lockouts.DELETE("/:id", handlers.LockoutHandler.ClearLockout)
+		}
+
+		roles := admin.Group("")
+		roles.Use(middlewares.RequirePermission(models.PermRoleManage))
+		{
+			roles.GET("/roles", handlers.RoleHandler.GetRoles)
+			roles.GET("/users/:id/roles", handlers.RoleHandler.GetUserRoles)
+			roles.PUT("/users/:id/roles", handlers.RoleHandler.AssignRoles)
+		}
+
+		verifications := admin.Group("/verifications")
+		verifications.Use(middlewares.RequirePermission(models.PermVerificationReview))
+		{
+			verifications.GET("", handlers.VerificationHandler.GetVerifications)
+			verifications.GET("/:id", handlers.VerificationHandler.GetVerification)
+			verifications.GET("/:id/documents/:docId", handlers.VerificationHandler.GetDocument)
+			verifications.POST("/:id/approve", handlers.VerificationHandler.Approve)
+			verifications.POST("/:id/reject", handlers.VerificationHandler.Reject)
+		}
+
+		threads := admin.Group("/threads")
+		threads.Use(middlewares.RequirePermission(models.PermMessageModerate))
+		{
+			threads.GET("", handlers.MessageHandler.GetReportedThreads)
+			threads.GET("/:id", handlers.MessageHandler.GetReportedThread)
+			threads.GET("/:id/attachments/:attachmentId", handlers.MessageHandler.GetReportedAttachment)
+		}
+
+		moderation := admin.Group("/moderation")
+		{
+			review := moderation.Group("")
+			review.Use(middlewares.RequirePermission(models.PermContentReview))
+			{
+				review.GET("/decisions", handlers.ModerationHandler.GetDecisions)
+				review.GET("/decisions/:id", handlers.ModerationHandler.GetDecision)
+				review.POST("/decisions/:id/review", handlers.ModerationHandler.Review)
+			}
+
+			manage := moderation.Group("")
+			manage.Use(middlewares.RequirePermission(models.PermModerationManage))
+			{
+				manage.GET("/rules", handlers.ModerationHandler.GetRules)
+				manage.PUT("/rules", handlers.ModerationHandler.UpdateRule)
+				manage.GET("/banned-words", handlers.ModerationHandler.GetBannedWords)
+				manage.POST("/banned-words", handlers.ModerationHandler.AddBannedWord)
+				manage.DELETE("/banned-words/:id", handlers.ModerationHandler.RemoveBannedWord)
+			}
+		}
+
+		rates := admin.Group("/exchange-rates")
+		rates.Use(middlewares.RequirePermission(models.PermRatesManage))
+		{
+			rates.PUT("/:currency", handlers.ExchangeRateHandler.SetRate)
+			rates.DELETE("/:currency", handlers.ExchangeRateHandler.DeleteRate)
+		}
+
+		finance := admin.Group("")
+		finance.Use(middlewares.RequirePermission(models.PermFinanceManage))
+		{
+			finance.GET("/fees/rules", handlers.FeeHandler.GetRules)
+			finance.POST("/fees/rules", handlers.FeeHandler.CreateRule)
+			finance.PUT("/fees/rules/:id", handlers.FeeHandler.UpdateRule)
+			finance.DELETE("/fees/rules/:id", handlers.FeeHandler.DeleteRule)
+			finance.GET("/ledger/accounts", handlers.LedgerHandler.GetAccounts)
+			finance.GET("/ledger/accounts/:id/entries", handlers.LedgerHandler.GetEntries)
+			finance.GET("/ledger/reconciliation", handlers.LedgerHandler.Reconcile)
+			finance.GET("/payouts", handlers.LedgerHandler.GetPayouts)
+		}
+
+		disputes := admin.Group("/disputes")
+		disputes.Use(middlewares.RequirePermission(models.PermDisputeResolve))
+		{
+			disputes.GET("", handlers.DisputeHandler.GetDisputes)
+			disputes.GET("/:id", handlers.DisputeHandler.GetDisputeByID)
+			disputes.POST("/:id/messages", handlers.DisputeHandler.AddAdminMessage)
+			disputes.GET("/:id/evidence/:evidenceId", handlers.DisputeHandler.GetDisputeEvidence)
+			disputes.POST("/:id/resolve", handlers.DisputeHandler.Resolve)
+		}
+
+		settings := admin.Group("/settings")
+		settings.Use(middlewares.RequirePermission(models.PermSettingsManage))
+		{
+			settings.GET("", handlers.SettingHandler.GetSettings)
+			settings.PUT("/:key", handlers.SettingHandler.UpdateSetting)
+		}
+	}
+}
+
+// setupWellKnownRoutes registers /.well-known discovery documents
+func setupWellKnownRoutes(router *gin.Engine, h *handlers.WellKnownHandler) {
+	router.GET("/.well-known/jwks.json", h.JWKS)
+}
+
+// setupAuthRoutes registers registration, login and token routes
+func setupAuthRoutes(api *gin.RouterGroup, h *handlers.AuthHandler) {
+	auth := api.Group("/auth")
+	{
+		auth.POST("/register", h.Register)
+		auth.POST("/login", h.Login)
+		auth.POST("/login/2fa", h.LoginTwoFactor)
+		auth.POST("/refresh", h.RefreshToken)
+		auth.POST("/logout", h.Logout)
+		auth.POST("/logout-all", middlewares.Auth(), h.LogoutAll)
+		auth.POST("/password/forgot", h.ForgotPassword)
+		auth.POST("/password/reset", h.ResetPassword)
+		auth.POST("/email/verify", h.VerifyEmail)
+		auth.POST("/email/resend", middlewares.Auth(), h.ResendVerification)
+	}
+}
+
+// setupTwoFactorRoutes registers TOTP enrolment and management routes
+func setupTwoFactorRoutes(api *gin.RouterGroup, h *handlers.TwoFactorHandler) {
+	twoFactor := api.Group("/auth/2fa")
+	twoFactor.Use(middlewares.Auth())
+	{
+		twoFactor.GET("", h.GetStatus)
+		twoFactor.POST("/setup", h.Setup)
+		twoFactor.POST("/enable", h.Enable)
+		twoFactor.POST("/disable", h.Disable)
+		twoFactor.POST("/recovery-codes", h.RegenerateRecoveryCodes)
+	}
+}
+
+// setupOIDCRoutes registers OpenID Connect social login routes
+func setupOIDCRoutes(api *gin.RouterGroup, h *handlers.OIDCHandler) {
+	oidc := api.Group("/auth/oidc")
+	{
+		oidc.GET("/providers", h.GetProviders)
+		oidc.GET("/:provider/start", h.StartLogin)
+		oidc.POST("/:provider/callback", h.Callback)
+	}
+}
+
+// setupUserRoutes registers user profile routes
+func setupUserRoutes(api *gin.RouterGroup, h *handlers.UserHandler) {
+	users := api.Group("/users")
+	{
+		// Public profiles and storefronts
+		users.GET("/:id", h.GetUser)
+		users.GET("/:id/listings", h.GetUserListings)
+	}
+
+	authenticated := users.Group("")
+	authenticated.Use(middlewares.Auth()) // Require authentication
+	{
+		authenticated.GET("/me", h.GetProfile)
+		authenticated.PUT("/me", h.UpdateProfile)
+		authenticated.PUT("/me/avatar", h.UploadAvatar)
+		authenticated.DELETE("/me/avatar", h.DeleteAvatar)
+		authenticated.GET("/:id/bids", h.GetUserBids)
+	}
+}
+
+// setupNotificationRoutes registers the logged-in user's notification routes
+func setupNotificationRoutes(api *gin.RouterGroup, h *handlers.NotificationHandler) {
+	notifications := api.Group("/users/me/notifications")
+	notifications.Use(middlewares.Auth())
+	{
+		notifications.GET("", h.GetNotifications)
+		notifications.POST("/:id/read", h.MarkRead)
+		notifications.POST("/read-all", h.MarkAllRead)
+	}
+}
+
+// setupVerificationRoutes registers the logged-in user's verification routes
+func setupVerificationRoutes(api *gin.RouterGroup, h *handlers.VerificationHandler) {
+	verification := api.Group("/users/me/verifications")
+	verification.Use(middlewares.Auth())
+	{
+		verification.GET("", h.GetMine)
+		verification.POST("", middlewares.RequireVerifiedEmail(), h.Submit)
+	}
+}
+
+// setupSessionRoutes registers the logged-in user's session routes
+func setupSessionRoutes(api *gin.RouterGroup, h *handlers.SessionHandler) {
+	sessions := api.Group("/users/me/sessions")
+	sessions.Use(middlewares.Auth())
+	{
+		sessions.GET("", h.GetSessions)
+		sessions.DELETE("/:id", h.RevokeSession)
+	}
+}
+
+// setupAccountRoutes registers data export and account deletion routes
+func setupAccountRoutes(api *gin.RouterGroup, h *handlers.AccountHandler) {
+	account := api.Group("/users/me")
+	account.Use(middlewares.Auth())
+	{
+		account.GET("/export", h.Export)
+		account.GET("/deletion", h.GetDeletion)
+		account.POST("/deletion", h.RequestDeletion)
+		account.DELETE("/deletion", h.CancelDeletion)
+	}
+}
+
+// setupAddressRoutes registers the logged-in user's address book routes
+func setupAddressRoutes(api *gin.RouterGroup, h *handlers.AddressHandler) {
+	addresses := api.Group("/users/me/addresses")
+	addresses.Use(middlewares.Auth())
+	{
+		addresses.GET("", h.GetAddresses)
+		addresses.POST("", h.CreateAddress)
+		addresses.GET("/:id", h.GetAddress)
+		addresses.PUT("/:id", h.UpdateAddress)
+		addresses.DELETE("/:id", h.DeleteAddress)
+		addresses.POST("/:id/default", h.SetDefault)
+	}
+}
+
+// setupListingRoutes registers listing routes
+func setupListingRoutes(api *gin.RouterGroup, h *handlers.ListingHandler) {
+	listings := api.Group("/listings")
+	{
+		listings.GET("", h.GetListings)
+		listings.GET("/:id", h.GetListing)
+
+		// Protected routes
+		authenticated := listings.Group("")
+		authenticated.Use(middlewares.Auth())
+		{
+			authenticated.POST("", middlewares.RequireVerifiedEmail(), middlewares.RequireTwoFactorCompliance(), middlewares.RequirePermission(models.PermListingCreate), h.CreateListing)
+			authenticated.PUT("/:id", middlewares.RequireTwoFactorCompliance(), h.UpdateListing)
+			authenticated.DELETE("/:id", middlewares.RequireTwoFactorCompliance(), h.DeleteListing)
+		}
+	}
+}
+
+// setupFeedbackRoutes registers feedback between buyers and sellers
+func setupFeedbackRoutes(api *gin.RouterGroup, h *handlers.FeedbackHandler) {
+	api.GET("/users/:id/feedback/received", h.GetReceived)
+	api.GET("/users/:id/feedback/given", h.GetGiven)
+
+	authenticated := api.Group("")
+	authenticated.Use(middlewares.Auth())
+	{
+		authenticated.POST("/listings/:id/feedback", h.LeaveFeedback)
+		authenticated.POST("/feedback/:id/reply", h.Reply)
+	}
+}
+
+// setupMessageRoutes registers buyer-seller conversation routes
+func setupMessageRoutes(api *gin.RouterGroup, h *handlers.MessageHandler) {
+	api.POST("/listings/:id/messages", middlewares.Auth(), middlewares.RequireVerifiedEmail(), h.StartThread)
+
+	threads := api.Group("/threads")
+	threads.Use(middlewares.Auth())
+	{
+		threads.GET("", h.GetThreads)
+		threads.GET("/:id/messages", h.GetMessages)
+		threads.POST("/:id/messages", middlewares.RequireVerifiedEmail(), h.SendMessage)
+		threads.GET("/:id/attachments/:attachmentId", h.GetAttachment)
+		threads.POST("/:id/block", h.Block)
+		threads.DELETE("/:id/block", h.Unblock)
+		threads.POST("/:id/report", h.Report)
+	}
+}
+
+// setupQuestionRoutes registers listing Q&A routes. Published questions
+// are returned with the listing itself.
+func setupQuestionRoutes(api *gin.RouterGroup, h *handlers.QuestionHandler) {
+	questions := api.Group("/listings/:id/questions")
+	questions.Use(middlewares.Auth())
+	{
+		questions.GET("", h.GetQuestions)
+		questions.POST("", middlewares.RequireVerifiedEmail(), h.Ask)
+		questions.POST("/:questionId/answer", h.Answer)
+		questions.POST("/:questionId/publish", h.Publish)
+		questions.POST("/:questionId/hide", h.Hide)
+	}
+}
+
+// setupCategoryRoutes registers category routes
+func setupCategoryRoutes(api *gin.RouterGroup, h *handlers.ListingHandler) {
+	categories := api.Group("/categories")
+	{
+		categories.GET("", h.GetCategories)
+		categories.GET("/:id/listings", h.GetListingsByCategory)
+	}
 }
 
-// Individual route group setups...
+// setupBidRoutes registers bidding routes
+func setupBidRoutes(api *gin.RouterGroup, h *handlers.BidHandler) {
+	bids := api.Group("/listings")
+	bids.Use(middlewares.Auth())
+	{
+		bids.POST("/:id/bids", middlewares.RequireVerifiedEmail(), middlewares.RequireTwoFactorCompliance(), middlewares.RequirePermission(models.PermBidPlace), h.PlaceBid)
+	}
+}
+
+// setupTransactionRoutes registers Buy Now and the buyer's and seller's
+// side of each sale
+func setupTransactionRoutes(api *gin.RouterGroup, h *handlers.TransactionHandler) {
+	api.POST("/listings/:id/buy-now", middlewares.Auth(), middlewares.RequireVerifiedEmail(), middlewares.RequireTwoFactorCompliance(), middlewares.RequirePermission(models.PermBidPlace), h.BuyNow)
+
+	transactions := api.Group("/transactions")
+	transactions.Use(middlewares.Auth())
+	{
+		transactions.GET("", h.GetTransactions)
+		transactions.GET("/:id", h.GetTransaction)
+		transactions.PUT("/:id/shipping-address", middlewares.RequireTwoFactorCompliance(), h.SetShippingAddress)
+		transactions.POST("/:id/ship", middlewares.RequireTwoFactorCompliance(), h.Ship)
+		transactions.POST("/:id/confirm-delivery", middlewares.RequireTwoFactorCompliance(), h.ConfirmDelivery)
+		transactions.POST("/:id/cancel", middlewares.RequireTwoFactorCompliance(), h.Cancel)
+	}
+}
+
+// setupPaymentRoutes registers paying for transactions and the payment
+// provider's webhooks, which authenticate by signature rather than token
+func setupPaymentRoutes(api *gin.RouterGroup, h *handlers.PaymentHandler) {
+	api.POST("/payments/webhooks/:provider", h.Webhook)
+
+	authenticated := api.Group("")
+	authenticated.Use(middlewares.Auth())
+	{
+		authenticated.POST("/transactions/:id/pay", middlewares.RequireTwoFactorCompliance(), h.Pay)
+		authenticated.GET("/transactions/:id/payments", h.GetPayments)
+		authenticated.POST("/payments/fake/:intentId/confirm", h.ConfirmFakePayment)
+	}
+}
+
+// setupExchangeRateRoutes registers the public list of exchange rates.
+// Admins change them under /admin/exchange-rates.
+func setupExchangeRateRoutes(api *gin.RouterGroup, h *handlers.ExchangeRateHandler) {
+	api.GET("/exchange-rates", h.GetRates)
+}
+
+// setupLedgerRoutes registers the logged-in seller's balance and payouts.
+// Admins see the whole ledger under /admin/ledger.
+func setupLedgerRoutes(api *gin.RouterGroup, h *handlers.LedgerHandler) {
+	seller := api.Group("/users/me")
+	seller.Use(middlewares.Auth())
+	{
+		seller.GET("/balance", h.GetBalance)
+		seller.GET("/payouts", h.GetMyPayouts)
+	}
+}
+
+// setupDisputeRoutes registers the buyer's and seller's side of disputes
+// about a transaction
+func setupDisputeRoutes(api *gin.RouterGroup, h *handlers.DisputeHandler) {
+	dispute := api.Group("/transactions/:id/dispute")
+	dispute.Use(middlewares.Auth())
+	{
+		dispute.POST("", middlewares.RequireTwoFactorCompliance(), h.Open)
+		dispute.GET("", h.GetDispute)
+		dispute.POST("/messages", middlewares.RequireTwoFactorCompliance(), h.AddMessage)
+		dispute.GET("/evidence/:evidenceId", h.GetEvidence)
+	}
+}
+
+// setupInvoiceRoutes registers the invoices of completed sales
+func setupInvoiceRoutes(api *gin.RouterGroup, h *handlers.InvoiceHandler) {
+	api.GET("/transactions/:id/invoice.pdf", middlewares.Auth(), h.GetInvoice)
+}
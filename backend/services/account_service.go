@@ -0,0 +1,475 @@
+// services/account_service.go
+package services
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/config"
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/repositories"
+	"github.com/jimsyyap/auctions/backend/storage"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrDeletionNotRequested = errors.New("account deletion has not been requested")
+	ErrOpenAuctions         = errors.New("you have active auctions as a seller or bidder; wait for them to end before deleting your account")
+	ErrOpenTransactions     = errors.New("you have unfinished purchases or sales; complete or cancel them before deleting your account")
+)
+
+// AccountService handles the data-protection side of an account: exporting
+// everything held about a user and deleting the account after a cooling-off
+// period
+type AccountService struct {
+	accountRepo      *repositories.AccountRepository
+	userRepo         *repositories.UserRepository
+	verificationRepo *repositories.VerificationRepository
+	sessionService   *SessionService
+	emailService     *EmailService
+	imageService     *ImageService
+	documentStorage  storage.Storage
+}
+
+func NewAccountService(accountRepo *repositories.AccountRepository, userRepo *repositories.UserRepository, verificationRepo *repositories.VerificationRepository, sessionService *SessionService, emailService *EmailService, imageService *ImageService, documentStorage storage.Storage) *AccountService {
+	return &AccountService{
+		accountRepo:      accountRepo,
+		userRepo:         userRepo,
+		verificationRepo: verificationRepo,
+		sessionService:   sessionService,
+		emailService:     emailService,
+		imageService:     imageService,
+		documentStorage:  documentStorage,
+	}
+}
+
+// DeleteAccountRequest confirms a deletion request with the user's password
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// DeletionStatus describes a pending account deletion
+type DeletionStatus struct {
+	Pending     bool       `json:"pending"`
+	RequestedAt *time.Time `json:"requested_at,omitempty"`
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+}
+
+// GetDeletionStatus reports whether the account is due to be deleted
+func (s *AccountService) GetDeletionStatus(userID uint) (*DeletionStatus, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return deletionStatus(user), nil
+}
+
+// RequestDeletion schedules the account for deletion once the cooling-off
+// period has passed. Until then the user can sign in and cancel.
+func (s *AccountService) RequestDeletion(userID uint, req *DeleteAccountRequest) (*DeletionStatus, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		return nil, errors.New("password is incorrect")
+	}
+
+	if user.DeletionScheduledAt != nil {
+		return deletionStatus(user), nil
+	}
+
+	if err := s.checkNothingOpen(userID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	scheduledAt := now.Add(config.GetAccountConfig().DeletionCoolingOff)
+	user.DeletionRequestedAt = &now
+	user.DeletionScheduledAt = &scheduledAt
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	s.emailService.SendAsync(user.Email, "Your account is scheduled for deletion",
+		fmt.Sprintf("Hi %s,\n\nWe received a request to delete your account. It will be permanently deleted on %s.\n\nIf you change your mind, sign in and cancel the deletion from your account settings before then.\n\nIf you did not make this request, sign in, cancel the deletion and change your password.",
+			user.Username, scheduledAt.Format("2 January 2006")))
+
+	return deletionStatus(user), nil
+}
+
+// CancelDeletion withdraws a pending deletion request
+func (s *AccountService) CancelDeletion(userID uint) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.DeletionScheduledAt == nil {
+		return ErrDeletionNotRequested
+	}
+
+	user.DeletionRequestedAt = nil
+	user.DeletionScheduledAt = nil
+	return s.userRepo.Update(user)
+}
+
+// ProcessDueDeletions anonymises every account whose cooling-off period has
+// ended. Accounts that have since joined an auction are retried later.
+func (s *AccountService) ProcessDueDeletions() error {
+	users, err := s.accountRepo.FindDueDeletions(time.Now())
+	if err != nil {
+		return err
+	}
+
+	for i := range users {
+		if err := s.deleteAccount(&users[i]); err != nil {
+			log.Printf("Failed to delete account %d: %v", users[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// checkNothingOpen returns an error if the user is still part of an
+// auction or an unfinished sale
+func (s *AccountService) checkNothingOpen(userID uint) error {
+	open, err := s.accountRepo.CountOpenAuctions(userID)
+	if err != nil {
+		return err
+	}
+	if open > 0 {
+		return ErrOpenAuctions
+	}
+
+	open, err = s.accountRepo.CountOpenTransactions(userID)
+	if err != nil {
+		return err
+	}
+	if open > 0 {
+		return ErrOpenTransactions
+	}
+	return nil
+}
+
+// deleteAccount signs the user out everywhere, removes their identity
+// documents and anonymises their personal data
+func (s *AccountService) deleteAccount(user *models.User) error {
+	if err := s.checkNothingOpen(user.ID); err != nil {
+		return err
+	}
+
+	if err := s.sessionService.RevokeAllForUser(user.ID); err != nil {
+		return err
+	}
+
+	verifications, err := s.verificationRepo.FindByUser(user.ID)
+	if err != nil {
+		return err
+	}
+	for _, verification := range verifications {
+		for _, doc := range verification.Documents {
+			if err := s.documentStorage.Delete(doc.StorageKey); err != nil {
+				return err
+			}
+		}
+	}
+
+	if user.ProfileImageKey != "" {
+		if err := s.imageService.Delete(user.ProfileImageKey); err != nil {
+			return err
+		}
+	}
+
+	// Not a bcrypt hash, so no password can ever match it
+	unusable, err := generateOpaqueToken(32)
+	if err != nil {
+		return err
+	}
+
+	// Tell the user while we still have their address
+	email, username := user.Email, user.Username
+	if err := s.accountRepo.Anonymize(user.ID, "!"+unusable); err != nil {
+		return err
+	}
+
+	s.emailService.SendAsync(email, "Your account has been deleted",
+		fmt.Sprintf("Hi %s,\n\nYour account and personal details have now been deleted. Records of completed auctions are kept without your personal details.", username))
+	return nil
+}
+
+// deletionStatus builds the status of a user's deletion request
+func deletionStatus(user *models.User) *DeletionStatus {
+	return &DeletionStatus{
+		Pending:     user.DeletionScheduledAt != nil,
+		RequestedAt: user.DeletionRequestedAt,
+		ScheduledAt: user.DeletionScheduledAt,
+	}
+}
+
+// Export rows are flat so the same data can be written as JSON and CSV
+
+type exportProfile struct {
+	ID              uint                      `json:"id"`
+	Username        string                    `json:"username"`
+	Email           string                    `json:"email"`
+	FirstName       string                    `json:"first_name"`
+	LastName        string                    `json:"last_name"`
+	PhoneNumber     string                    `json:"phone_number"`
+	Address         string                    `json:"address"`
+	Bio             string                    `json:"bio"`
+	AvatarURL       string                    `json:"avatar_url"`
+	EmailVerified   bool                      `json:"email_verified"`
+	SellerVerified  bool                      `json:"seller_verified"`
+	TwoFactor       bool                      `json:"two_factor_enabled"`
+	MemberSince     time.Time                 `json:"member_since"`
+	Verifications   []models.UserVerification `json:"verifications"`
+	Sessions        []SessionInfo             `json:"sessions"`
+	DeletionPending bool                      `json:"deletion_pending"`
+}
+
+type exportListing struct {
+	ID           uint         `json:"id"`
+	Title        string       `json:"title"`
+	Description  string       `json:"description"`
+	Status       string       `json:"status"`
+	StartPrice   models.Money `json:"start_price"`
+	ReservePrice models.Money `json:"reserve_price"`
+	BuyNowPrice  models.Money `json:"buy_now_price"`
+	EndTime      time.Time    `json:"end_time"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+type exportBid struct {
+	ID           uint         `json:"id"`
+	ListingID    uint         `json:"listing_id"`
+	ListingTitle string       `json:"listing_title"`
+	Amount       models.Money `json:"amount"`
+	PlacedAt     time.Time    `json:"placed_at"`
+}
+
+type exportRating struct {
+	ID        uint      `json:"id"`
+	Direction string    `json:"direction"` // received or given
+	Score     int       `json:"score"`
+	Comment   string    `json:"comment"`
+	ListingID uint      `json:"listing_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportNotification struct {
+	ID        uint      `json:"id"`
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	IsRead    bool      `json:"is_read"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportTransaction struct {
+	ID           uint         `json:"id"`
+	ListingID    uint         `json:"listing_id"`
+	ListingTitle string       `json:"listing_title"`
+	Role         string       `json:"role"` // buyer or seller
+	Type         string       `json:"type"`
+	Status       string       `json:"status"`
+	ItemPrice    models.Money `json:"item_price"`
+	ShippingCost models.Money `json:"shipping_cost"`
+	TaxAmount    models.Money `json:"tax_amount"`
+	Amount       models.Money `json:"amount"`
+	CompletedAt  *time.Time   `json:"completed_at,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+type exportMessage struct {
+	ID          uint       `json:"id"`
+	ThreadID    uint       `json:"thread_id"`
+	ListingID   uint       `json:"listing_id"`
+	Direction   string     `json:"direction"` // sent or received
+	Body        string     `json:"body"`
+	Attachments int        `json:"attachments"`
+	ReadAt      *time.Time `json:"read_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Export writes a zip archive of everything held about a user: profile,
+// listings, bids, ratings, transactions, messages and notifications, as
+// JSON with CSV copies of the tabular data
+func (s *AccountService) Export(userID uint, w io.Writer) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	verifications, err := s.verificationRepo.FindByUser(userID)
+	if err != nil {
+		return err
+	}
+	sessions, err := s.sessionService.GetSessions(userID, 0)
+	if err != nil {
+		return err
+	}
+	listings, err := s.accountRepo.FindListings(userID)
+	if err != nil {
+		return err
+	}
+	bids, err := s.accountRepo.FindBids(userID)
+	if err != nil {
+		return err
+	}
+	ratings, err := s.accountRepo.FindRatings(userID)
+	if err != nil {
+		return err
+	}
+	transactions, err := s.accountRepo.FindTransactions(userID)
+	if err != nil {
+		return err
+	}
+	messages, err := s.accountRepo.FindMessages(userID)
+	if err != nil {
+		return err
+	}
+	notifications, err := s.accountRepo.FindNotifications(userID)
+	if err != nil {
+		return err
+	}
+
+	profile := exportProfile{
+		ID:              user.ID,
+		Username:        user.Username,
+		Email:           user.Email,
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		PhoneNumber:     user.PhoneNumber,
+		Address:         user.Address,
+		Bio:             user.Bio,
+		AvatarURL:       user.ProfileImageURL,
+		EmailVerified:   user.IsEmailVerified,
+		SellerVerified:  user.IsSellerVerified,
+		TwoFactor:       user.TwoFactorEnabled,
+		MemberSince:     user.CreatedAt,
+		Verifications:   verifications,
+		Sessions:        sessions,
+		DeletionPending: user.DeletionScheduledAt != nil,
+	}
+
+	listingRows := make([]exportListing, len(listings))
+	listingCSV := [][]string{{"id", "title", "status", "currency", "start_price", "reserve_price", "buy_now_price", "end_time", "created_at"}}
+	for i, l := range listings {
+		listingRows[i] = exportListing{l.ID, l.Title, l.Description, l.Status, l.StartPrice, l.ReservePrice, l.BuyNowPrice, l.EndTime, l.CreatedAt}
+		listingCSV = append(listingCSV, []string{
+			strconv.FormatUint(uint64(l.ID), 10), l.Title, l.Status, l.StartPrice.Currency,
+			formatAmount(l.StartPrice), formatAmount(l.ReservePrice), formatAmount(l.BuyNowPrice),
+			l.EndTime.Format(time.RFC3339), l.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	bidRows := make([]exportBid, len(bids))
+	bidCSV := [][]string{{"id", "listing_id", "listing_title", "currency", "amount", "placed_at"}}
+	for i, b := range bids {
+		bidRows[i] = exportBid{b.ID, b.ListingID, b.Listing.Title, b.Amount, b.PlacedAt}
+		bidCSV = append(bidCSV, []string{
+			strconv.FormatUint(uint64(b.ID), 10), strconv.FormatUint(uint64(b.ListingID), 10), b.Listing.Title,
+			b.Amount.Currency, formatAmount(b.Amount), b.PlacedAt.Format(time.RFC3339),
+		})
+	}
+
+	ratingRows := make([]exportRating, len(ratings))
+	ratingCSV := [][]string{{"id", "direction", "score", "comment", "listing_id", "created_at"}}
+	for i, r := range ratings {
+		direction := "received"
+		if r.RaterUserID == userID {
+			direction = "given"
+		}
+		ratingRows[i] = exportRating{r.ID, direction, r.Score, r.Comment, r.ListingID, r.CreatedAt}
+		ratingCSV = append(ratingCSV, []string{
+			strconv.FormatUint(uint64(r.ID), 10), direction, strconv.Itoa(r.Score), r.Comment,
+			strconv.FormatUint(uint64(r.ListingID), 10), r.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	transactionRows := make([]exportTransaction, len(transactions))
+	transactionCSV := [][]string{{"id", "listing_id", "listing_title", "role", "type", "status", "currency", "item_price", "shipping_cost", "tax_amount", "amount", "created_at"}}
+	for i, t := range transactions {
+		role := "buyer"
+		if t.SellerID == userID {
+			role = "seller"
+		}
+		transactionRows[i] = exportTransaction{t.ID, t.ListingID, t.Listing.Title, role, t.Type, t.Status, t.ItemPrice, t.ShippingCost, t.TaxAmount, t.Amount, t.CompletedAt, t.CreatedAt}
+		transactionCSV = append(transactionCSV, []string{
+			strconv.FormatUint(uint64(t.ID), 10), strconv.FormatUint(uint64(t.ListingID), 10), t.Listing.Title,
+			role, t.Type, t.Status, t.Amount.Currency,
+			formatAmount(t.ItemPrice), formatAmount(t.ShippingCost), formatAmount(t.TaxAmount), formatAmount(t.Amount),
+			t.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	messageRows := make([]exportMessage, len(messages))
+	for i, m := range messages {
+		direction := "received"
+		if m.SenderID == userID {
+			direction = "sent"
+		}
+		messageRows[i] = exportMessage{m.ID, m.ThreadID, m.Thread.ListingID, direction, m.Body, len(m.Attachments), m.ReadAt, m.CreatedAt}
+	}
+
+	notificationRows := make([]exportNotification, len(notifications))
+	for i, n := range notifications {
+		notificationRows[i] = exportNotification{n.ID, n.Type, n.Title, n.Content, n.IsRead, n.CreatedAt}
+	}
+
+	archive := zip.NewWriter(w)
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", profile},
+		{"listings.json", listingRows},
+		{"listings.csv", listingCSV},
+		{"bids.json", bidRows},
+		{"bids.csv", bidCSV},
+		{"ratings.json", ratingRows},
+		{"ratings.csv", ratingCSV},
+		{"transactions.json", transactionRows},
+		{"transactions.csv", transactionCSV},
+		{"messages.json", messageRows},
+		{"notifications.json", notificationRows},
+	}
+	for _, file := range files {
+		if err := writeExportFile(archive, file.name, file.data); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// writeExportFile adds one file to the export archive. [][]string values
+// are written as CSV, anything else as indented JSON.
+func writeExportFile(archive *zip.Writer, name string, data interface{}) error {
+	f, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	if rows, ok := data.([][]string); ok {
+		cw := csv.NewWriter(f)
+		if err := cw.WriteAll(rows); err != nil {
+			return err
+		}
+		return cw.Error()
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(data)
+}
+
+// formatAmount formats a price for CSV output; the currency has its own column
+func formatAmount(amount models.Money) string {
+	return amount.Decimal()
+}
@@ -0,0 +1,244 @@
+// services/address_service.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/repositories"
+)
+
+var ErrAddressNotFound = errors.New("address not found")
+
+// Maximum number of saved addresses per user
+const maxAddressesPerUser = 20
+
+// postalCodeFormats holds postal code patterns for countries we ship to
+// most. Codes are upper-cased before matching.
+var postalCodeFormats = map[string]*regexp.Regexp{
+	"US": regexp.MustCompile(`^\d{5}(-\d{4})?$`),
+	"CA": regexp.MustCompile(`^[ABCEGHJ-NPRSTVXY]\d[ABCEGHJ-NPRSTV-Z] ?\d[ABCEGHJ-NPRSTV-Z]\d$`),
+	"GB": regexp.MustCompile(`^([A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}|GIR ?0AA)$`),
+	"IE": regexp.MustCompile(`^[AC-FHKNPRTV-Y]\d{2}[ \d]?[0-9AC-FHKNPRTV-Y]{4}$`),
+	"AU": regexp.MustCompile(`^\d{4}$`),
+	"NZ": regexp.MustCompile(`^\d{4}$`),
+	"DE": regexp.MustCompile(`^\d{5}$`),
+	"FR": regexp.MustCompile(`^\d{5}$`),
+	"ES": regexp.MustCompile(`^\d{5}$`),
+	"IT": regexp.MustCompile(`^\d{5}$`),
+	"NL": regexp.MustCompile(`^\d{4} ?[A-Z]{2}$`),
+	"BE": regexp.MustCompile(`^\d{4}$`),
+	"CH": regexp.MustCompile(`^\d{4}$`),
+	"AT": regexp.MustCompile(`^\d{4}$`),
+	"SE": regexp.MustCompile(`^\d{3} ?\d{2}$`),
+	"PL": regexp.MustCompile(`^\d{2}-\d{3}$`),
+	"PT": regexp.MustCompile(`^\d{4}-\d{3}$`),
+	"JP": regexp.MustCompile(`^\d{3}-?\d{4}$`),
+	"IN": regexp.MustCompile(`^\d{6}$`),
+	"SG": regexp.MustCompile(`^\d{6}$`),
+	"PH": regexp.MustCompile(`^\d{4}$`),
+	"MY": regexp.MustCompile(`^\d{5}$`),
+	"BR": regexp.MustCompile(`^\d{5}-?\d{3}$`),
+	"MX": regexp.MustCompile(`^\d{5}$`),
+}
+
+// Countries that do not use postal codes
+var countriesWithoutPostalCodes = map[string]bool{
+	"AE": true, "HK": true, "MO": true, "QA": true, "FJ": true,
+}
+
+// Countries where the state or province is part of the address
+var countriesRequiringState = map[string]bool{
+	"US": true, "CA": true, "AU": true, "BR": true, "MX": true, "IN": true,
+}
+
+var (
+	countryCodeFormat   = regexp.MustCompile(`^[A-Z]{2}$`)
+	genericPostalFormat = regexp.MustCompile(`^[A-Z0-9][A-Z0-9 -]{1,9}$`)
+)
+
+type AddressService struct {
+	addressRepo *repositories.AddressRepository
+}
+
+func NewAddressService(addressRepo *repositories.AddressRepository) *AddressService {
+	return &AddressService{
+		addressRepo: addressRepo,
+	}
+}
+
+// AddressRequest represents the data for creating or updating an address
+type AddressRequest struct {
+	AddressType    string `json:"address_type" binding:"required,oneof=billing shipping both"`
+	IsDefault      bool   `json:"is_default"`
+	StreetAddress1 string `json:"street_address1" binding:"required,max=255"`
+	StreetAddress2 string `json:"street_address2" binding:"max=255"`
+	City           string `json:"city" binding:"required,max=100"`
+	State          string `json:"state" binding:"max=100"`
+	PostalCode     string `json:"postal_code" binding:"max=20"`
+	Country        string `json:"country" binding:"required"` // ISO 3166-1 alpha-2
+}
+
+// GetAddresses lists a user's saved addresses
+func (s *AddressService) GetAddresses(userID uint) ([]models.UserAddress, error) {
+	return s.addressRepo.FindByUser(userID)
+}
+
+// GetAddress returns one of a user's addresses
+func (s *AddressService) GetAddress(userID, id uint) (*models.UserAddress, error) {
+	address, err := s.addressRepo.FindByID(id)
+	if err != nil || address.UserID != userID {
+		return nil, ErrAddressNotFound
+	}
+	return address, nil
+}
+
+// CreateAddress saves a new address. A user's first address is always
+// the default.
+func (s *AddressService) CreateAddress(userID uint, req *AddressRequest) (*models.UserAddress, error) {
+	fields, err := normalizeAddress(req)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.addressRepo.CountByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxAddressesPerUser {
+		return nil, fmt.Errorf("you can save at most %d addresses", maxAddressesPerUser)
+	}
+
+	address := &models.UserAddress{
+		AddressType:     req.AddressType,
+		IsDefault:       req.IsDefault || count == 0,
+		AddressSnapshot: *fields,
+		UserID:          userID,
+	}
+	if err := s.addressRepo.Save(address); err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// UpdateAddress replaces the fields of one of a user's addresses
+func (s *AddressService) UpdateAddress(userID, id uint, req *AddressRequest) (*models.UserAddress, error) {
+	address, err := s.GetAddress(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := normalizeAddress(req)
+	if err != nil {
+		return nil, err
+	}
+
+	address.AddressType = req.AddressType
+	address.AddressSnapshot = *fields
+	// The default can be moved to another address but not simply removed
+	if req.IsDefault {
+		address.IsDefault = true
+	}
+
+	if err := s.addressRepo.Save(address); err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// SetDefault makes one of a user's addresses the default
+func (s *AddressService) SetDefault(userID, id uint) (*models.UserAddress, error) {
+	address, err := s.GetAddress(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	address.IsDefault = true
+	if err := s.addressRepo.Save(address); err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// DeleteAddress removes one of a user's addresses. Records that already
+// copied it, such as transactions, keep their copy.
+func (s *AddressService) DeleteAddress(userID, id uint) error {
+	address, err := s.GetAddress(userID, id)
+	if err != nil {
+		return err
+	}
+	return s.addressRepo.Delete(address)
+}
+
+// SnapshotShippingAddress returns a copy of the address a purchase should
+// ship to: the given address, or the user's default shipping address when
+// addressID is 0
+func (s *AddressService) SnapshotShippingAddress(userID, addressID uint) (*models.AddressSnapshot, error) {
+	var address *models.UserAddress
+	var err error
+	if addressID == 0 {
+		address, err = s.addressRepo.FindDefaultShipping(userID)
+		if err != nil {
+			return nil, errors.New("add a shipping address before checking out")
+		}
+	} else {
+		address, err = s.GetAddress(userID, addressID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !address.CanShip() {
+		return nil, errors.New("this address can only be used for billing")
+	}
+
+	snapshot := address.AddressSnapshot
+	return &snapshot, nil
+}
+
+// normalizeAddress trims the request and validates it against the rules
+// of its country
+func normalizeAddress(req *AddressRequest) (*models.AddressSnapshot, error) {
+	fields := &models.AddressSnapshot{
+		StreetAddress1: strings.TrimSpace(req.StreetAddress1),
+		StreetAddress2: strings.TrimSpace(req.StreetAddress2),
+		City:           strings.TrimSpace(req.City),
+		State:          strings.TrimSpace(req.State),
+		PostalCode:     strings.ToUpper(strings.Join(strings.Fields(req.PostalCode), " ")),
+		Country:        strings.ToUpper(strings.TrimSpace(req.Country)),
+	}
+
+	if fields.StreetAddress1 == "" || fields.City == "" {
+		return nil, errors.New("street address and city are required")
+	}
+
+	if !countryCodeFormat.MatchString(fields.Country) {
+		return nil, errors.New("country must be a two-letter ISO 3166-1 code, such as US or GB")
+	}
+
+	if countriesRequiringState[fields.Country] && fields.State == "" {
+		return nil, fmt.Errorf("state or province is required for addresses in %s", fields.Country)
+	}
+
+	if countriesWithoutPostalCodes[fields.Country] {
+		fields.PostalCode = ""
+		return fields, nil
+	}
+
+	if fields.PostalCode == "" {
+		return nil, errors.New("postal code is required")
+	}
+
+	format, ok := postalCodeFormats[fields.Country]
+	if !ok {
+		format = genericPostalFormat
+	}
+	if !format.MatchString(fields.PostalCode) {
+		return nil, fmt.Errorf("%s is not a valid postal code for %s", fields.PostalCode, fields.Country)
+	}
+
+	return fields, nil
+}
@@ -2,26 +2,65 @@
 package services
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"regexp"
 	"time"
 
+	"github.com/jimsyyap/auctions/backend/config"
 	"github.com/jimsyyap/auctions/backend/middlewares"
 	"github.com/jimsyyap/auctions/backend/models"
 	"github.com/jimsyyap/auctions/backend/repositories"
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrInvalidEmailToken   = errors.New("invalid or expired token")
+
+	ErrTwoFactorChallengeExhausted = errors.New("too many incorrect codes, please sign in again")
+)
+
+const (
+	passwordResetTTL     = 1 * time.Hour
+	emailVerificationTTL = 48 * time.Hour
+	twoFactorLoginTTL    = 5 * time.Minute
+
+	// Wrong codes allowed against one 2FA login challenge before the
+	// password has to be entered again
+	twoFactorLoginMaxAttempts = 5
+)
+
 type AuthService struct {
-	userRepo *repositories.UserRepository
+	userRepo         *repositories.UserRepository
+	refreshTokenRepo *repositories.RefreshTokenRepository
+	oneTimeTokenRepo *repositories.OneTimeTokenRepository
+	emailService     *EmailService
+	twoFactorService *TwoFactorService
+	throttleService  *LoginThrottleService
+	sessionService   *SessionService
 }
 
-func NewAuthService(userRepo *repositories.UserRepository) *AuthService {
+func NewAuthService(userRepo *repositories.UserRepository, refreshTokenRepo *repositories.RefreshTokenRepository, oneTimeTokenRepo *repositories.OneTimeTokenRepository, emailService *EmailService, twoFactorService *TwoFactorService, throttleService *LoginThrottleService, sessionService *SessionService) *AuthService {
 	return &AuthService{
-		userRepo: userRepo,
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		oneTimeTokenRepo: oneTimeTokenRepo,
+		emailService:     emailService,
+		twoFactorService: twoFactorService,
+		throttleService:  throttleService,
+		sessionService:   sessionService,
 	}
 }
 
+// dummyPasswordHash is compared against when a username does not exist, so
+// unknown and known usernames take the same time to reject
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
 // LoginRequest represents login form data
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
@@ -39,15 +78,53 @@ type RegisterRequest struct {
 	Address     string `json:"address"`
 }
 
+// RefreshRequest carries the opaque refresh token issued at login
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// ForgotPasswordRequest starts a password reset
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest completes a password reset
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// VerifyEmailRequest confirms ownership of an email address
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// TwoFactorLoginRequest completes a login that requires a second factor
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"` // TOTP or recovery code
+}
+
+// TwoFactorChallenge is returned by Login instead of tokens when the account
+// has 2FA enabled
+type TwoFactorChallenge struct {
+	TwoFactorRequired bool      `json:"two_factor_required"`
+	ChallengeToken    string    `json:"challenge_token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
 // AuthResponse represents the response after successful authentication
 type AuthResponse struct {
-	Token     string      `json:"token"`
-	ExpiresAt time.Time   `json:"expires_at"`
-	User      *models.User `json:"user"`
+	Token                  string       `json:"token"`
+	ExpiresAt              time.Time    `json:"expires_at"`
+	RefreshToken           string       `json:"refresh_token"`
+	RefreshTokenExpiresAt  time.Time    `json:"refresh_token_expires_at"`
+	TwoFactorSetupRequired bool         `json:"two_factor_setup_required,omitempty"`
+	User                   *models.User `json:"user"`
 }
 
 // Register creates a new user account
-func (s *AuthService) Register(req *RegisterRequest) (*AuthResponse, error) {
+func (s *AuthService) Register(req *RegisterRequest, client ClientInfo) (*AuthResponse, error) {
 	// Check if username already exists
 	existingUser, err := s.userRepo.FindByUsername(req.Username)
 	if err == nil && existingUser.ID > 0 {
@@ -87,71 +164,381 @@ func (s *AuthService) Register(req *RegisterRequest) (*AuthResponse, error) {
 		return nil, err
 	}
 
-	// Generate JWT token
-	token, err := middlewares.GenerateToken(user.ID, user.Username, user.IsAdmin)
-	if err != nil {
+	if err := s.sendVerificationEmail(user); err != nil {
 		return nil, err
 	}
 
-	return &AuthResponse{
-		Token:     token,
-		ExpiresAt: time.Now().Add(24 * time.Hour),
-		User:      user,
-	}, nil
+	// Start a new refresh token family for this login
+	return s.issueTokens(user, "", client)
 }
 
-// Login authenticates a user and returns a JWT token
-func (s *AuthService) Login(req *LoginRequest) (*AuthResponse, error) {
+// Login authenticates a user and returns a JWT token. When the account has
+// two-factor authentication enabled, no tokens are issued; instead a
+// challenge is returned that must be completed with CompleteTwoFactorLogin.
+// Failed attempts are throttled per username and per client IP.
+func (s *AuthService) Login(req *LoginRequest, client ClientInfo) (*AuthResponse, *TwoFactorChallenge, error) {
+	if err := s.throttleService.Check(req.Username, client.IP); err != nil {
+		return nil, nil, err
+	}
+
 	user, err := s.userRepo.FindByUsername(req.Username)
 	if err != nil {
-		return nil, errors.New("invalid credentials")
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(req.Password))
+		if err := s.throttleService.RecordFailure(req.Username, client.IP); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, errors.New("invalid credentials")
 	}
 
 	// Check password
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
 	if err != nil {
-		return nil, errors.New("invalid credentials")
+		if err := s.throttleService.RecordFailure(req.Username, client.IP); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, errors.New("invalid credentials")
 	}
 
-	// Generate JWT token
-	token, err := middlewares.GenerateToken(user.ID, user.Username, user.IsAdmin)
+	if !user.TwoFactorEnabled {
+		if err := s.throttleService.RecordSuccess(req.Username); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return s.beginSession(user, client)
+}
+
+// beginSession finishes a successful primary authentication. Accounts with
+// 2FA get a challenge; everyone else gets a new refresh token family.
+func (s *AuthService) beginSession(user *models.User, client ClientInfo) (*AuthResponse, *TwoFactorChallenge, error) {
+	if user.TwoFactorEnabled {
+		challengeToken, err := s.createOneTimeToken(user.ID, models.TokenPurposeTwoFactorLogin, twoFactorLoginTTL)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, &TwoFactorChallenge{
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
+			ExpiresAt:         time.Now().Add(twoFactorLoginTTL),
+		}, nil
+	}
+
+	resp, err := s.issueTokens(user, "", client)
+	return resp, nil, err
+}
+
+// CompleteTwoFactorLogin finishes a login with a TOTP or recovery code
+func (s *AuthService) CompleteTwoFactorLogin(req *TwoFactorLoginRequest, client ClientInfo) (*AuthResponse, error) {
+	challenge, err := s.oneTimeTokenRepo.FindByHash(models.TokenPurposeTwoFactorLogin, hashToken(req.ChallengeToken))
+	if err != nil || !challenge.IsUsable() {
+		return nil, ErrInvalidEmailToken
+	}
+
+	// Second-factor guesses count towards the same limits as passwords
+	username := challenge.User.Username
+	if err := s.throttleService.Check(username, client.IP); err != nil {
+		return nil, err
+	}
+
+	ok, err := s.twoFactorService.VerifyCode(&challenge.User, req.Code)
 	if err != nil {
 		return nil, err
 	}
+	if !ok {
+		if err := s.throttleService.RecordFailure(username, client.IP); err != nil {
+			return nil, err
+		}
+		exhausted, err := s.oneTimeTokenRepo.RecordFailedAttempt(challenge.ID, twoFactorLoginMaxAttempts)
+		if err != nil {
+			return nil, err
+		}
+		if exhausted {
+			return nil, ErrTwoFactorChallengeExhausted
+		}
+		return nil, ErrInvalidTwoFactorCode
+	}
 
-	// Sanitize user data before returning
-	user.Password = ""
+	// Only consume the challenge once the code is right, so a typo does not
+	// force the user to re-enter their password
+	used, err := s.oneTimeTokenRepo.MarkUsed(challenge.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, ErrInvalidEmailToken
+	}
 
-	return &AuthResponse{
-		Token:     token,
-		ExpiresAt: time.Now().Add(24 * time.Hour),
-		User:      user,
-	}, nil
+	if err := s.throttleService.RecordSuccess(username); err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(&challenge.User, "", client)
 }
 
-// RefreshToken generates a new token for a valid user
-func (s *AuthService) RefreshToken(userID uint) (*AuthResponse, error) {
+// RefreshToken exchanges a refresh token for a new access/refresh pair.
+// Each refresh token can be used once; presenting a token that was already
+// rotated is treated as theft and revokes every token in its family.
+func (s *AuthService) RefreshToken(rawToken string, client ClientInfo) (*AuthResponse, error) {
+	stored, err := s.refreshTokenRepo.FindByHash(hashToken(rawToken))
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if stored.RotatedAt != nil {
+		// Reuse of a rotated token: someone else holds a copy of this chain
+		if err := s.sessionService.RevokeFamily(stored.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if !stored.IsActive() {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Guard against two concurrent requests rotating the same token
+	rotated, err := s.refreshTokenRepo.MarkRotated(stored.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		if err := s.sessionService.RevokeFamily(stored.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	return s.issueTokens(&stored.User, stored.FamilyID, client)
+}
+
+// Logout ends the session the given refresh token belongs to
+func (s *AuthService) Logout(rawToken string) error {
+	stored, err := s.refreshTokenRepo.FindByHash(hashToken(rawToken))
+	if err != nil {
+		// Nothing to revoke; logging out is idempotent
+		return nil
+	}
+	return s.sessionService.RevokeFamily(stored.FamilyID)
+}
+
+// LogoutAll ends every session belonging to a user
+func (s *AuthService) LogoutAll(userID uint) error {
+	return s.sessionService.RevokeAllForUser(userID)
+}
+
+// RequestPasswordReset emails a reset link if the address belongs to an
+// account. Callers must respond identically whether or not it does, so the
+// endpoint cannot be used to discover registered emails.
+func (s *AuthService) RequestPasswordReset(email string) error {
+	user, err := s.userRepo.FindByEmail(email)
+	if err != nil {
+		return nil
+	}
+
+	rawToken, err := s.createOneTimeToken(user.ID, models.TokenPurposePasswordReset, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", config.GetAppConfig().FrontendURL, rawToken)
+	s.emailService.SendAsync(user.Email, "Reset your password",
+		fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password. It expires in one hour.\n\n%s\n\nIf you did not request this, you can ignore this email.", user.Username, link))
+
+	return nil
+}
+
+// ResetPassword sets a new password using a reset token and signs the user
+// out everywhere
+func (s *AuthService) ResetPassword(req *ResetPasswordRequest) error {
+	// A rejected password leaves the link usable for another try
+	if err := s.validatePassword(req.NewPassword); err != nil {
+		return err
+	}
+	token, err := s.oneTimeTokenRepo.FindByHash(models.TokenPurposePasswordReset, hashToken(req.Token))
+	if err != nil || !token.IsUsable() {
+		return ErrInvalidEmailToken
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	// Any other reset links are consumed with this one
+	reset, err := s.oneTimeTokenRepo.ResetPassword(token, string(hashedPassword))
+	if err != nil {
+		return err
+	}
+	if !reset {
+		return ErrInvalidEmailToken
+	}
+
+	// Existing sessions are no longer valid
+	return s.sessionService.RevokeAllForUser(token.UserID)
+}
+
+// VerifyEmail marks the user's email as verified
+func (s *AuthService) VerifyEmail(rawToken string) error {
+	token, err := s.consumeOneTimeToken(models.TokenPurposeEmailVerification, rawToken)
+	if err != nil {
+		return err
+	}
+
+	user := &token.User
+	user.IsEmailVerified = true
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+
+	return s.oneTimeTokenRepo.InvalidateForUser(user.ID, models.TokenPurposeEmailVerification)
+}
+
+// ResendVerificationEmail sends a fresh verification link
+func (s *AuthService) ResendVerificationEmail(userID uint) error {
 	user, err := s.userRepo.FindByID(userID)
 	if err != nil {
-		return nil, errors.New("user not found")
+		return errors.New("user not found")
+	}
+
+	if user.IsEmailVerified {
+		return errors.New("email is already verified")
+	}
+
+	// Only the newest link should work
+	if err := s.oneTimeTokenRepo.InvalidateForUser(user.ID, models.TokenPurposeEmailVerification); err != nil {
+		return err
+	}
+
+	return s.sendVerificationEmail(user)
+}
+
+// sendVerificationEmail issues a verification token and emails it
+func (s *AuthService) sendVerificationEmail(user *models.User) error {
+	rawToken, err := s.createOneTimeToken(user.ID, models.TokenPurposeEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", config.GetAppConfig().FrontendURL, rawToken)
+	s.emailService.SendAsync(user.Email, "Confirm your email address",
+		fmt.Sprintf("Hi %s,\n\nPlease confirm your email address to start bidding and selling:\n\n%s", user.Username, link))
+
+	return nil
+}
+
+// createOneTimeToken stores the hash of a new random token and returns the raw value
+func (s *AuthService) createOneTimeToken(userID uint, purpose string, ttl time.Duration) (string, error) {
+	rawToken, err := generateOpaqueToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	token := &models.OneTimeToken{
+		Purpose:   purpose,
+		TokenHash: hashToken(rawToken),
+		ExpiresAt: time.Now().Add(ttl),
+		UserID:    userID,
+	}
+	if err := s.oneTimeTokenRepo.Create(token); err != nil {
+		return "", err
+	}
+
+	return rawToken, nil
+}
+
+// consumeOneTimeToken validates a token and marks it as used
+func (s *AuthService) consumeOneTimeToken(purpose, rawToken string) (*models.OneTimeToken, error) {
+	token, err := s.oneTimeTokenRepo.FindByHash(purpose, hashToken(rawToken))
+	if err != nil || !token.IsUsable() {
+		return nil, ErrInvalidEmailToken
+	}
+
+	used, err := s.oneTimeTokenRepo.MarkUsed(token.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, ErrInvalidEmailToken
+	}
+
+	return token, nil
+}
+
+// issueTokens creates a short-lived access token and a new refresh token.
+// An empty familyID starts a new family, and with it a new session.
+func (s *AuthService) issueTokens(user *models.User, familyID string, client ClientInfo) (*AuthResponse, error) {
+	authConfig := config.GetAuthConfig()
+	now := time.Now()
+	refreshExpiresAt := now.Add(authConfig.RefreshTokenTTL)
+
+	var err error
+	if familyID == "" {
+		familyID, err = generateOpaqueToken(16)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// Generate new JWT token
-	token, err := middlewares.GenerateToken(user.ID, user.Username, user.IsAdmin)
+	session, err := s.sessionService.Record(user.ID, familyID, client, refreshExpiresAt)
 	if err != nil {
 		return nil, err
 	}
 
+	// Accounts that must use 2FA but have not enrolled get a restricted token
+	setupRequired := !user.TwoFactorEnabled && s.twoFactorService.IsRequired(user)
+
+	expiresAt := now.Add(authConfig.AccessTokenTTL)
+	token, err := middlewares.GenerateToken(user, middlewares.TokenOptions{
+		SessionID:              session.ID,
+		TwoFactorSetupRequired: setupRequired,
+	}, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rawRefresh, err := generateOpaqueToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh := &models.RefreshToken{
+		TokenHash: hashToken(rawRefresh),
+		FamilyID:  familyID,
+		ExpiresAt: refreshExpiresAt,
+		UserID:    user.ID,
+	}
+	if err := s.refreshTokenRepo.Create(refresh); err != nil {
+		return nil, err
+	}
+
 	// Sanitize user data before returning
 	user.Password = ""
 
 	return &AuthResponse{
-		Token:     token,
-		ExpiresAt: time.Now().Add(24 * time.Hour),
-		User:      user,
+		Token:                  token,
+		ExpiresAt:              expiresAt,
+		RefreshToken:           rawRefresh,
+		RefreshTokenExpiresAt:  refresh.ExpiresAt,
+		TwoFactorSetupRequired: setupRequired,
+		User:                   user,
 	}, nil
 }
 
+// generateOpaqueToken returns n random bytes encoded as URL-safe base64
+func generateOpaqueToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hex SHA-256 digest used to store opaque tokens
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // validatePassword checks password against security requirements
 func (s *AuthService) validatePassword(password string) error {
 	if len(password) < 8 {
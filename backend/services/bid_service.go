@@ -2,9 +2,16 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/models"
 	"github.com/jimsyyap/auctions/backend/repositories"
 )
 
+var ErrOwnListingBid = errors.New("you cannot bid on your own listing")
+
 type BidService struct {
 	bidRepo     *repositories.BidRepository
 	listingRepo *repositories.ListingRepository
@@ -18,3 +25,55 @@ func NewBidService(bidRepo *repositories.BidRepository, listingRepo *repositorie
 		userRepo:    userRepo,
 	}
 }
+
+// BidRequest represents a bid. Amount is a decimal string such as "12.50"
+// in the listing's currency.
+type BidRequest struct {
+	Amount string `json:"amount" binding:"required"`
+}
+
+// PlaceBid bids on an active listing. The first bid must be at least the
+// start price and every later one must beat the highest bid so far.
+func (s *BidService) PlaceBid(userID, listingID uint, req *BidRequest) (*BidView, error) {
+	listing, err := s.listingRepo.FindByID(listingID)
+	if err != nil || listing.Status == models.ModerationStatusHeld || listing.Status == models.ModerationStatusRejected {
+		return nil, ErrListingNotFound
+	}
+
+	amount, err := models.ParseMoney(req.Amount, listing.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount: %v", err)
+	}
+
+	bid := &models.Bid{Amount: amount, UserID: userID, ListingID: listing.ID}
+	err = s.bidRepo.Place(bid, func(listing *models.Listing, highest *models.Bid) error {
+		if listing.UserID == userID {
+			return ErrOwnListingBid
+		}
+		if listing.Status != "active" || time.Now().After(listing.EndTime) {
+			return errors.New("this auction has ended")
+		}
+		if highest == nil {
+			if amount.Cmp(listing.StartPrice) < 0 {
+				return fmt.Errorf("the opening bid must be at least %s", listing.StartPrice)
+			}
+			return nil
+		}
+		if highest.UserID == userID {
+			return errors.New("you already have the highest bid")
+		}
+		if amount.Cmp(highest.Amount) <= 0 {
+			return fmt.Errorf("your bid must be higher than %s", highest.Amount)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	view := BidView{ID: bid.ID, Amount: bid.Amount, PlacedAt: bid.PlacedAt}
+	if bidder, err := s.userRepo.FindByID(userID); err == nil {
+		view.Bidder = toUserSummary(bidder)
+	}
+	return &view, nil
+}
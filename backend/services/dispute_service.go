@@ -0,0 +1,636 @@
+// services/dispute_service.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/config"
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/repositories"
+	"github.com/jimsyyap/auctions/backend/storage"
+)
+
+var (
+	ErrDisputeNotFound  = errors.New("dispute not found")
+	ErrEvidenceNotFound = errors.New("evidence not found")
+)
+
+const (
+	maxDisputeEvidence     = 5
+	maxDisputeEvidenceSize = 10 << 20 // 10 MB
+)
+
+// DisputeService handles cases buyers open when a sale goes wrong: the
+// timeline of messages and evidence between buyer, seller and admins, the
+// seller's deadline to respond, and the admin's resolution, which refunds
+// the buyer through the payment layer and counts against the seller
+type DisputeService struct {
+	disputeRepo         *repositories.DisputeRepository
+	transactionRepo     *repositories.TransactionRepository
+	paymentService      *PaymentService
+	ledgerService       *LedgerService
+	reputationService   *ReputationService
+	notificationService *NotificationService
+	evidenceStorage     storage.Storage
+}
+
+// NewDisputeService creates the service. evidenceStorage must be private:
+// evidence is only served to the buyer, the seller and admins.
+func NewDisputeService(disputeRepo *repositories.DisputeRepository, transactionRepo *repositories.TransactionRepository, paymentService *PaymentService, ledgerService *LedgerService, reputationService *ReputationService, notificationService *NotificationService, evidenceStorage storage.Storage) *DisputeService {
+	return &DisputeService{
+		disputeRepo:         disputeRepo,
+		transactionRepo:     transactionRepo,
+		paymentService:      paymentService,
+		ledgerService:       ledgerService,
+		reputationService:   reputationService,
+		notificationService: notificationService,
+		evidenceStorage:     evidenceStorage,
+	}
+}
+
+// OpenDisputeRequest opens a dispute. It is sent as multipart form data
+// when photos or documents are uploaded as "evidence" files.
+type OpenDisputeRequest struct {
+	Reason      string `form:"reason" json:"reason" binding:"required,oneof=item_not_received not_as_described damaged other"`
+	Description string `form:"description" json:"description" binding:"required,max=5000"`
+}
+
+// DisputeMessageRequest adds a message to a dispute, with optional
+// "evidence" files
+type DisputeMessageRequest struct {
+	Body string `form:"body" json:"body" binding:"max=5000"`
+}
+
+// ResolveDisputeRequest closes a dispute. RefundAmount is a decimal string
+// in the sale's currency and is only used for partial refunds.
+type ResolveDisputeRequest struct {
+	Resolution   string `json:"resolution" binding:"required,oneof=full_refund partial_refund no_action"`
+	RefundAmount string `json:"refund_amount"`
+	Note         string `json:"note" binding:"required,max=5000"`
+}
+
+// DisputeView is a dispute as shown to its buyer and seller and to admins.
+// Messages are left out of lists.
+type DisputeView struct {
+	ID                uint                 `json:"id"`
+	TransactionID     uint                 `json:"transaction_id"`
+	Listing           ListingSummary       `json:"listing"`
+	Reason            string               `json:"reason"`
+	Status            string               `json:"status"`
+	SellerRespondBy   time.Time            `json:"seller_respond_by"`
+	SellerRespondedAt *time.Time           `json:"seller_responded_at,omitempty"`
+	Resolution        string               `json:"resolution,omitempty"`
+	RefundAmount      *models.Money        `json:"refund_amount,omitempty"`
+	ResolutionNote    string               `json:"resolution_note,omitempty"`
+	ResolvedAt        *time.Time           `json:"resolved_at,omitempty"`
+	CreatedAt         time.Time            `json:"created_at"`
+	Messages          []DisputeMessageView `json:"messages,omitempty"`
+}
+
+// DisputeMessageView is one entry in a dispute's timeline
+type DisputeMessageView struct {
+	ID         uint           `json:"id"`
+	AuthorRole string         `json:"author_role"`
+	Body       string         `json:"body"`
+	Evidence   []EvidenceView `json:"evidence"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+// EvidenceView points at a piece of evidence's download route
+type EvidenceView struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	URL         string `json:"url"`
+}
+
+// Open starts a dispute about one of the buyer's purchases and freezes the
+// payment until it is resolved. Completed sales can be disputed for a
+// while after the buyer confirmed delivery.
+func (s *DisputeService) Open(userID, transactionID uint, req *OpenDisputeRequest, files []*multipart.FileHeader) (*DisputeView, error) {
+	transaction, err := s.transactionRepo.FindByID(transactionID)
+	if err != nil || !transaction.HasParticipant(userID) {
+		return nil, ErrTransactionNotFound
+	}
+	if transaction.BuyerID != userID {
+		return nil, ErrNotTransactionBuyer
+	}
+	if !transaction.CanMoveTo(models.TransactionDisputed) {
+		return nil, fmt.Errorf("a %s transaction cannot be disputed", strings.ReplaceAll(transaction.Status, "_", " "))
+	}
+	now := time.Now()
+	marketplace := config.GetMarketplaceConfig()
+	if transaction.CompletedAt != nil && now.After(transaction.CompletedAt.Add(marketplace.DisputeWindow)) {
+		return nil, errors.New("the time to dispute this sale has passed")
+	}
+	if _, err := s.disputeRepo.FindByTransaction(transaction.ID); err == nil {
+		return nil, errors.New("this sale has already been disputed")
+	}
+
+	description := strings.TrimSpace(req.Description)
+	message, err := s.newMessage(transaction, models.DisputeAuthorBuyer, &userID, description, files)
+	if err != nil {
+		return nil, err
+	}
+
+	dispute := &models.Dispute{
+		Reason:          req.Reason,
+		Status:          models.DisputeAwaitingSeller,
+		SellerRespondBy: now.Add(marketplace.DisputeResponseWindow),
+		TransactionID:   transaction.ID,
+		Messages:        []models.DisputeMessage{*message},
+	}
+	transaction.DisputedAt = &now
+	transaction.DisputeReason = description
+
+	opened, err := s.disputeRepo.Open(dispute, transaction, transaction.Status)
+	if err != nil || !opened {
+		s.deleteEvidence(message.Evidence)
+		if err == nil {
+			err = errors.New("this transaction was changed by someone else; reload it and try again")
+		}
+		return nil, err
+	}
+	transaction.Status = models.TransactionDisputed
+	dispute.Transaction = *transaction
+
+	s.notify(transaction.SellerID, dispute, "A buyer opened a dispute",
+		fmt.Sprintf("The buyer of \"%s\" opened a dispute: %s. Respond by %s or an admin will decide without hearing from you. The payment is frozen until the dispute is resolved.",
+			transaction.Listing.Title, description, dispute.SellerRespondBy.Format("January 2 15:04 MST")))
+
+	view := toDisputeView(participantDisputeURL(transaction.ID), dispute)
+	return &view, nil
+}
+
+// GetDispute returns the dispute about one of the user's sales
+func (s *DisputeService) GetDispute(userID, transactionID uint) (*DisputeView, error) {
+	dispute, err := s.participantDispute(userID, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	view := toDisputeView(participantDisputeURL(transactionID), dispute)
+	return &view, nil
+}
+
+// AddMessage adds the buyer's or seller's message to a dispute. The
+// seller's first message is their response, which sends the case to an
+// admin.
+func (s *DisputeService) AddMessage(userID, transactionID uint, req *DisputeMessageRequest, files []*multipart.FileHeader) (*DisputeMessageView, error) {
+	dispute, err := s.participantDispute(userID, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	transaction := &dispute.Transaction
+
+	role := models.DisputeAuthorBuyer
+	if userID == transaction.SellerID {
+		role = models.DisputeAuthorSeller
+	}
+	message, err := s.addMessage(dispute, role, &userID, req.Body, files)
+	if err != nil {
+		return nil, err
+	}
+
+	if role == models.DisputeAuthorSeller && dispute.Status == models.DisputeAwaitingSeller {
+		responded, err := s.disputeRepo.MoveStatus(dispute.ID, models.DisputeAwaitingSeller, map[string]interface{}{
+			"status":              models.DisputeUnderReview,
+			"seller_responded_at": message.CreatedAt,
+		})
+		if err != nil {
+			log.Printf("Failed to record the seller's response to dispute %d: %v", dispute.ID, err)
+		}
+		if responded {
+			s.notify(transaction.BuyerID, dispute, "The seller responded to your dispute",
+				fmt.Sprintf("The seller of \"%s\" responded to your dispute. An admin will review the case.", transaction.Listing.Title))
+			view := toDisputeMessageView(participantDisputeURL(transactionID), message)
+			return &view, nil
+		}
+	}
+
+	s.notify(transaction.OtherParty(userID), dispute, "New message in a dispute",
+		fmt.Sprintf("There is a new message in the dispute about \"%s\".", transaction.Listing.Title))
+
+	view := toDisputeMessageView(participantDisputeURL(transactionID), message)
+	return &view, nil
+}
+
+// OpenEvidence streams a piece of evidence to the buyer or seller
+func (s *DisputeService) OpenEvidence(userID, transactionID, evidenceID uint) (io.ReadCloser, *models.DisputeEvidence, error) {
+	dispute, err := s.participantDispute(userID, transactionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.openEvidence(dispute.ID, evidenceID)
+}
+
+// GetDisputes lists disputes for admins, optionally with one status
+func (s *DisputeService) GetDisputes(status string, page, limit int) ([]DisputeView, int64, error) {
+	disputes, total, err := s.disputeRepo.FindAll(status, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	views := make([]DisputeView, len(disputes))
+	for i := range disputes {
+		views[i] = toDisputeView(adminDisputeURL(disputes[i].ID), &disputes[i])
+	}
+	return views, total, nil
+}
+
+// GetDisputeByID returns a dispute with its timeline for admins
+func (s *DisputeService) GetDisputeByID(id uint) (*DisputeView, error) {
+	dispute, err := s.disputeRepo.FindByID(id)
+	if err != nil {
+		return nil, ErrDisputeNotFound
+	}
+	view := toDisputeView(adminDisputeURL(dispute.ID), dispute)
+	return &view, nil
+}
+
+// AddAdminMessage adds an admin's message to a dispute, for example to ask
+// either party for more evidence
+func (s *DisputeService) AddAdminMessage(adminID, id uint, req *DisputeMessageRequest, files []*multipart.FileHeader) (*DisputeMessageView, error) {
+	dispute, err := s.disputeRepo.FindByID(id)
+	if err != nil {
+		return nil, ErrDisputeNotFound
+	}
+
+	message, err := s.addMessage(dispute, models.DisputeAuthorAdmin, &adminID, req.Body, files)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction := &dispute.Transaction
+	content := fmt.Sprintf("An admin added a message to the dispute about \"%s\".", transaction.Listing.Title)
+	s.notify(transaction.BuyerID, dispute, "New message in a dispute", content)
+	s.notify(transaction.SellerID, dispute, "New message in a dispute", content)
+
+	view := toDisputeMessageView(adminDisputeURL(dispute.ID), message)
+	return &view, nil
+}
+
+// OpenDisputeEvidence streams a piece of evidence to an admin
+func (s *DisputeService) OpenDisputeEvidence(id, evidenceID uint) (io.ReadCloser, *models.DisputeEvidence, error) {
+	if _, err := s.disputeRepo.FindByID(id); err != nil {
+		return nil, nil, ErrDisputeNotFound
+	}
+	return s.openEvidence(id, evidenceID)
+}
+
+// Resolve closes a dispute. A full refund returns the buyer's payment and
+// cancels the sale; a partial refund returns part of it out of the
+// seller's share and completes the sale; no action completes the sale.
+// Either refund counts against the seller's reputation.
+func (s *DisputeService) Resolve(adminID, id uint, req *ResolveDisputeRequest) (*DisputeView, error) {
+	dispute, err := s.disputeRepo.FindByID(id)
+	if err != nil {
+		return nil, ErrDisputeNotFound
+	}
+	if dispute.Status == models.DisputeResolved {
+		return nil, errors.New("this dispute has already been resolved")
+	}
+	transaction := &dispute.Transaction
+	if transaction.Status != models.TransactionDisputed {
+		return nil, fmt.Errorf("the sale is %s, not disputed", strings.ReplaceAll(transaction.Status, "_", " "))
+	}
+	if transaction.HasParticipant(adminID) {
+		return nil, errors.New("you cannot resolve a dispute about your own sale")
+	}
+
+	refund := models.NewMoney(0, transaction.Amount.Currency)
+	switch req.Resolution {
+	case models.DisputeFullRefund:
+		refund = transaction.Amount
+	case models.DisputePartialRefund:
+		refund, err = models.ParseMoney(req.RefundAmount, transaction.Amount.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid refund amount: %w", err)
+		}
+		if !refund.IsPositive() || refund.Cmp(transaction.Amount) >= 0 {
+			return nil, fmt.Errorf("a partial refund must be more than 0 and less than %s", transaction.Amount)
+		}
+	}
+
+	// Claim the dispute first so two admins cannot both refund the buyer
+	now := time.Now()
+	fromStatus := dispute.Status
+	note := strings.TrimSpace(req.Note)
+	claimed, err := s.disputeRepo.MoveStatus(dispute.ID, fromStatus, map[string]interface{}{
+		"status":          models.DisputeResolved,
+		"resolution":      req.Resolution,
+		"refund_minor":    refund.Minor,
+		"refund_currency": refund.Currency,
+		"resolution_note": note,
+		"resolved_at":     now,
+		"resolved_by_id":  adminID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, errors.New("this dispute was changed by someone else; reload it and try again")
+	}
+
+	if err := s.refund(transaction, req.Resolution, refund); err != nil {
+		// Nothing was refunded, so the case can be resolved again
+		if _, reopenErr := s.disputeRepo.MoveStatus(dispute.ID, models.DisputeResolved, map[string]interface{}{
+			"status": fromStatus, "resolution": "", "refund_minor": 0, "refund_currency": "", "resolution_note": "", "resolved_at": nil, "resolved_by_id": nil,
+		}); reopenErr != nil {
+			log.Printf("Failed to reopen dispute %d after a failed refund: %v", dispute.ID, reopenErr)
+		}
+		return nil, err
+	}
+	s.closeTransaction(transaction, req.Resolution, now)
+
+	if refund.IsPositive() {
+		if err := s.reputationService.RecordDisputeLost(transaction.SellerID); err != nil {
+			log.Printf("Failed to record lost dispute %d against seller %d: %v", dispute.ID, transaction.SellerID, err)
+		}
+	}
+
+	dispute.Status = models.DisputeResolved
+	dispute.Resolution = req.Resolution
+	dispute.RefundAmount = refund
+	dispute.ResolutionNote = note
+	dispute.ResolvedAt = &now
+	dispute.ResolvedByID = &adminID
+
+	outcome := describeResolution(req.Resolution, refund)
+	if _, err := s.addMessage(dispute, models.DisputeAuthorSystem, nil, fmt.Sprintf("Resolved: %s. %s", outcome, note), nil); err != nil {
+		log.Printf("Failed to add the resolution of dispute %d to its timeline: %v", dispute.ID, err)
+	}
+	content := fmt.Sprintf("The dispute about \"%s\" was resolved: %s.\n\n%s", transaction.Listing.Title, outcome, note)
+	s.notify(transaction.BuyerID, dispute, "Your dispute was resolved", content)
+	s.notify(transaction.SellerID, dispute, "A dispute was resolved", content)
+
+	view := toDisputeView(adminDisputeURL(dispute.ID), dispute)
+	return &view, nil
+}
+
+// EscalateOverdue sends disputes whose seller did not respond in time to
+// the admins
+func (s *DisputeService) EscalateOverdue() error {
+	disputes, err := s.disputeRepo.FindOverdue(time.Now())
+	if err != nil {
+		return err
+	}
+
+	for i := range disputes {
+		dispute := &disputes[i]
+		escalated, err := s.disputeRepo.MoveStatus(dispute.ID, models.DisputeAwaitingSeller, map[string]interface{}{"status": models.DisputeUnderReview})
+		if err != nil {
+			log.Printf("Failed to escalate dispute %d: %v", dispute.ID, err)
+			continue
+		}
+		if !escalated {
+			continue
+		}
+		dispute.Status = models.DisputeUnderReview
+
+		if _, err := s.addMessage(dispute, models.DisputeAuthorSystem, nil, "The seller did not respond in time. An admin will review the case.", nil); err != nil {
+			log.Printf("Failed to add the escalation of dispute %d to its timeline: %v", dispute.ID, err)
+		}
+		transaction := &dispute.Transaction
+		content := fmt.Sprintf("The seller did not respond to the dispute about \"%s\" in time. An admin will review the case.", transaction.Listing.Title)
+		s.notify(transaction.BuyerID, dispute, "Your dispute is with an admin", content)
+		s.notify(transaction.SellerID, dispute, "A dispute went to an admin", content)
+	}
+	return nil
+}
+
+// refund returns the buyer's money for a resolution
+func (s *DisputeService) refund(transaction *models.Transaction, resolution string, amount models.Money) error {
+	switch resolution {
+	case models.DisputeFullRefund:
+		return s.paymentService.RefundTransaction(transaction)
+	case models.DisputePartialRefund:
+		return s.paymentService.RefundPart(transaction, amount)
+	}
+	return nil
+}
+
+// closeTransaction ends a resolved dispute's sale: cancelled after a full
+// refund, otherwise completed with the rest of the payment released to
+// the seller. The buyer has been refunded by now, so failures are logged
+// rather than undoing the resolution.
+func (s *DisputeService) closeTransaction(transaction *models.Transaction, resolution string, now time.Time) {
+	status := models.TransactionCompleted
+	if resolution == models.DisputeFullRefund {
+		status = models.TransactionCancelled
+		transaction.CancelledAt = &now
+		transaction.CancelReason = "Refunded after a dispute"
+	} else if transaction.CompletedAt == nil {
+		transaction.CompletedAt = &now
+	}
+
+	if !transaction.CanResolveTo(status) {
+		log.Printf("Transaction %d is %s and cannot be closed by its dispute", transaction.ID, transaction.Status)
+		return
+	}
+	transaction.Status = status
+	updated, err := s.transactionRepo.Update(transaction, models.TransactionDisputed)
+	if err != nil || !updated {
+		log.Printf("Failed to close transaction %d after its dispute (updated %v): %v", transaction.ID, updated, err)
+		return
+	}
+
+	if status == models.TransactionCompleted {
+		if err := s.ledgerService.Settle(transaction); err != nil {
+			log.Printf("Failed to settle transaction %d in the ledger: %v", transaction.ID, err)
+		}
+	}
+}
+
+// participantDispute loads the dispute about a sale the user bought or sold in
+func (s *DisputeService) participantDispute(userID, transactionID uint) (*models.Dispute, error) {
+	dispute, err := s.disputeRepo.FindByTransaction(transactionID)
+	if err != nil || !dispute.Transaction.HasParticipant(userID) {
+		return nil, ErrDisputeNotFound
+	}
+	return dispute, nil
+}
+
+// addMessage stores a message on a dispute's timeline. Resolved disputes
+// only take system messages.
+func (s *DisputeService) addMessage(dispute *models.Dispute, role string, authorID *uint, body string, files []*multipart.FileHeader) (*models.DisputeMessage, error) {
+	if dispute.Status == models.DisputeResolved && role != models.DisputeAuthorSystem {
+		return nil, errors.New("this dispute has been resolved")
+	}
+
+	message, err := s.newMessage(&dispute.Transaction, role, authorID, strings.TrimSpace(body), files)
+	if err != nil {
+		return nil, err
+	}
+	message.DisputeID = dispute.ID
+	if err := s.disputeRepo.AddMessage(message); err != nil {
+		s.deleteEvidence(message.Evidence)
+		return nil, err
+	}
+	return message, nil
+}
+
+// newMessage builds a timeline message and stores its evidence
+func (s *DisputeService) newMessage(transaction *models.Transaction, role string, authorID *uint, body string, files []*multipart.FileHeader) (*models.DisputeMessage, error) {
+	if body == "" && len(files) == 0 {
+		return nil, errors.New("a message needs text or evidence")
+	}
+	if len(files) > maxDisputeEvidence {
+		return nil, fmt.Errorf("a message can have at most %d pieces of evidence", maxDisputeEvidence)
+	}
+
+	message := &models.DisputeMessage{AuthorRole: role, AuthorID: authorID, Body: body}
+	for _, file := range files {
+		evidence, err := s.storeEvidence(transaction.ID, file)
+		if err != nil {
+			s.deleteEvidence(message.Evidence)
+			return nil, err
+		}
+		message.Evidence = append(message.Evidence, *evidence)
+	}
+	return message, nil
+}
+
+// storeEvidence validates an upload and saves it to private storage. The
+// same formats are accepted as for verification documents.
+func (s *DisputeService) storeEvidence(transactionID uint, file *multipart.FileHeader) (*models.DisputeEvidence, error) {
+	if file.Size > maxDisputeEvidenceSize {
+		return nil, fmt.Errorf("%s is larger than 10 MB", file.Filename)
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// Trust the file's content, not its name or the client's header
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	contentType := http.DetectContentType(head[:n])
+	ext, ok := allowedDocumentTypes[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%s must be a JPEG, PNG or PDF file", file.Filename)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	name, err := generateOpaqueToken(16)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("disputes/%d/%s%s", transactionID, name, ext)
+	if err := s.evidenceStorage.Save(key, f); err != nil {
+		return nil, err
+	}
+
+	return &models.DisputeEvidence{
+		StorageKey:   key,
+		OriginalName: filepath.Base(file.Filename),
+		ContentType:  contentType,
+		Size:         file.Size,
+	}, nil
+}
+
+// deleteEvidence removes stored evidence of a message that was not saved
+func (s *DisputeService) deleteEvidence(evidence []models.DisputeEvidence) {
+	for _, e := range evidence {
+		if err := s.evidenceStorage.Delete(e.StorageKey); err != nil {
+			log.Printf("Failed to delete dispute evidence %s: %v", e.StorageKey, err)
+		}
+	}
+}
+
+func (s *DisputeService) openEvidence(disputeID, evidenceID uint) (io.ReadCloser, *models.DisputeEvidence, error) {
+	evidence, err := s.disputeRepo.FindEvidence(disputeID, evidenceID)
+	if err != nil {
+		return nil, nil, ErrEvidenceNotFound
+	}
+	r, err := s.evidenceStorage.Open(evidence.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return r, evidence, nil
+}
+
+func (s *DisputeService) notify(userID uint, dispute *models.Dispute, title, content string) {
+	s.notificationService.Notify(userID, models.NotificationDispute, title, content, &dispute.TransactionID)
+}
+
+// describeResolution says what a resolution did, for messages
+func describeResolution(resolution string, refund models.Money) string {
+	switch resolution {
+	case models.DisputeFullRefund:
+		return fmt.Sprintf("the buyer was refunded %s in full", refund)
+	case models.DisputePartialRefund:
+		return fmt.Sprintf("the buyer was refunded %s", refund)
+	default:
+		return "no refund was made and the payment was released to the seller"
+	}
+}
+
+func participantDisputeURL(transactionID uint) string {
+	return fmt.Sprintf("/api/transactions/%d/dispute", transactionID)
+}
+
+func adminDisputeURL(disputeID uint) string {
+	return fmt.Sprintf("/api/admin/disputes/%d", disputeID)
+}
+
+// toDisputeView converts a dispute for display. Evidence URLs are built
+// under disputeURL, which differs for participants and admins.
+func toDisputeView(disputeURL string, d *models.Dispute) DisputeView {
+	view := DisputeView{
+		ID:                d.ID,
+		TransactionID:     d.TransactionID,
+		Listing:           ListingSummary{ID: d.Transaction.Listing.ID, Title: d.Transaction.Listing.Title},
+		Reason:            d.Reason,
+		Status:            d.Status,
+		SellerRespondBy:   d.SellerRespondBy,
+		SellerRespondedAt: d.SellerRespondedAt,
+		Resolution:        d.Resolution,
+		ResolutionNote:    d.ResolutionNote,
+		ResolvedAt:        d.ResolvedAt,
+		CreatedAt:         d.CreatedAt,
+	}
+	if d.Status == models.DisputeResolved {
+		refund := d.RefundAmount
+		view.RefundAmount = &refund
+	}
+	for i := range d.Messages {
+		view.Messages = append(view.Messages, toDisputeMessageView(disputeURL, &d.Messages[i]))
+	}
+	return view
+}
+
+func toDisputeMessageView(disputeURL string, m *models.DisputeMessage) DisputeMessageView {
+	view := DisputeMessageView{
+		ID:         m.ID,
+		AuthorRole: m.AuthorRole,
+		Body:       m.Body,
+		Evidence:   make([]EvidenceView, len(m.Evidence)),
+		CreatedAt:  m.CreatedAt,
+	}
+	for i, e := range m.Evidence {
+		view.Evidence[i] = EvidenceView{
+			ID:          e.ID,
+			Name:        e.OriginalName,
+			ContentType: e.ContentType,
+			Size:        e.Size,
+			URL:         fmt.Sprintf("%s/evidence/%d", disputeURL, e.ID),
+		}
+	}
+	return view
+}
@@ -0,0 +1,57 @@
+// services/email_service.go
+package services
+
+import (
+	"fmt"
+	"log"
+	"net/smtp"
+	"strings"
+
+	"github.com/jimsyyap/auctions/backend/config"
+)
+
+type EmailService struct {
+	config *config.EmailConfig
+}
+
+func NewEmailService() *EmailService {
+	return &EmailService{
+		config: config.GetEmailConfig(),
+	}
+}
+
+// Send delivers a plain-text email. Without an SMTP host configured the
+// message is logged, which is convenient for local development.
+func (s *EmailService) Send(to, subject, body string) error {
+	if s.config.SMTPHost == "" {
+		log.Printf("Email to %s: %s\n%s", to, subject, body)
+		return nil
+	}
+
+	msg := strings.Join([]string{
+		"From: " + s.config.From,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	addr := fmt.Sprintf("%s:%s", s.config.SMTPHost, s.config.SMTPPort)
+	var auth smtp.Auth
+	if s.config.Username != "" {
+		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.SMTPHost)
+	}
+
+	return smtp.SendMail(addr, auth, s.config.From, []string{to}, []byte(msg))
+}
+
+// SendAsync delivers an email in the background and logs any failure
+func (s *EmailService) SendAsync(to, subject, body string) {
+	go func() {
+		if err := s.Send(to, subject, body); err != nil {
+			log.Printf("Failed to send email to %s: %v", to, err)
+		}
+	}()
+}
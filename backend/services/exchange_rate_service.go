@@ -0,0 +1,226 @@
+// services/exchange_rate_service.go
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/config"
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/repositories"
+)
+
+var (
+	ErrExchangeRateNotFound = errors.New("exchange rate not found")
+	ErrUnsupportedCurrency  = errors.New("currency is not supported")
+)
+
+// ExchangeRateService keeps the exchange rates used to show prices in a
+// buyer's currency. Rates are set by admins or fetched from a feed, and
+// are only ever applied when amounts are read.
+type ExchangeRateService struct {
+	rateRepo   *repositories.ExchangeRateRepository
+	httpClient *http.Client
+}
+
+func NewExchangeRateService(rateRepo *repositories.ExchangeRateRepository) *ExchangeRateService {
+	return &ExchangeRateService{
+		rateRepo:   rateRepo,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// SetRateRequest sets the rate for a currency: how many units of it one
+// unit of the default currency buys, as a decimal string such as "0.92"
+type SetRateRequest struct {
+	Rate string `json:"rate" binding:"required"`
+}
+
+// ExchangeRates is a snapshot of the rates table, keyed by currency.
+// Conversions between two currencies go through the default currency.
+type ExchangeRates map[string]float64
+
+// Supports reports whether amounts in a currency can be converted
+func (r ExchangeRates) Supports(currency string) bool {
+	_, ok := r.rate(currency)
+	return ok
+}
+
+// Convert returns an approximate amount in another currency. It reports
+// false if either currency has no rate.
+func (r ExchangeRates) Convert(amount models.Money, currency string) (models.Money, bool) {
+	if amount.Currency == currency {
+		return amount, true
+	}
+	from, ok := r.rate(amount.Currency)
+	if !ok {
+		return models.Money{}, false
+	}
+	to, ok := r.rate(currency)
+	if !ok {
+		return models.Money{}, false
+	}
+	return amount.Convert(currency, to/from), true
+}
+
+func (r ExchangeRates) rate(currency string) (float64, bool) {
+	if currency == models.DefaultCurrency {
+		return 1, true
+	}
+	rate, ok := r[currency]
+	return rate, ok
+}
+
+// Rates returns the current exchange rates
+func (s *ExchangeRateService) Rates() (ExchangeRates, error) {
+	stored, err := s.rateRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	rates := make(ExchangeRates, len(stored))
+	for _, rate := range stored {
+		rates[rate.Currency] = rate.Rate
+	}
+	return rates, nil
+}
+
+// GetRates lists the stored exchange rates
+func (s *ExchangeRateService) GetRates() ([]models.ExchangeRate, error) {
+	return s.rateRepo.FindAll()
+}
+
+// SetRate creates or replaces the rate for a currency
+func (s *ExchangeRateService) SetRate(adminID uint, currency string, req *SetRateRequest) (*models.ExchangeRate, error) {
+	currency, err := parseCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	if currency == models.DefaultCurrency {
+		return nil, fmt.Errorf("%s is the default currency and has no rate", currency)
+	}
+
+	rate, err := strconv.ParseFloat(strings.TrimSpace(req.Rate), 64)
+	if err != nil || rate <= 0 {
+		return nil, errors.New("rate must be a number greater than zero")
+	}
+
+	exchangeRate := &models.ExchangeRate{
+		Currency:    currency,
+		Rate:        rate,
+		Source:      models.RateSourceAdmin,
+		UpdatedByID: &adminID,
+		UpdatedAt:   time.Now(),
+	}
+	if err := s.rateRepo.Save(exchangeRate); err != nil {
+		return nil, err
+	}
+	return exchangeRate, nil
+}
+
+// DeleteRate removes the rate for a currency. A currency that open
+// listings are priced in keeps its rate.
+func (s *ExchangeRateService) DeleteRate(currency string) error {
+	currency, err := parseCurrency(currency)
+	if err != nil {
+		return err
+	}
+
+	open, err := s.rateRepo.CountListingsIn(currency)
+	if err != nil {
+		return err
+	}
+	if open > 0 {
+		return fmt.Errorf("%d open listings are priced in %s", open, currency)
+	}
+
+	deleted, err := s.rateRepo.Delete(currency)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrExchangeRateNotFound
+	}
+	return nil
+}
+
+// rateFeed is the document served by the configured rate feed
+type rateFeed struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// RefreshRates replaces the rates with those from the configured feed. It
+// does nothing when no feed is configured.
+func (s *ExchangeRateService) RefreshRates() error {
+	feedURL := config.GetExchangeRateConfig().FeedURL
+	if feedURL == "" {
+		return nil
+	}
+
+	resp, err := s.httpClient.Get(feedURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("exchange rate feed returned status %d", resp.StatusCode)
+	}
+
+	var feed rateFeed
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&feed); err != nil {
+		return err
+	}
+
+	// Feeds quoted against another currency are rebased on ours
+	base := 1.0
+	if feed.Base != models.DefaultCurrency {
+		var ok bool
+		if base, ok = feed.Rates[models.DefaultCurrency]; !ok || base <= 0 {
+			return fmt.Errorf("exchange rate feed has no %s rate", models.DefaultCurrency)
+		}
+	}
+
+	now := time.Now()
+	saved := 0
+	for code, rate := range feed.Rates {
+		currency, err := parseCurrency(code)
+		if err != nil || currency == models.DefaultCurrency || rate <= 0 {
+			continue
+		}
+		exchangeRate := &models.ExchangeRate{
+			Currency:  currency,
+			Rate:      rate / base,
+			Source:    models.RateSourceFeed,
+			UpdatedAt: now,
+		}
+		if err := s.rateRepo.Save(exchangeRate); err != nil {
+			return err
+		}
+		saved++
+	}
+
+	log.Printf("Refreshed %d exchange rates", saved)
+	return nil
+}
+
+// parseCurrency normalises an ISO 4217 currency code such as "eur"
+func parseCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return "", fmt.Errorf("%q is not a currency code", code)
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return "", fmt.Errorf("%q is not a currency code", code)
+		}
+	}
+	return code, nil
+}
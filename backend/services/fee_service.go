@@ -0,0 +1,202 @@
+// services/fee_service.go
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jimsyyap/auctions/backend/config"
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/repositories"
+)
+
+var ErrFeeRuleNotFound = errors.New("fee rule not found")
+
+// FeeService works out the insertion and final value fees sellers pay,
+// from rules admins set by category and price band
+type FeeService struct {
+	feeRepo      *repositories.FeeRepository
+	categoryRepo *repositories.CategoryRepository
+}
+
+func NewFeeService(feeRepo *repositories.FeeRepository, categoryRepo *repositories.CategoryRepository) *FeeService {
+	return &FeeService{
+		feeRepo:      feeRepo,
+		categoryRepo: categoryRepo,
+	}
+}
+
+// FeeRuleRequest creates or replaces a fee rule. Amounts are decimal
+// strings in Currency; MaxPrice may be left out for a band with no upper
+// bound, and CategoryID for a rule that applies to every category.
+type FeeRuleRequest struct {
+	Kind       string  `json:"kind" binding:"required,oneof=insertion final_value"`
+	CategoryID *uint   `json:"category_id"`
+	Currency   string  `json:"currency"`
+	MinPrice   string  `json:"min_price"`
+	MaxPrice   string  `json:"max_price"`
+	Rate       float64 `json:"rate" binding:"min=0,max=1"`
+	FixedFee   string  `json:"fixed_fee"`
+}
+
+// GetRules lists the fee rules, optionally of one kind
+func (s *FeeService) GetRules(kind string) ([]models.FeeRule, error) {
+	return s.feeRepo.FindRules(kind)
+}
+
+// CreateRule adds a fee rule
+func (s *FeeService) CreateRule(req *FeeRuleRequest) (*models.FeeRule, error) {
+	rule := &models.FeeRule{}
+	if err := s.applyRequest(rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.feeRepo.CreateRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// UpdateRule replaces a fee rule. Fees already charged are not affected.
+func (s *FeeService) UpdateRule(id uint, req *FeeRuleRequest) (*models.FeeRule, error) {
+	rule, err := s.feeRepo.FindRuleByID(id)
+	if err != nil {
+		return nil, ErrFeeRuleNotFound
+	}
+	if err := s.applyRequest(rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.feeRepo.UpdateRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// DeleteRule removes a fee rule
+func (s *FeeService) DeleteRule(id uint) error {
+	found, err := s.feeRepo.DeleteRule(id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrFeeRuleNotFound
+	}
+	return nil
+}
+
+// Fee returns the fee of a kind for a listing at a price, in the price's
+// currency. Without a matching rule there is no insertion fee, and the
+// final value fee is the configured platform fee rate.
+func (s *FeeService) Fee(kind string, listing *models.Listing, price models.Money) (models.Money, error) {
+	categoryIDs := make([]uint, len(listing.Categories))
+	for i, category := range listing.Categories {
+		categoryIDs[i] = category.ID
+	}
+
+	rules, err := s.feeRepo.FindMatchingRules(kind, price.Currency, categoryIDs)
+	if err != nil {
+		return models.Money{}, err
+	}
+
+	var best *models.FeeRule
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Matches(price) && (best == nil || moreSpecific(rule, best)) {
+			best = rule
+		}
+	}
+	if best != nil {
+		return best.Fee(price), nil
+	}
+
+	if kind == models.FeeFinalValue {
+		return price.ApplyRate(config.GetMarketplaceConfig().PlatformFeeRate), nil
+	}
+	return models.NewMoney(0, price.Currency), nil
+}
+
+// moreSpecific reports whether rule a should win over rule b: a category
+// rule beats a general one, then a narrower band beats a wider one, then
+// the newer rule wins. Both rules match the same price, so of two bands
+// with no upper bound the one starting higher is the narrower.
+func moreSpecific(a, b *models.FeeRule) bool {
+	if (a.CategoryID != nil) != (b.CategoryID != nil) {
+		return a.CategoryID != nil
+	}
+	if c := compareBandWidths(a, b); c != 0 {
+		return c < 0
+	}
+	if c := a.MinPrice.Cmp(b.MinPrice); c != 0 {
+		return c > 0
+	}
+	return a.ID > b.ID
+}
+
+// compareBandWidths compares the width of two rules' price bands. A band
+// with no upper bound is wider than any bounded one.
+func compareBandWidths(a, b *models.FeeRule) int {
+	aOpen, bOpen := a.MaxPrice.IsZero(), b.MaxPrice.IsZero()
+	switch {
+	case aOpen && bOpen:
+		return 0
+	case aOpen:
+		return 1
+	case bOpen:
+		return -1
+	}
+	return a.MaxPrice.Sub(a.MinPrice).Cmp(b.MaxPrice.Sub(b.MinPrice))
+}
+
+// applyRequest validates a fee rule request and copies it onto rule
+func (s *FeeService) applyRequest(rule *models.FeeRule, req *FeeRuleRequest) error {
+	currency := models.DefaultCurrency
+	if req.Currency != "" {
+		var err error
+		if currency, err = parseCurrency(req.Currency); err != nil {
+			return err
+		}
+	}
+
+	parse := func(name, amount string) (models.Money, error) {
+		if amount == "" {
+			return models.NewMoney(0, currency), nil
+		}
+		value, err := models.ParseMoney(amount, currency)
+		if err != nil {
+			return value, fmt.Errorf("invalid %s: %v", name, err)
+		}
+		if value.Minor < 0 {
+			return value, fmt.Errorf("%s cannot be negative", name)
+		}
+		return value, nil
+	}
+
+	minPrice, err := parse("min price", req.MinPrice)
+	if err != nil {
+		return err
+	}
+	maxPrice, err := parse("max price", req.MaxPrice)
+	if err != nil {
+		return err
+	}
+	fixedFee, err := parse("fixed fee", req.FixedFee)
+	if err != nil {
+		return err
+	}
+	if !maxPrice.IsZero() && maxPrice.Cmp(minPrice) <= 0 {
+		return errors.New("max price must be greater than min price")
+	}
+
+	if req.CategoryID != nil {
+		if _, err := s.categoryRepo.FindByID(*req.CategoryID); err != nil {
+			return errors.New("invalid category ID")
+		}
+	}
+
+	rule.Kind = req.Kind
+	rule.CategoryID = req.CategoryID
+	rule.MinPrice = minPrice
+	rule.MaxPrice = maxPrice
+	rule.Rate = req.Rate
+	rule.FixedFee = fixedFee
+	return nil
+}
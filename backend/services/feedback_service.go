@@ -0,0 +1,224 @@
+// services/feedback_service.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/config"
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/repositories"
+)
+
+var (
+	ErrFeedbackNotAllowed = errors.New("only the buyer and seller of a completed sale can leave feedback")
+	ErrFeedbackNotFound   = errors.New("feedback not found")
+)
+
+// FeedbackService lets the two parties of a completed sale rate each other
+type FeedbackService struct {
+	ratingRepo          *repositories.RatingRepository
+	listingRepo         *repositories.ListingRepository
+	transactionRepo     *repositories.TransactionRepository
+	notificationService *NotificationService
+}
+
+func NewFeedbackService(ratingRepo *repositories.RatingRepository, listingRepo *repositories.ListingRepository, transactionRepo *repositories.TransactionRepository, notificationService *NotificationService) *FeedbackService {
+	return &FeedbackService{
+		ratingRepo:          ratingRepo,
+		listingRepo:         listingRepo,
+		transactionRepo:     transactionRepo,
+		notificationService: notificationService,
+	}
+}
+
+// FeedbackRequest represents feedback left on a sale
+type FeedbackRequest struct {
+	Score   int    `json:"score" binding:"required,min=1,max=5"`
+	Comment string `json:"comment" binding:"max=2000"`
+}
+
+// FeedbackReplyRequest represents the rated user's reply to feedback
+type FeedbackReplyRequest struct {
+	Reply string `json:"reply" binding:"required,max=2000"`
+}
+
+// FeedbackQuery filters a feedback list. Role is the role of the rated
+// user; Period is 30d, 12m or all.
+type FeedbackQuery struct {
+	Role      string `form:"role" binding:"omitempty,oneof=seller buyer"`
+	Sentiment string `form:"sentiment" binding:"omitempty,oneof=positive neutral negative"`
+	Period    string `form:"period" binding:"omitempty,oneof=30d 12m all"`
+}
+
+// FeedbackView is a rating as shown publicly
+type FeedbackView struct {
+	ID        uint           `json:"id"`
+	Score     int            `json:"score"`
+	Sentiment string         `json:"sentiment"`
+	Comment   string         `json:"comment,omitempty"`
+	RatedAs   string         `json:"rated_as"`
+	Reply     string         `json:"reply,omitempty"`
+	RepliedAt *time.Time     `json:"replied_at,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	Listing   ListingSummary `json:"listing"`
+	Rater     UserSummary    `json:"rater"`
+	Rated     UserSummary    `json:"rated"`
+}
+
+// LeaveFeedback rates the other party of a completed sale. Each party can
+// rate once, within the feedback window after the sale completes.
+func (s *FeedbackService) LeaveFeedback(raterID, listingID uint, req *FeedbackRequest) (*FeedbackView, error) {
+	listing, err := s.listingRepo.FindByID(listingID)
+	if err != nil {
+		return nil, errors.New("listing not found")
+	}
+
+	sellerID, buyerID, completedAt, err := s.saleParties(listing)
+	if err != nil {
+		return nil, err
+	}
+
+	rating := &models.Rating{
+		Score:       req.Score,
+		Comment:     strings.TrimSpace(req.Comment),
+		RaterUserID: raterID,
+		ListingID:   listing.ID,
+	}
+	switch raterID {
+	case buyerID:
+		rating.RatedUserID = sellerID
+		rating.RatedAs = models.RatedAsSeller
+	case sellerID:
+		rating.RatedUserID = buyerID
+		rating.RatedAs = models.RatedAsBuyer
+	default:
+		return nil, ErrFeedbackNotAllowed
+	}
+
+	window := config.GetMarketplaceConfig().FeedbackWindow
+	if time.Now().After(completedAt.Add(window)) {
+		return nil, fmt.Errorf("feedback can only be left within %d days of the sale", int(window.Hours()/24))
+	}
+
+	exists, err := s.ratingRepo.Exists(listing.ID, raterID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, errors.New("you have already left feedback for this sale")
+	}
+
+	if err := s.ratingRepo.Create(rating); err != nil {
+		return nil, err
+	}
+
+	s.notificationService.Notify(rating.RatedUserID, models.NotificationFeedback,
+		"You received new feedback",
+		fmt.Sprintf("You received %s feedback for \"%s\".", models.Sentiment(rating.Score), listing.Title),
+		&rating.ID)
+
+	stored, err := s.ratingRepo.FindByID(rating.ID)
+	if err != nil {
+		return nil, err
+	}
+	view := toFeedbackView(stored)
+	return &view, nil
+}
+
+// Reply posts the rated user's single public reply to feedback
+func (s *FeedbackService) Reply(userID, ratingID uint, req *FeedbackReplyRequest) (*FeedbackView, error) {
+	rating, err := s.ratingRepo.FindByID(ratingID)
+	if err != nil {
+		return nil, ErrFeedbackNotFound
+	}
+	if rating.RatedUserID != userID {
+		return nil, errors.New("only the user who received this feedback can reply")
+	}
+
+	reply := strings.TrimSpace(req.Reply)
+	if reply == "" {
+		return nil, errors.New("reply cannot be empty")
+	}
+
+	saved, err := s.ratingRepo.SaveReply(rating.ID, reply)
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		return nil, errors.New("you have already replied to this feedback")
+	}
+
+	rating, err = s.ratingRepo.FindByID(rating.ID)
+	if err != nil {
+		return nil, err
+	}
+	view := toFeedbackView(rating)
+	return &view, nil
+}
+
+// GetReceived lists feedback a user received
+func (s *FeedbackService) GetReceived(userID uint, query FeedbackQuery, page, limit int) ([]FeedbackView, int64, error) {
+	ratings, total, err := s.ratingRepo.FindReceived(userID, query.filter(), page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	return toFeedbackViews(ratings), total, nil
+}
+
+// GetGiven lists feedback a user left for others
+func (s *FeedbackService) GetGiven(userID uint, query FeedbackQuery, page, limit int) ([]FeedbackView, int64, error) {
+	ratings, total, err := s.ratingRepo.FindGiven(userID, query.filter(), page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	return toFeedbackViews(ratings), total, nil
+}
+
+// filter turns the query into a repository filter
+func (q FeedbackQuery) filter() repositories.RatingFilter {
+	filter := repositories.RatingFilter{RatedAs: q.Role, Sentiment: q.Sentiment}
+	switch q.Period {
+	case "30d":
+		filter.Since = time.Now().AddDate(0, 0, -30)
+	case "12m":
+		filter.Since = time.Now().AddDate(0, -12, 0)
+	}
+	return filter
+}
+
+// saleParties returns the seller and buyer of a completed sale and when it
+// completed. A sale completes when the buyer confirms delivery.
+func (s *FeedbackService) saleParties(listing *models.Listing) (sellerID, buyerID uint, completedAt time.Time, err error) {
+	transaction, err := s.transactionRepo.FindByListing(listing.ID)
+	if err != nil || transaction.Status != models.TransactionCompleted || transaction.CompletedAt == nil {
+		return 0, 0, time.Time{}, ErrFeedbackNotAllowed
+	}
+	return transaction.SellerID, transaction.BuyerID, *transaction.CompletedAt, nil
+}
+
+func toFeedbackViews(ratings []models.Rating) []FeedbackView {
+	views := make([]FeedbackView, len(ratings))
+	for i := range ratings {
+		views[i] = toFeedbackView(&ratings[i])
+	}
+	return views
+}
+
+func toFeedbackView(r *models.Rating) FeedbackView {
+	return FeedbackView{
+		ID:        r.ID,
+		Score:     r.Score,
+		Sentiment: models.Sentiment(r.Score),
+		Comment:   r.Comment,
+		RatedAs:   r.RatedAs,
+		Reply:     r.Reply,
+		RepliedAt: r.RepliedAt,
+		CreatedAt: r.CreatedAt,
+		Listing:   ListingSummary{ID: r.Listing.ID, Title: r.Listing.Title},
+		Rater:     toUserSummary(&r.RaterUser),
+		Rated:     toUserSummary(&r.RatedUser),
+	}
+}
@@ -0,0 +1,131 @@
+// services/image_service.go
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/jimsyyap/auctions/backend/storage"
+)
+
+const (
+	maxImageSize      = 5 << 20 // 5 MB
+	maxImageDimension = 4096    // pixels, per side
+)
+
+// Image formats accepted for upload, by sniffed content type
+var allowedImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+// StoredImage is an image saved to public storage
+type StoredImage struct {
+	Key string
+	URL string
+}
+
+// ImageService is the pipeline every user-uploaded picture goes through:
+// it checks the real format and size, re-encodes the image to drop
+// metadata such as GPS coordinates, and saves it to public storage
+type ImageService struct {
+	imageStorage storage.Storage
+}
+
+func NewImageService(imageStorage storage.Storage) *ImageService {
+	return &ImageService{
+		imageStorage: imageStorage,
+	}
+}
+
+// Store processes an uploaded image and saves it under the given key prefix,
+// such as "avatars/12"
+func (s *ImageService) Store(prefix string, file *multipart.FileHeader) (*StoredImage, error) {
+	if file.Size > maxImageSize {
+		return nil, errors.New("image must be 5 MB or smaller")
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImageSize {
+		return nil, errors.New("image must be 5 MB or smaller")
+	}
+
+	// Trust the file's content, not its name or the client's header
+	contentType := http.DetectContentType(data)
+	ext, ok := allowedImageTypes[contentType]
+	if !ok {
+		return nil, errors.New("image must be a JPEG or PNG file")
+	}
+
+	// Check dimensions before decoding so huge images are never expanded
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("image could not be read")
+	}
+	if cfg.Width > maxImageDimension || cfg.Height > maxImageDimension {
+		return nil, fmt.Errorf("image must be at most %dx%d pixels", maxImageDimension, maxImageDimension)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("image could not be read")
+	}
+
+	var encoded bytes.Buffer
+	if contentType == "image/png" {
+		err = png.Encode(&encoded, img)
+	} else {
+		err = jpeg.Encode(&encoded, img, &jpeg.Options{Quality: 90})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := generateOpaqueToken(16)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("%s/%s%s", prefix, name, ext)
+	if err := s.imageStorage.Save(key, &encoded); err != nil {
+		return nil, err
+	}
+
+	return &StoredImage{Key: key, URL: s.imageStorage.URL(key)}, nil
+}
+
+// Open reads a stored image back, with its content type. It is used to
+// serve images kept in private storage.
+func (s *ImageService) Open(key string) (io.ReadCloser, string, error) {
+	r, err := s.imageStorage.Open(key)
+	if err != nil {
+		return nil, "", err
+	}
+	for contentType, ext := range allowedImageTypes {
+		if strings.HasSuffix(key, ext) {
+			return r, contentType, nil
+		}
+	}
+	return r, "application/octet-stream", nil
+}
+
+// Delete removes a stored image
+func (s *ImageService) Delete(key string) error {
+	return s.imageStorage.Delete(key)
+}
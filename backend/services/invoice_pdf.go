@@ -0,0 +1,252 @@
+// services/invoice_pdf.go
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/pdf"
+)
+
+// Invoice layout, in points on an A4 page
+const (
+	invoiceMargin = 50.0
+	invoiceRight  = pdf.A4Width - invoiceMargin
+)
+
+const invoiceDates = "January 2, 2006"
+
+var (
+	invoiceGray = pdf.Color{R: 100, G: 100, B: 100}
+	invoiceRule = pdf.Color{R: 210, G: 210, B: 210}
+)
+
+// invoiceParty is a name and postal address printed on an invoice
+type invoiceParty struct {
+	Name    string
+	Address []string
+}
+
+// invoiceLine is one line item; detail is printed small under the description
+type invoiceLine struct {
+	description string
+	detail      string
+	amount      models.Money
+}
+
+// invoiceDocument is everything printed on an invoice
+type invoiceDocument struct {
+	Branding    *Branding
+	Invoice     *models.Invoice
+	Transaction *models.Transaction
+	Seller      invoiceParty
+	Buyer       invoiceParty
+	ShipTo      invoiceParty
+	Refunded    models.Money // refunded to the buyer after the sale
+}
+
+// renderInvoice lays out an invoice as a one-page PDF
+func renderInvoice(d *invoiceDocument) ([]byte, error) {
+	b := d.Branding
+	t := d.Transaction
+
+	doc := pdf.New()
+	doc.SetInfo("Invoice "+d.Invoice.Number, b.Name, d.Invoice.IssuedAt)
+	page := doc.AddPage(pdf.A4Width, pdf.A4Height)
+	top := page.Height()
+
+	// Branded header
+	page.SetColor(b.Color)
+	page.Rect(0, top-90, page.Width(), 90)
+	page.SetColor(pdf.White)
+	page.Text(invoiceMargin, top-50, pdf.HelveticaBold, 22, b.Name)
+	page.TextRight(invoiceRight, top-50, pdf.HelveticaBold, 22, "INVOICE")
+	if b.Email != "" {
+		page.Text(invoiceMargin, top-70, pdf.Helvetica, 9, b.Email)
+	}
+	page.TextRight(invoiceRight, top-70, pdf.Helvetica, 10, d.Invoice.Number)
+
+	// The marketplace's details on the left, the invoice's on the right
+	left := top - 120
+	page.SetColor(invoiceGray)
+	for _, line := range b.Address {
+		page.Text(invoiceMargin, left, pdf.Helvetica, 9, line)
+		left -= 12
+	}
+	if b.TaxID != "" {
+		page.Text(invoiceMargin, left, pdf.Helvetica, 9, "Tax ID: "+b.TaxID)
+		left -= 12
+	}
+
+	details := [][2]string{
+		{"Invoice number", d.Invoice.Number},
+		{"Issue date", d.Invoice.IssuedAt.Format(invoiceDates)},
+		{"Sale completed", formatInvoiceDate(t.CompletedAt)},
+		{"Paid", formatInvoiceDate(t.PaidAt)},
+		{"Order", fmt.Sprintf("#%d", t.ID)},
+	}
+	right := top - 120
+	for _, row := range details {
+		page.SetColor(invoiceGray)
+		page.Text(360, right, pdf.Helvetica, 9, row[0])
+		page.SetColor(pdf.Black)
+		page.TextRight(invoiceRight, right, pdf.HelveticaBold, 9, row[1])
+		right -= 14
+	}
+
+	// Parties
+	y := min(left, right) - 20
+	partiesBottom := y
+	for i, party := range []struct {
+		heading string
+		party   invoiceParty
+	}{
+		{"SELLER", d.Seller},
+		{"BILL TO", d.Buyer},
+		{"SHIP TO", d.ShipTo},
+	} {
+		x := invoiceMargin + float64(i)*165
+		line := y
+		page.SetColor(b.Color)
+		page.Text(x, line, pdf.HelveticaBold, 8, party.heading)
+		line -= 15
+		page.SetColor(pdf.Black)
+		page.Text(x, line, pdf.HelveticaBold, 10, party.party.Name)
+		line -= 13
+		for _, address := range party.party.Address {
+			for _, wrapped := range pdf.WrapText(pdf.Helvetica, 9, 155, address) {
+				page.Text(x, line, pdf.Helvetica, 9, wrapped)
+				line -= 12
+			}
+		}
+		partiesBottom = min(partiesBottom, line)
+	}
+
+	// Line items
+	y = partiesBottom - 20
+	page.SetColor(b.Color.Tint(0.85))
+	page.Rect(invoiceMargin, y-6, invoiceRight-invoiceMargin, 20)
+	page.SetColor(pdf.Black)
+	page.Text(invoiceMargin+8, y, pdf.HelveticaBold, 9, "Description")
+	page.TextRight(360, y, pdf.HelveticaBold, 9, "Qty")
+	page.TextRight(450, y, pdf.HelveticaBold, 9, "Unit price")
+	page.TextRight(invoiceRight-8, y, pdf.HelveticaBold, 9, "Amount")
+	y -= 26
+
+	items := []invoiceLine{
+		{t.Listing.Title, fmt.Sprintf("Listing #%d, %s", t.ListingID, describeSaleType(t.Type)), t.ItemPrice},
+	}
+	if t.ShippingCost.IsPositive() {
+		items = append(items, invoiceLine{"Shipping", "", t.ShippingCost})
+	}
+	for _, item := range items {
+		lines := pdf.WrapText(pdf.Helvetica, 10, 270, item.description)
+		page.SetColor(pdf.Black)
+		page.TextRight(360, y, pdf.Helvetica, 10, "1")
+		page.TextRight(450, y, pdf.Helvetica, 10, item.amount.String())
+		page.TextRight(invoiceRight-8, y, pdf.Helvetica, 10, item.amount.String())
+		for _, line := range lines {
+			page.Text(invoiceMargin+8, y, pdf.Helvetica, 10, line)
+			y -= 13
+		}
+		if item.detail != "" {
+			page.SetColor(invoiceGray)
+			page.Text(invoiceMargin+8, y, pdf.Helvetica, 8, item.detail)
+			y -= 11
+		}
+		y -= 4
+		page.SetColor(invoiceRule)
+		page.Line(invoiceMargin, y+8, invoiceRight, y+8, 0.5)
+		y -= 8
+	}
+
+	// Totals
+	y -= 6
+	subtotal := t.ItemPrice.Add(t.ShippingCost)
+	for _, row := range [][2]string{
+		{"Subtotal", subtotal.String()},
+		{"Sales tax", t.TaxAmount.String()},
+	} {
+		page.SetColor(invoiceGray)
+		page.Text(360, y, pdf.Helvetica, 10, row[0])
+		page.SetColor(pdf.Black)
+		page.TextRight(invoiceRight-8, y, pdf.Helvetica, 10, row[1])
+		y -= 16
+	}
+	page.SetColor(b.Color)
+	page.Line(360, y+10, invoiceRight, y+10, 1)
+	y -= 6
+	page.Text(360, y, pdf.HelveticaBold, 12, "Total")
+	page.TextRight(invoiceRight-8, y, pdf.HelveticaBold, 12, t.Amount.String())
+	y -= 16
+	paid := "Paid in full " + formatInvoiceDate(t.PaidAt)
+	if d.Refunded.IsPositive() {
+		for _, row := range [][2]string{
+			{"Refunded", "-" + d.Refunded.String()},
+			{"Net paid", t.Amount.Sub(d.Refunded).String()},
+		} {
+			page.SetColor(invoiceGray)
+			page.Text(360, y, pdf.Helvetica, 10, row[0])
+			page.SetColor(pdf.Black)
+			page.TextRight(invoiceRight-8, y, pdf.Helvetica, 10, row[1])
+			y -= 16
+		}
+		paid = fmt.Sprintf("Paid %s, %s refunded", formatInvoiceDate(t.PaidAt), d.Refunded)
+	}
+	page.SetColor(invoiceGray)
+	page.TextRight(invoiceRight-8, y, pdf.Helvetica, 9, paid)
+
+	// Marketplace fees, which the seller pays out of the sale
+	y -= 40
+	page.SetColor(b.Color)
+	page.Text(invoiceMargin, y, pdf.HelveticaBold, 8, "MARKETPLACE FEES")
+	y -= 16
+	fees := [][2]string{
+		{"Final value fee, deducted from the seller's proceeds", t.PlatformFee.String()},
+	}
+	proceeds := t.SellerProceeds()
+	if d.Refunded.IsPositive() {
+		fees = append(fees, [2]string{"Refund to the buyer, deducted from the seller's proceeds", d.Refunded.String()})
+		proceeds = proceeds.Sub(d.Refunded)
+	}
+	for _, row := range append(fees, [2]string{"Seller proceeds", proceeds.String()}) {
+		page.SetColor(pdf.Black)
+		page.Text(invoiceMargin, y, pdf.Helvetica, 9, row[0])
+		page.TextRight(invoiceRight-8, y, pdf.Helvetica, 9, row[1])
+		y -= 14
+	}
+	page.SetColor(invoiceGray)
+	page.Text(invoiceMargin, y, pdf.Helvetica, 8, "Fees are charged to the seller and are not part of the total the buyer paid.")
+
+	// Footer
+	var footer []string
+	if b.InvoiceFooter != "" {
+		footer = pdf.WrapText(pdf.Helvetica, 8, invoiceRight-invoiceMargin, b.InvoiceFooter)
+	}
+	footer = append(footer, fmt.Sprintf("Issued by %s on behalf of the seller.", b.Name))
+	y = 40 + float64(len(footer))*11
+	page.SetColor(invoiceRule)
+	page.Line(invoiceMargin, y+4, invoiceRight, y+4, 0.5)
+	page.SetColor(invoiceGray)
+	for _, line := range footer {
+		y -= 11
+		page.Text(invoiceMargin, y, pdf.Helvetica, 8, line)
+	}
+
+	return doc.Bytes()
+}
+
+func formatInvoiceDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(invoiceDates)
+}
+
+func describeSaleType(saleType string) string {
+	if saleType == models.TransactionBuyNow {
+		return "bought with Buy Now"
+	}
+	return "won at auction"
+}
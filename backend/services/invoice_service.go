@@ -0,0 +1,234 @@
+// services/invoice_service.go
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/repositories"
+	"github.com/jimsyyap/auctions/backend/storage"
+)
+
+var (
+	ErrInvoiceNotReady = errors.New("an invoice is issued once the sale is completed")
+	ErrInvoiceAltered  = errors.New("the stored invoice does not match the one that was issued")
+)
+
+// InvoiceService issues PDF invoices for completed sales. An invoice is
+// generated the first time it is asked for and stored; every later
+// request returns the stored document, checked against its checksum, so
+// changes to the sale, the parties' addresses or the branding never alter
+// an invoice that has been issued.
+type InvoiceService struct {
+	invoiceRepo     *repositories.InvoiceRepository
+	transactionRepo *repositories.TransactionRepository
+	paymentRepo     *repositories.PaymentRepository
+	addressRepo     *repositories.AddressRepository
+	settingService  *SettingService
+	invoiceStorage  storage.Storage
+}
+
+// NewInvoiceService creates the service. invoiceStorage must be private:
+// invoices are only served to the buyer and the seller.
+func NewInvoiceService(invoiceRepo *repositories.InvoiceRepository, transactionRepo *repositories.TransactionRepository, paymentRepo *repositories.PaymentRepository, addressRepo *repositories.AddressRepository, settingService *SettingService, invoiceStorage storage.Storage) *InvoiceService {
+	return &InvoiceService{
+		invoiceRepo:     invoiceRepo,
+		transactionRepo: transactionRepo,
+		paymentRepo:     paymentRepo,
+		addressRepo:     addressRepo,
+		settingService:  settingService,
+		invoiceStorage:  invoiceStorage,
+	}
+}
+
+// GetInvoice returns the invoice for one of the user's completed sales as a
+// PDF, issuing it first if it has not been issued yet
+func (s *InvoiceService) GetInvoice(userID, transactionID uint) (*models.Invoice, []byte, error) {
+	transaction, err := s.transactionRepo.FindByID(transactionID)
+	if err != nil || !transaction.HasParticipant(userID) {
+		return nil, nil, ErrTransactionNotFound
+	}
+
+	if invoice, err := s.invoiceRepo.FindByTransaction(transaction.ID); err == nil {
+		return s.load(invoice)
+	}
+	if transaction.Status != models.TransactionCompleted {
+		return nil, nil, ErrInvoiceNotReady
+	}
+
+	invoice, content, err := s.issue(transaction)
+	if err != nil {
+		// Someone else may have issued it first
+		if existing, findErr := s.invoiceRepo.FindByTransaction(transaction.ID); findErr == nil {
+			return s.load(existing)
+		}
+		return nil, nil, err
+	}
+	return invoice, content, nil
+}
+
+// issue numbers, renders and stores the invoice for a completed sale
+func (s *InvoiceService) issue(transaction *models.Transaction) (*models.Invoice, []byte, error) {
+	branding, err := s.settingService.Branding()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	seller := invoiceParty{Name: displayName(&transaction.Seller)}
+	if address, err := s.addressRepo.FindDefaultBilling(transaction.SellerID); err == nil {
+		seller.Address = addressLines(&address.AddressSnapshot)
+	}
+	buyer := invoiceParty{Name: displayName(&transaction.Buyer), Address: addressLines(&transaction.ShippingAddress)}
+	if address, err := s.addressRepo.FindDefaultBilling(transaction.BuyerID); err == nil {
+		buyer.Address = addressLines(&address.AddressSnapshot)
+	}
+	shipTo := invoiceParty{Name: buyer.Name, Address: addressLines(&transaction.ShippingAddress)}
+
+	// A dispute can end with the sale completed and part of the payment
+	// refunded
+	refunded := models.NewMoney(0, transaction.Amount.Currency)
+	attempts, err := s.paymentRepo.FindByTransaction(transaction.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, payment := range attempts {
+		if payment.Status == models.PaymentPartiallyRefunded {
+			refunded = payment.RefundedAmount
+			break
+		}
+	}
+
+	invoice := &models.Invoice{
+		IssuedAt:      time.Now().UTC().Truncate(time.Second),
+		TransactionID: transaction.ID,
+		SellerID:      transaction.SellerID,
+		BuyerID:       transaction.BuyerID,
+	}
+	var content []byte
+	err = s.invoiceRepo.Issue(invoice, func(invoice *models.Invoice) error {
+		invoice.Number = fmt.Sprintf("%s-%d-%06d", branding.InvoicePrefix, invoice.SellerID, invoice.Sequence)
+
+		var err error
+		content, err = renderInvoice(&invoiceDocument{
+			Branding:    branding,
+			Invoice:     invoice,
+			Transaction: transaction,
+			Seller:      seller,
+			Buyer:       buyer,
+			ShipTo:      shipTo,
+			Refunded:    refunded,
+		})
+		if err != nil {
+			return err
+		}
+
+		// The document is kept under a pending key until the invoice is on
+		// record, so one left behind by a failed issue is easy to tell apart
+		name, err := generateOpaqueToken(16)
+		if err != nil {
+			return err
+		}
+		key := fmt.Sprintf("invoices/pending/%s.pdf", name)
+		if err := s.invoiceStorage.Save(key, bytes.NewReader(content)); err != nil {
+			return err
+		}
+		sum := sha256.Sum256(content)
+		invoice.StorageKey = key
+		invoice.Checksum = hex.EncodeToString(sum[:])
+		invoice.Size = int64(len(content))
+		return nil
+	})
+	if err != nil {
+		if invoice.StorageKey != "" {
+			if deleteErr := s.invoiceStorage.Delete(invoice.StorageKey); deleteErr != nil {
+				log.Printf("Failed to delete unissued invoice %s: %v", invoice.StorageKey, deleteErr)
+			}
+		}
+		return nil, nil, err
+	}
+
+	s.keep(invoice, content)
+	return invoice, content, nil
+}
+
+// keep moves an issued invoice's document from its pending key to a
+// permanent one under the seller. The invoice points at a stored copy at
+// every step, and stays on the pending key if the move fails.
+func (s *InvoiceService) keep(invoice *models.Invoice, content []byte) {
+	pending := invoice.StorageKey
+	key := fmt.Sprintf("invoices/%d/%s", invoice.SellerID, path.Base(pending))
+	if err := s.invoiceStorage.Save(key, bytes.NewReader(content)); err != nil {
+		log.Printf("Failed to store invoice %s under %s: %v", invoice.Number, key, err)
+		return
+	}
+
+	moved, err := s.invoiceRepo.MoveDocument(invoice, key)
+	if err != nil || !moved {
+		log.Printf("Failed to move invoice %s to %s: %v", invoice.Number, key, err)
+		if err := s.invoiceStorage.Delete(key); err != nil {
+			log.Printf("Failed to delete unused invoice copy %s: %v", key, err)
+		}
+		return
+	}
+	if err := s.invoiceStorage.Delete(pending); err != nil {
+		log.Printf("Failed to delete pending invoice %s: %v", pending, err)
+	}
+}
+
+// load reads an issued invoice from storage and checks it is unchanged
+func (s *InvoiceService) load(invoice *models.Invoice) (*models.Invoice, []byte, error) {
+	r, err := s.invoiceStorage.Open(invoice.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer r.Close()
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	sum := sha256.Sum256(content)
+	if hex.EncodeToString(sum[:]) != invoice.Checksum {
+		log.Printf("Invoice %s (%s) no longer matches its checksum", invoice.Number, invoice.StorageKey)
+		return nil, nil, ErrInvoiceAltered
+	}
+	return invoice, content, nil
+}
+
+// displayName is how a user is named on documents: their full name when
+// they gave one, otherwise their username
+func displayName(user *models.User) string {
+	if name := strings.TrimSpace(user.FirstName + " " + user.LastName); name != "" {
+		return name
+	}
+	return user.Username
+}
+
+// addressLines formats a postal address for printing
+func addressLines(a *models.AddressSnapshot) []string {
+	if a.Country == "" {
+		return nil
+	}
+
+	lines := []string{a.StreetAddress1}
+	if a.StreetAddress2 != "" {
+		lines = append(lines, a.StreetAddress2)
+	}
+	locality := a.City
+	if a.State != "" {
+		locality += ", " + a.State
+	}
+	if a.PostalCode != "" {
+		locality += " " + a.PostalCode
+	}
+	return append(lines, locality, a.Country)
+}
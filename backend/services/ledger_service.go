@@ -0,0 +1,526 @@
+// services/ledger_service.go
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/repositories"
+)
+
+// LedgerService records every movement of money in a double-entry ledger:
+// buyer payments into escrow, refunds out of it, settlement of completed
+// sales to sellers, tax and platform revenue, fees, and payouts. Each
+// event is recorded once, however often it is reported.
+type LedgerService struct {
+	ledgerRepo          *repositories.LedgerRepository
+	feeService          *FeeService
+	notificationService *NotificationService
+}
+
+func NewLedgerService(ledgerRepo *repositories.LedgerRepository, feeService *FeeService, notificationService *NotificationService) *LedgerService {
+	return &LedgerService{
+		ledgerRepo:          ledgerRepo,
+		feeService:          feeService,
+		notificationService: notificationService,
+	}
+}
+
+// AccountView is a ledger account and its balance
+type AccountView struct {
+	ID      uint         `json:"id"`
+	Type    string       `json:"type"`
+	UserID  uint         `json:"user_id,omitempty"`
+	Balance models.Money `json:"balance"`
+}
+
+// EntryView is a ledger entry with its postings
+type EntryView struct {
+	ID          uint          `json:"id"`
+	Kind        string        `json:"kind"`
+	Reference   string        `json:"reference"`
+	Description string        `json:"description"`
+	Postings    []PostingView `json:"postings"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+// PostingView is one side of a ledger entry
+type PostingView struct {
+	AccountID   uint         `json:"account_id"`
+	AccountType string       `json:"account_type"`
+	UserID      uint         `json:"user_id,omitempty"`
+	Amount      models.Money `json:"amount"`
+}
+
+// PayoutView is a payout and the sales it paid for
+type PayoutView struct {
+	ID        uint                    `json:"id"`
+	Amount    models.Money            `json:"amount"`
+	Status    string                  `json:"status"`
+	PaidAt    *time.Time              `json:"paid_at,omitempty"`
+	CreatedAt time.Time               `json:"created_at"`
+	SellerID  uint                    `json:"seller_id"`
+	Sales     []PayoutTransactionView `json:"sales"`
+}
+
+// PayoutTransactionView is a settled sale included in a payout
+type PayoutTransactionView struct {
+	TransactionID uint           `json:"transaction_id"`
+	Listing       ListingSummary `json:"listing"`
+	Proceeds      models.Money   `json:"proceeds"`
+	SettledAt     *time.Time     `json:"settled_at"`
+}
+
+// ReconciliationReport lists every way the ledger disagrees with itself or
+// with the payments and transactions it records
+type ReconciliationReport struct {
+	CheckedAt     time.Time     `json:"checked_at"`
+	Balanced      bool          `json:"balanced"`
+	Discrepancies []Discrepancy `json:"discrepancies"`
+}
+
+// Discrepancy is one reconciliation failure
+type Discrepancy struct {
+	Check  string `json:"check"`
+	Detail string `json:"detail"`
+}
+
+// RecordPayment moves a captured payment from the buyer into escrow
+func (s *LedgerService) RecordPayment(payment *models.Payment, transaction *models.Transaction) error {
+	return s.post(&models.LedgerEntry{
+		Kind:          models.EntryPayment,
+		Reference:     fmt.Sprintf("payment:%d", payment.ID),
+		Description:   fmt.Sprintf("Payment for transaction %d", transaction.ID),
+		TransactionID: &transaction.ID,
+		Postings: []models.LedgerPosting{
+			posting(models.AccountBuyer, transaction.BuyerID, negate(payment.Amount)),
+			posting(models.AccountEscrow, 0, payment.Amount),
+		},
+	})
+}
+
+// RecordRefund returns a refunded payment to the buyer. A payment still in
+// escrow comes out of escrow; one already released to the seller is taken
+// back from the seller, who gets the final value fee back, and from tax.
+func (s *LedgerService) RecordRefund(payment *models.Payment, transaction *models.Transaction) error {
+	postings := []models.LedgerPosting{
+		posting(models.AccountEscrow, 0, negate(payment.Amount)),
+	}
+	if transaction.SettledAt != nil {
+		postings = []models.LedgerPosting{
+			posting(models.AccountSeller, transaction.SellerID, negate(transaction.ItemPrice.Add(transaction.ShippingCost))),
+			posting(models.AccountTax, 0, negate(transaction.TaxAmount)),
+			posting(models.AccountPlatformRevenue, 0, negate(transaction.PlatformFee)),
+			posting(models.AccountSeller, transaction.SellerID, transaction.PlatformFee),
+		}
+	}
+
+	return s.post(&models.LedgerEntry{
+		Kind:          models.EntryRefund,
+		Reference:     fmt.Sprintf("refund:%d", payment.ID),
+		Description:   fmt.Sprintf("Refund for transaction %d", transaction.ID),
+		TransactionID: &transaction.ID,
+		Postings:      append(postings, posting(models.AccountBuyer, transaction.BuyerID, payment.Amount)),
+	})
+}
+
+// RecordPartialRefund returns part of a payment to the buyer out of the
+// seller's share of the sale
+func (s *LedgerService) RecordPartialRefund(payment *models.Payment, transaction *models.Transaction) error {
+	return s.post(&models.LedgerEntry{
+		Kind:          models.EntryRefund,
+		Reference:     fmt.Sprintf("partial_refund:%d", payment.ID),
+		Description:   fmt.Sprintf("Partial refund for transaction %d", transaction.ID),
+		TransactionID: &transaction.ID,
+		Postings: []models.LedgerPosting{
+			posting(models.AccountSeller, transaction.SellerID, negate(payment.RefundedAmount)),
+			posting(models.AccountBuyer, transaction.BuyerID, payment.RefundedAmount),
+		},
+	})
+}
+
+// Settle releases a sale's payment to the seller: the item and shipping
+// to the seller less the final value fee, the fee to platform revenue and
+// the tax to the tax account. Payments under dispute stay where they are.
+func (s *LedgerService) Settle(transaction *models.Transaction) error {
+	entry := &models.LedgerEntry{
+		Kind:          models.EntrySettlement,
+		Reference:     fmt.Sprintf("settlement:%d", transaction.ID),
+		Description:   fmt.Sprintf("Settlement of transaction %d", transaction.ID),
+		TransactionID: &transaction.ID,
+		Postings: []models.LedgerPosting{
+			posting(models.AccountEscrow, 0, negate(transaction.Amount)),
+			posting(models.AccountSeller, transaction.SellerID, transaction.ItemPrice.Add(transaction.ShippingCost)),
+			posting(models.AccountTax, 0, transaction.TaxAmount),
+			posting(models.AccountSeller, transaction.SellerID, negate(transaction.PlatformFee)),
+			posting(models.AccountPlatformRevenue, 0, transaction.PlatformFee),
+		},
+	}
+	if err := checkBalanced(entry); err != nil {
+		return err
+	}
+
+	_, err := s.ledgerRepo.Settle(transaction.ID, entry, time.Now())
+	return err
+}
+
+// ChargeInsertionFee charges the seller the insertion fee for a listing
+// that has gone live. Listings that have been charged before are not
+// charged again.
+func (s *LedgerService) ChargeInsertionFee(listing *models.Listing) error {
+	fee, err := s.feeService.Fee(models.FeeInsertion, listing, listing.StartPrice)
+	if err != nil || !fee.IsPositive() {
+		return err
+	}
+
+	return s.post(&models.LedgerEntry{
+		Kind:        models.EntryInsertionFee,
+		Reference:   fmt.Sprintf("insertion_fee:%d", listing.ID),
+		Description: fmt.Sprintf("Insertion fee for listing %d", listing.ID),
+		ListingID:   &listing.ID,
+		Postings: []models.LedgerPosting{
+			posting(models.AccountSeller, listing.UserID, negate(fee)),
+			posting(models.AccountPlatformRevenue, 0, fee),
+		},
+	})
+}
+
+// RunPayouts pays each seller the balance of their account in each
+// currency in which they have sales settled since their last payout. Fees
+// the seller owes are taken from the balance first, and the proceeds of
+// sales under dispute are frozen until the dispute is resolved; a seller
+// left with nothing to pay waits for the next run. Sales whose settlement
+// failed when they were paid or completed are settled first.
+func (s *LedgerService) RunPayouts() error {
+	if err := s.settleDue(); err != nil {
+		return err
+	}
+
+	settled, err := s.ledgerRepo.FindUnpaidSettled()
+	if err != nil {
+		return err
+	}
+
+	type batchKey struct {
+		sellerID uint
+		currency string
+	}
+	type batch struct {
+		transactionIDs []uint
+		frozen         models.Money
+	}
+	batches := make(map[batchKey]*batch)
+	var order []batchKey
+	for i := range settled {
+		transaction := &settled[i]
+		key := batchKey{transaction.SellerID, transaction.Amount.Currency}
+		if batches[key] == nil {
+			batches[key] = &batch{frozen: models.NewMoney(0, key.currency)}
+			order = append(order, key)
+		}
+		if transaction.FundsStatus() == models.FundsFrozen {
+			batches[key].frozen = batches[key].frozen.Add(transaction.SellerProceeds())
+			continue
+		}
+		batches[key].transactionIDs = append(batches[key].transactionIDs, transaction.ID)
+	}
+
+	for _, key := range order {
+		b := batches[key]
+		if len(b.transactionIDs) == 0 {
+			continue
+		}
+		if err := s.payout(key.sellerID, key.currency, b.transactionIDs, b.frozen); err != nil {
+			log.Printf("Failed to pay out seller %d in %s: %v", key.sellerID, key.currency, err)
+		}
+	}
+	return nil
+}
+
+// settleDue settles the sales that should have been settled already
+func (s *LedgerService) settleDue() error {
+	due, err := s.ledgerRepo.FindUnsettled()
+	if err != nil {
+		return err
+	}
+	for i := range due {
+		if err := s.Settle(&due[i]); err != nil {
+			log.Printf("Failed to settle transaction %d in the ledger: %v", due[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// payout pays a seller their balance in a currency, less frozen proceeds,
+// for a batch of sales
+func (s *LedgerService) payout(sellerID uint, currency string, transactionIDs []uint, frozen models.Money) error {
+	accounts, _, err := s.ledgerRepo.FindAccounts(models.AccountSeller, sellerID, 1, 100)
+	if err != nil {
+		return err
+	}
+	balance := models.NewMoney(0, currency)
+	for _, account := range accounts {
+		if account.Currency == currency {
+			balance = account.Balance()
+		}
+	}
+	balance = balance.Sub(frozen)
+	if !balance.IsPositive() {
+		return nil
+	}
+
+	now := time.Now()
+	payout := &models.Payout{
+		Amount:   balance,
+		Status:   models.PayoutPaid,
+		PaidAt:   &now,
+		SellerID: sellerID,
+	}
+	entry := &models.LedgerEntry{
+		Kind:        models.EntryPayout,
+		Description: fmt.Sprintf("Payout of %d sales to seller %d", len(transactionIDs), sellerID),
+		Postings: []models.LedgerPosting{
+			posting(models.AccountSeller, sellerID, negate(balance)),
+			posting(models.AccountPayouts, 0, balance),
+		},
+	}
+	created, err := s.ledgerRepo.CreatePayout(payout, transactionIDs, entry)
+	if err != nil || !created {
+		return err
+	}
+
+	s.notificationService.Notify(sellerID, models.NotificationPayout, "Payout sent",
+		fmt.Sprintf("We sent you %s for %d sales.", balance, len(transactionIDs)), &payout.ID)
+	return nil
+}
+
+// GetBalances returns the seller balances of a user, one per currency
+func (s *LedgerService) GetBalances(userID uint) ([]AccountView, error) {
+	accounts, _, err := s.ledgerRepo.FindAccounts(models.AccountSeller, userID, 1, 100)
+	if err != nil {
+		return nil, err
+	}
+	return toAccountViews(accounts), nil
+}
+
+// GetAccounts lists ledger accounts, optionally of one type
+func (s *LedgerService) GetAccounts(accountType string, page, limit int) ([]AccountView, int64, error) {
+	accounts, total, err := s.ledgerRepo.FindAccounts(accountType, 0, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	return toAccountViews(accounts), total, nil
+}
+
+// GetEntries lists the entries that posted to an account, newest first
+func (s *LedgerService) GetEntries(accountID uint, page, limit int) ([]EntryView, int64, error) {
+	entries, total, err := s.ledgerRepo.FindEntries(accountID, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	views := make([]EntryView, len(entries))
+	for i, entry := range entries {
+		postings := make([]PostingView, len(entry.Postings))
+		for j, p := range entry.Postings {
+			postings[j] = PostingView{AccountID: p.AccountID, AccountType: p.Account.Type, UserID: p.Account.UserID, Amount: p.Amount}
+		}
+		views[i] = EntryView{
+			ID:          entry.ID,
+			Kind:        entry.Kind,
+			Reference:   entry.Reference,
+			Description: entry.Description,
+			Postings:    postings,
+			CreatedAt:   entry.CreatedAt,
+		}
+	}
+	return views, total, nil
+}
+
+// GetPayouts lists payouts, optionally for one seller, newest first
+func (s *LedgerService) GetPayouts(sellerID uint, page, limit int) ([]PayoutView, int64, error) {
+	payouts, total, err := s.ledgerRepo.FindPayouts(sellerID, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	views := make([]PayoutView, len(payouts))
+	for i, payout := range payouts {
+		sales := make([]PayoutTransactionView, len(payout.Transactions))
+		for j := range payout.Transactions {
+			t := &payout.Transactions[j]
+			sales[j] = PayoutTransactionView{
+				TransactionID: t.ID,
+				Listing:       ListingSummary{ID: t.Listing.ID, Title: t.Listing.Title},
+				Proceeds:      t.SellerProceeds(),
+				SettledAt:     t.SettledAt,
+			}
+		}
+		views[i] = PayoutView{
+			ID:        payout.ID,
+			Amount:    payout.Amount,
+			Status:    payout.Status,
+			PaidAt:    payout.PaidAt,
+			CreatedAt: payout.CreatedAt,
+			SellerID:  payout.SellerID,
+			Sales:     sales,
+		}
+	}
+	return views, total, nil
+}
+
+// Reconcile checks that every entry balances, that every account balance
+// is the sum of its postings, that escrow holds exactly the payments for
+// unsettled sales, that every sale due to be settled has been, and that
+// every payment, refund and settlement has been recorded
+func (s *LedgerService) Reconcile() (*ReconciliationReport, error) {
+	report := &ReconciliationReport{CheckedAt: time.Now(), Discrepancies: []Discrepancy{}}
+	add := func(check, format string, args ...interface{}) {
+		report.Discrepancies = append(report.Discrepancies, Discrepancy{Check: check, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	imbalances, err := s.ledgerRepo.FindUnbalancedEntries()
+	if err != nil {
+		return nil, err
+	}
+	for _, imbalance := range imbalances {
+		add("entry_balance", "entry %s is out by %s", imbalance.Reference, models.NewMoney(imbalance.Total, imbalance.Currency))
+	}
+
+	drift, err := s.ledgerRepo.FindBalanceDrift()
+	if err != nil {
+		return nil, err
+	}
+	for _, account := range drift {
+		add("account_balance", "%s account %d (user %d) has balance %s but its postings sum to %s",
+			account.Type, account.AccountID, account.UserID,
+			models.NewMoney(account.BalanceMinor, account.Currency), models.NewMoney(account.PostingMinor, account.Currency))
+	}
+
+	escrow, err := s.ledgerRepo.SumBalances(models.AccountEscrow)
+	if err != nil {
+		return nil, err
+	}
+	held, err := s.ledgerRepo.SumHeldPayments()
+	if err != nil {
+		return nil, err
+	}
+	for currency, amounts := range pairTotals(escrow, held) {
+		if amounts[0] != amounts[1] {
+			add("escrow", "escrow holds %s but unsettled payments total %s",
+				models.NewMoney(amounts[0], currency), models.NewMoney(amounts[1], currency))
+		}
+	}
+
+	unsettled, err := s.ledgerRepo.FindUnsettled()
+	if err != nil {
+		return nil, err
+	}
+	for _, transaction := range unsettled {
+		add("unsettled", "transaction %d is %s but its payment has not been released to the seller",
+			transaction.ID, transaction.Status)
+	}
+
+	missing, err := s.ledgerRepo.FindMissingEntries()
+	if err != nil {
+		return nil, err
+	}
+	for _, reference := range missing {
+		add("missing_entry", "no ledger entry for %s", reference)
+	}
+
+	report.Balanced = len(report.Discrepancies) == 0
+	return report, nil
+}
+
+// CheckReconciliation runs Reconcile for the background job and logs any
+// discrepancy it finds
+func (s *LedgerService) CheckReconciliation() error {
+	report, err := s.Reconcile()
+	if err != nil {
+		return err
+	}
+	for _, discrepancy := range report.Discrepancies {
+		log.Printf("Ledger discrepancy (%s): %s", discrepancy.Check, discrepancy.Detail)
+	}
+	if !report.Balanced {
+		return fmt.Errorf("ledger does not reconcile: %d discrepancies", len(report.Discrepancies))
+	}
+	return nil
+}
+
+// post checks that an entry balances and records it. Postings of zero are
+// left out.
+func (s *LedgerService) post(entry *models.LedgerEntry) error {
+	if err := checkBalanced(entry); err != nil {
+		return err
+	}
+	_, err := s.ledgerRepo.Post(entry)
+	return err
+}
+
+// checkBalanced drops an entry's zero postings and makes sure the rest sum
+// to zero in each currency
+func checkBalanced(entry *models.LedgerEntry) error {
+	totals := make(map[string]int64)
+	postings := entry.Postings[:0]
+	for _, p := range entry.Postings {
+		if p.Amount.IsZero() {
+			continue
+		}
+		totals[p.Amount.Currency] += p.Amount.Minor
+		postings = append(postings, p)
+	}
+	entry.Postings = postings
+
+	for currency, total := range totals {
+		if total != 0 {
+			return fmt.Errorf("ledger entry %s does not balance: out by %s", entry.Reference, models.NewMoney(total, currency))
+		}
+	}
+	return nil
+}
+
+// posting adds amount to a user's account of a type, or to a platform
+// account when userID is 0
+func posting(accountType string, userID uint, amount models.Money) models.LedgerPosting {
+	return models.LedgerPosting{
+		Account: models.LedgerAccount{Type: accountType, UserID: userID},
+		Amount:  amount,
+	}
+}
+
+func negate(amount models.Money) models.Money {
+	return models.NewMoney(-amount.Minor, amount.Currency)
+}
+
+// pairTotals lines up two sets of per-currency totals
+func pairTotals(a, b []repositories.CurrencyTotal) map[string][2]int64 {
+	pairs := make(map[string][2]int64)
+	for _, total := range a {
+		pair := pairs[total.Currency]
+		pair[0] = total.Minor
+		pairs[total.Currency] = pair
+	}
+	for _, total := range b {
+		pair := pairs[total.Currency]
+		pair[1] = total.Minor
+		pairs[total.Currency] = pair
+	}
+	return pairs
+}
+
+func toAccountViews(accounts []models.LedgerAccount) []AccountView {
+	views := make([]AccountView, len(accounts))
+	for i := range accounts {
+		views[i] = AccountView{
+			ID:      accounts[i].ID,
+			Type:    accounts[i].Type,
+			UserID:  accounts[i].UserID,
+			Balance: accounts[i].Balance(),
+		}
+	}
+	return views
+}
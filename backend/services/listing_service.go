@@ -3,40 +3,233 @@ package services
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"log"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/jimsyyap/auctions/backend/models"
 	"github.com/jimsyyap/auctions/backend/repositories"
 )
 
+var (
+	ErrListingNotFound        = errors.New("listing not found")
+	ErrNotListingOwner        = errors.New("you do not have permission to change this listing")
+	ErrVerifiedSellerRequired = errors.New("only verified sellers can list items at this price")
+)
+
 type ListingService struct {
-	listingRepo  *repositories.ListingRepository
-	categoryRepo *repositories.CategoryRepository
+	listingRepo         *repositories.ListingRepository
+	categoryRepo        *repositories.CategoryRepository
+	policyService       *PolicyService
+	moderationService   *ModerationService
+	exchangeRateService *ExchangeRateService
+	ledgerService       *LedgerService
 }
 
-func NewListingService(listingRepo *repositories.ListingRepository, categoryRepo *repositories.CategoryRepository) *ListingService {
+func NewListingService(listingRepo *repositories.ListingRepository, categoryRepo *repositories.CategoryRepository, policyService *PolicyService, moderationService *ModerationService, exchangeRateService *ExchangeRateService, ledgerService *LedgerService) *ListingService {
 	return &ListingService{
-		listingRepo:  listingRepo,
-		categoryRepo: categoryRepo,
+		listingRepo:         listingRepo,
+		categoryRepo:        categoryRepo,
+		policyService:       policyService,
+		moderationService:   moderationService,
+		exchangeRateService: exchangeRateService,
+		ledgerService:       ledgerService,
 	}
 }
 
-// ListingRequest represents data for creating/updating a listing
+// ListingRequest represents data for creating/updating a listing. Prices
+// are decimal strings such as "12.50" so they are never rounded on the way
+// in, and are in Currency, which defaults to the marketplace's currency.
 type ListingRequest struct {
-	Title        string    `json:"title" binding:"required"`
-	Description  string    `json:"description" binding:"required"`
-	StartPrice   float64   `json:"start_price" binding:"required"`
-	ReservePrice float64   `json:"reserve_price"`
-	BuyNowPrice  float64   `json:"buy_now_price"`
-	Duration     int       `json:"duration" binding:"required"` // Duration in days
-	CategoryIDs  []uint    `json:"category_ids" binding:"required"`
+	Title         string `json:"title" binding:"required"`
+	Description   string `json:"description" binding:"required"`
+	Currency      string `json:"currency"`
+	StartPrice    string `json:"start_price" binding:"required"`
+	ReservePrice  string `json:"reserve_price"`
+	BuyNowPrice   string `json:"buy_now_price"`
+	ShippingPrice string `json:"shipping_price"`
+	Duration      int    `json:"duration" binding:"required"` // Duration in days
+	CategoryIDs   []uint `json:"category_ids" binding:"required"`
+}
+
+// listingPrices are the validated prices of a ListingRequest
+type listingPrices struct {
+	start, reserve, buyNow, shipping models.Money
+}
+
+// prices parses and validates the request's prices in a currency.
+// Optional prices that are left out are zero.
+func (req *ListingRequest) prices(currency string) (*listingPrices, error) {
+	parse := func(name, amount string) (models.Money, error) {
+		if amount == "" {
+			return models.NewMoney(0, currency), nil
+		}
+		price, err := models.ParseMoney(amount, currency)
+		if err != nil {
+			return price, fmt.Errorf("invalid %s: %v", name, err)
+		}
+		return price, nil
+	}
+
+	var p listingPrices
+	var err error
+	if p.start, err = parse("start price", req.StartPrice); err != nil {
+		return nil, err
+	}
+	if p.reserve, err = parse("reserve price", req.ReservePrice); err != nil {
+		return nil, err
+	}
+	if p.buyNow, err = parse("buy now price", req.BuyNowPrice); err != nil {
+		return nil, err
+	}
+	if p.shipping, err = parse("shipping price", req.ShippingPrice); err != nil {
+		return nil, err
+	}
+
+	if !p.start.IsPositive() {
+		return nil, errors.New("start price must be greater than zero")
+	}
+
+	if p.reserve.IsPositive() && p.reserve.Cmp(p.start) < 0 {
+		return nil, errors.New("reserve price must be greater than or equal to start price")
+	}
+
+	if p.buyNow.IsPositive() && p.buyNow.Cmp(p.reserve) < 0 {
+		return nil, errors.New("buy now price must be greater than or equal to reserve price")
+	}
+
+	if p.reserve.Minor < 0 || p.buyNow.Minor < 0 {
+		return nil, errors.New("prices cannot be negative")
+	}
+
+	if p.shipping.Minor < 0 {
+		return nil, errors.New("shipping price cannot be negative")
+	}
+
+	return &p, nil
+}
+
+// highest returns the largest of the prices
+func (p *listingPrices) highest() models.Money {
+	highest := p.start
+	for _, price := range []models.Money{p.reserve, p.buyNow} {
+		if price.Cmp(highest) > 0 {
+			highest = price
+		}
+	}
+	return highest
+}
+
+// ListingQuery filters the public listing search. Currency is the one the
+// viewer wants to see prices in; the price bounds are in it too, and match
+// listings in other currencies at the current exchange rate.
+type ListingQuery struct {
+	MinPrice string `form:"min_price"`
+	MaxPrice string `form:"max_price"`
+	Currency string `form:"currency"`
 }
 
-// GetListings retrieves listings with pagination
-func (s *ListingService) GetListings(page, limit int) ([]models.Listing, int64, error) {
-	return s.listingRepo.FindAll(page, limit)
+// ListingView is a listing as shown to buyers. The reserve price stays
+// hidden; buyers only learn whether bidding has reached it. When the buyer
+// asked for another currency, Converted holds its prices in that currency.
+type ListingView struct {
+	ID            uint               `json:"id"`
+	Title         string             `json:"title"`
+	Description   string             `json:"description"`
+	Currency      string             `json:"currency"`
+	StartPrice    models.Money       `json:"start_price"`
+	CurrentPrice  models.Money       `json:"current_price"`
+	BuyNowPrice   *models.Money      `json:"buy_now_price,omitempty"`
+	ShippingPrice models.Money       `json:"shipping_price"`
+	HasReserve    bool               `json:"has_reserve"`
+	ReserveMet    bool               `json:"reserve_met"`
+	Status        string             `json:"status"`
+	EndTime       time.Time          `json:"end_time"`
+	SellerID      uint               `json:"seller_id"`
+	Categories    []CategorySummary  `json:"categories"`
+	Images        []ListingImageView `json:"images"`
+	BidCount      int                `json:"bid_count"`
+	Bids          []BidView          `json:"bids"`
+	CreatedAt     time.Time          `json:"created_at"`
+	Converted     *ConvertedPrices   `json:"converted,omitempty"`
+}
+
+// CategorySummary identifies a category a listing is in
+type CategorySummary struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// ListingImageView is one of a listing's photos
+type ListingImageView struct {
+	ID           uint   `json:"id"`
+	URL          string `json:"url"`
+	Caption      string `json:"caption,omitempty"`
+	IsPrimary    bool   `json:"is_primary"`
+	DisplayOrder int    `json:"display_order"`
+}
+
+// BidView is a bid in a listing's public bid history
+type BidView struct {
+	ID       uint         `json:"id"`
+	Amount   models.Money `json:"amount"`
+	PlacedAt time.Time    `json:"placed_at"`
+	Bidder   UserSummary  `json:"bidder"`
+}
+
+// ConvertedPrices are a listing's prices at the current exchange rate. They
+// are approximate: bids and payments are always in the listing's currency.
+type ConvertedPrices struct {
+	CurrentPrice  models.Money  `json:"current_price"`
+	BuyNowPrice   *models.Money `json:"buy_now_price,omitempty"`
+	ShippingPrice models.Money  `json:"shipping_price"`
+}
+
+// GetListings searches active listings, newest first
+func (s *ListingService) GetListings(query *ListingQuery, page, limit int) ([]ListingView, int64, error) {
+	rates, err := s.exchangeRateService.Rates()
+	if err != nil {
+		return nil, 0, err
+	}
+	currency, err := viewCurrency(query.Currency, rates)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filter, err := priceFilter(query, currency, rates)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	listings, total, err := s.listingRepo.Search(filter, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	views := make([]ListingView, len(listings))
+	for i := range listings {
+		views[i] = toListingView(&listings[i], currency, rates)
+	}
+	return views, total, nil
+}
+
+// ViewListing shows a listing with its prices converted to currency, if one
+// is given
+func (s *ListingService) ViewListing(listing *models.Listing, currency string) (*ListingView, error) {
+	rates, err := s.exchangeRateService.Rates()
+	if err != nil {
+		return nil, err
+	}
+	currency, err = viewCurrency(currency, rates)
+	if err != nil {
+		return nil, err
+	}
+
+	view := toListingView(listing, currency, rates)
+	return &view, nil
 }
 
 // GetListing retrieves a listing by ID
@@ -47,22 +240,24 @@ func (s *ListingService) GetListing(id uint) (*models.Listing, error) {
 // CreateListing creates a new listing
 func (s *ListingService) CreateListing(userID uint, req *ListingRequest) (*models.Listing, error) {
 	// Validate listing data
-	if req.StartPrice <= 0 {
-		return nil, errors.New("start price must be greater than zero")
-	}
-
-	if req.ReservePrice > 0 && req.ReservePrice < req.StartPrice {
-		return nil, errors.New("reserve price must be greater than or equal to start price")
+	currency, err := s.listingCurrency(req.Currency)
+	if err != nil {
+		return nil, err
 	}
 
-	if req.BuyNowPrice > 0 && req.BuyNowPrice < req.ReservePrice {
-		return nil, errors.New("buy now price must be greater than or equal to reserve price")
+	prices, err := req.prices(currency)
+	if err != nil {
+		return nil, err
 	}
 
 	if req.Duration < 1 || req.Duration > 14 {
 		return nil, errors.New("duration must be between 1 and 14 days")
 	}
 
+	if !s.policyService.CanListAtPrice(userID, prices.highest()) {
+		return nil, ErrVerifiedSellerRequired
+	}
+
 	// Fetch categories
 	var categories []models.Category
 	for _, categoryID := range req.CategoryIDs {
@@ -73,14 +268,25 @@ func (s *ListingService) CreateListing(userID uint, req *ListingRequest) (*model
 		categories = append(categories, *category)
 	}
 
-	// Create listing
+	screened, err := s.moderationService.Screen(models.ContentListing, userID, req.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create listing. Held listings go live once a moderator releases them.
+	status := "active"
+	if screened.IsHeld() {
+		status = models.ModerationStatusHeld
+	}
 	listing := &models.Listing{
 		Title:        req.Title,
-		Description:  req.Description,
-		StartPrice:   req.StartPrice,
-		ReservePrice: req.ReservePrice,
-		BuyNowPrice:  req.BuyNowPrice,
-		Status:       "active",
+		Description:  screened.Text,
+		Currency:     currency,
+		StartPrice:   prices.start,
+		ReservePrice: prices.reserve,
+		BuyNowPrice:  prices.buyNow,
+		ShippingPrice: prices.shipping,
+		Status:       status,
 		EndTime:      time.Now().Add(time.Duration(req.Duration) * 24 * time.Hour),
 		UserID:       userID,
 		Categories:   categories,
@@ -89,6 +295,10 @@ func (s *ListingService) CreateListing(userID uint, req *ListingRequest) (*model
 	if err := s.listingRepo.Create(listing); err != nil {
 		return nil, err
 	}
+	s.moderationService.Record(screened, listing.ID)
+	if listing.Status == "active" {
+		s.chargeInsertionFee(listing)
+	}
 
 	return listing, nil
 }
@@ -97,16 +307,16 @@ func (s *ListingService) CreateListing(userID uint, req *ListingRequest) (*model
 func (s *ListingService) UpdateListing(id, userID uint, req *ListingRequest) (*models.Listing, error) {
 	listing, err := s.listingRepo.FindByID(id)
 	if err != nil {
-		return nil, errors.New("listing not found")
+		return nil, ErrListingNotFound
 	}
 
 	// Verify ownership
-	if listing.UserID != userID {
-		return nil, errors.New("you do not have permission to update this listing")
+	if !s.policyService.CanModifyListing(userID, listing) {
+		return nil, ErrNotListingOwner
 	}
 
 	// Verify listing is still active and has no bids
-	if listing.Status != "active" {
+	if listing.Status != "active" && listing.Status != models.ModerationStatusHeld {
 		return nil, errors.New("only active listings can be updated")
 	}
 
@@ -116,24 +326,36 @@ func (s *ListingService) UpdateListing(id, userID uint, req *ListingRequest) (*m
 	}
 
 	// Validate listing data
-	if req.StartPrice <= 0 {
-		return nil, errors.New("start price must be greater than zero")
+	currency, err := s.listingCurrency(req.Currency)
+	if err != nil {
+		return nil, err
 	}
 
-	if req.ReservePrice > 0 && req.ReservePrice < req.StartPrice {
-		return nil, errors.New("reserve price must be greater than or equal to start price")
+	prices, err := req.prices(currency)
+	if err != nil {
+		return nil, err
 	}
 
-	if req.BuyNowPrice > 0 && req.BuyNowPrice < req.ReservePrice {
-		return nil, errors.New("buy now price must be greater than or equal to reserve price")
+	if !s.policyService.CanListAtPrice(listing.UserID, prices.highest()) {
+		return nil, ErrVerifiedSellerRequired
+	}
+
+	screened, err := s.moderationService.Screen(models.ContentListing, userID, req.Description)
+	if err != nil {
+		return nil, err
+	}
+	if screened.IsHeld() {
+		listing.Status = models.ModerationStatusHeld
 	}
 
 	// Update fields
 	listing.Title = req.Title
-	listing.Description = req.Description
-	listing.StartPrice = req.StartPrice
-	listing.ReservePrice = req.ReservePrice
-	listing.BuyNowPrice = req.BuyNowPrice
+	listing.Description = screened.Text
+	listing.Currency = currency
+	listing.StartPrice = prices.start
+	listing.ReservePrice = prices.reserve
+	listing.BuyNowPrice = prices.buyNow
+	listing.ShippingPrice = prices.shipping
 
 	// Only allow duration update if the listing has no bids
 	if req.Duration >= 1 && req.Duration <= 14 {
@@ -158,20 +380,46 @@ func (s *ListingService) UpdateListing(id, userID uint, req *ListingRequest) (*m
 	if err := s.listingRepo.Update(listing); err != nil {
 		return nil, err
 	}
+	s.moderationService.Record(screened, listing.ID)
 
 	return listing, nil
 }
 
+// ReleaseHeld puts a listing held by moderation live, or ends it if its
+// time ran out while it was held
+func (s *ListingService) ReleaseHeld(contentType string, listingID uint) error {
+	listing, err := s.listingRepo.FindByID(listingID)
+	if err != nil {
+		return err
+	}
+	status := "active"
+	if time.Now().After(listing.EndTime) {
+		status = "ended"
+	}
+	if err := s.listingRepo.UpdateStatus(listing.ID, status); err != nil {
+		return err
+	}
+	if status == "active" {
+		s.chargeInsertionFee(listing)
+	}
+	return nil
+}
+
+// RejectHeld keeps a listing held by moderation off the marketplace
+func (s *ListingService) RejectHeld(contentType string, listingID uint) error {
+	return s.listingRepo.UpdateStatus(listingID, models.ModerationStatusRejected)
+}
+
 // DeleteListing deletes a listing
 func (s *ListingService) DeleteListing(id, userID uint) error {
 	listing, err := s.listingRepo.FindByID(id)
 	if err != nil {
-		return errors.New("listing not found")
+		return ErrListingNotFound
 	}
 
 	// Verify ownership
-	if listing.UserID != userID {
-		return errors.New("you do not have permission to delete this listing")
+	if !s.policyService.CanModifyListing(userID, listing) {
+		return ErrNotListingOwner
 	}
 
 	// Verify listing has no bids
@@ -182,7 +430,7 @@ func (s *ListingService) DeleteListing(id, userID uint) error {
 	// Delete any associated images from the filesystem
 	for _, image := range listing.Images {
 		// Get file path
-		imgPath := filepath.Join("uploads", "listings", strconv.FormatUint(uint64(listing.ID), 10), image.Filename)
+		imgPath := filepath.Join("uploads", "listings", strconv.FormatUint(uint64(listing.ID), 10), filepath.Base(image.URL))
 		
 		// Attempt to remove the file
 		err := os.Remove(imgPath)
@@ -196,3 +444,158 @@ func (s *ListingService) DeleteListing(id, userID uint) error {
 	// Delete the listing from the database
 	return s.listingRepo.Delete(id)
 }
+
+// chargeInsertionFee charges the seller for a listing that has gone live.
+// A failure is logged rather than taking the listing down again.
+func (s *ListingService) chargeInsertionFee(listing *models.Listing) {
+	if err := s.ledgerService.ChargeInsertionFee(listing); err != nil {
+		log.Printf("Failed to charge the insertion fee for listing %d: %v", listing.ID, err)
+	}
+}
+
+// listingCurrency returns the currency a listing is priced in. Sellers can
+// only use currencies with an exchange rate, so that their prices can be
+// shown to buyers elsewhere and checked against the marketplace's limits.
+func (s *ListingService) listingCurrency(code string) (string, error) {
+	if code == "" {
+		return models.DefaultCurrency, nil
+	}
+	currency, err := parseCurrency(code)
+	if err != nil {
+		return "", err
+	}
+
+	rates, err := s.exchangeRateService.Rates()
+	if err != nil {
+		return "", err
+	}
+	if !rates.Supports(currency) {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+	}
+	return currency, nil
+}
+
+// viewCurrency returns the currency a buyer asked to see prices in, or ""
+// to show listings in their own currencies
+func viewCurrency(code string, rates ExchangeRates) (string, error) {
+	if code == "" {
+		return "", nil
+	}
+	currency, err := parseCurrency(code)
+	if err != nil {
+		return "", err
+	}
+	if !rates.Supports(currency) {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+	}
+	return currency, nil
+}
+
+// priceFilter turns the query's price bounds into one range per currency
+// with a rate. The stored prices are compared as they are; only the bounds
+// are converted.
+func priceFilter(query *ListingQuery, currency string, rates ExchangeRates) (repositories.ListingFilter, error) {
+	var filter repositories.ListingFilter
+	if query.MinPrice == "" && query.MaxPrice == "" {
+		return filter, nil
+	}
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+
+	var bounds [2]*models.Money
+	for i, amount := range []string{query.MinPrice, query.MaxPrice} {
+		if amount == "" {
+			continue
+		}
+		bound, err := models.ParseMoney(amount, currency)
+		if err != nil {
+			return filter, fmt.Errorf("invalid price filter: %v", err)
+		}
+		bounds[i] = &bound
+	}
+
+	// One range per currency. A currency the bounds cannot be converted to
+	// gets none, so its listings are left out rather than matched by a zero
+	// bound.
+	currencies := []string{models.DefaultCurrency}
+	for code := range rates {
+		if code != models.DefaultCurrency {
+			currencies = append(currencies, code)
+		}
+	}
+	for _, code := range currencies {
+		price := repositories.PriceRange{Currency: code}
+		converted := true
+		if bounds[0] != nil {
+			low, ok := rates.Convert(*bounds[0], code)
+			price.Min, converted = &low.Minor, converted && ok
+		}
+		if bounds[1] != nil {
+			high, ok := rates.Convert(*bounds[1], code)
+			price.Max, converted = &high.Minor, converted && ok
+		}
+		if converted {
+			filter.PriceRanges = append(filter.PriceRanges, price)
+		}
+	}
+	return filter, nil
+}
+
+func toListingView(listing *models.Listing, currency string, rates ExchangeRates) ListingView {
+	view := ListingView{
+		ID:            listing.ID,
+		Title:         listing.Title,
+		Description:   listing.Description,
+		Currency:      listing.Currency,
+		StartPrice:    listing.StartPrice,
+		CurrentPrice:  listing.GetCurrentPrice(),
+		ShippingPrice: listing.ShippingPrice,
+		HasReserve:    listing.ReservePrice.IsPositive(),
+		ReserveMet:    listing.IsReserveReached(),
+		Status:        listing.Status,
+		EndTime:       listing.EndTime,
+		SellerID:      listing.UserID,
+		Categories:    make([]CategorySummary, len(listing.Categories)),
+		Images:        make([]ListingImageView, len(listing.Images)),
+		BidCount:      len(listing.Bids),
+		Bids:          make([]BidView, len(listing.Bids)),
+		CreatedAt:     listing.CreatedAt,
+	}
+	if listing.BuyNowPrice.IsPositive() {
+		buyNow := listing.BuyNowPrice
+		view.BuyNowPrice = &buyNow
+	}
+	for i, c := range listing.Categories {
+		view.Categories[i] = CategorySummary{ID: c.ID, Name: c.Name}
+	}
+	for i, image := range listing.Images {
+		view.Images[i] = ListingImageView{
+			ID:           image.ID,
+			URL:          image.URL,
+			Caption:      image.Caption,
+			IsPrimary:    image.IsPrimary,
+			DisplayOrder: image.DisplayOrder,
+		}
+	}
+	for i := range listing.Bids {
+		bid := &listing.Bids[i]
+		view.Bids[i] = BidView{ID: bid.ID, Amount: bid.Amount, PlacedAt: bid.PlacedAt, Bidder: toUserSummary(&bid.User)}
+	}
+
+	if currency == "" || currency == listing.Currency {
+		return view
+	}
+
+	current, ok := rates.Convert(listing.GetCurrentPrice(), currency)
+	if !ok {
+		return view
+	}
+	shipping, _ := rates.Convert(listing.ShippingPrice, currency)
+	view.Converted = &ConvertedPrices{CurrentPrice: current, ShippingPrice: shipping}
+	if listing.BuyNowPrice.IsPositive() {
+		buyNow, _ := rates.Convert(listing.BuyNowPrice, currency)
+		view.Converted.BuyNowPrice = &buyNow
+	}
+	return view
+}
@@ -0,0 +1,176 @@
+// services/login_throttle_service.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/config"
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/repositories"
+)
+
+// Failures allowed before progressive delays start, and the longest delay
+const (
+	freeLoginAttempts = 2
+	maxLoginDelay     = 30 * time.Second
+)
+
+// ThrottledError is returned when a login attempt arrives too early. The
+// message is deliberately the same for locked and delayed keys.
+type ThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return "too many login attempts, please try again later"
+}
+
+type LoginThrottleService struct {
+	throttleRepo *repositories.LoginThrottleRepository
+	userRepo     *repositories.UserRepository
+	emailService *EmailService
+}
+
+func NewLoginThrottleService(throttleRepo *repositories.LoginThrottleRepository, userRepo *repositories.UserRepository, emailService *EmailService) *LoginThrottleService {
+	return &LoginThrottleService{
+		throttleRepo: throttleRepo,
+		userRepo:     userRepo,
+		emailService: emailService,
+	}
+}
+
+// Check returns a ThrottledError if either the username or the client IP
+// must wait before trying again
+func (s *LoginThrottleService) Check(username, clientIP string) error {
+	now := time.Now()
+	var wait time.Duration
+
+	for _, key := range [][2]string{
+		{models.ThrottleKindUsername, username},
+		{models.ThrottleKindIP, clientIP},
+	} {
+		throttle, err := s.throttleRepo.Find(key[0], key[1])
+		if err != nil {
+			return err
+		}
+		if throttle.IsLocked(now) {
+			wait = maxDuration(wait, throttle.LockedUntil.Sub(now))
+		}
+		if throttle.NextAttemptAt != nil && now.Before(*throttle.NextAttemptAt) {
+			wait = maxDuration(wait, throttle.NextAttemptAt.Sub(now))
+		}
+	}
+
+	if wait > 0 {
+		return &ThrottledError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// RecordFailure counts a failed attempt against the username and client IP,
+// applying progressive delays and locking out keys that exceed the limit
+func (s *LoginThrottleService) RecordFailure(username, clientIP string) error {
+	policy := config.GetSecurityConfig()
+
+	locked := false
+	userThrottle, err := s.throttleRepo.Modify(models.ThrottleKindUsername, username, func(t *models.LoginThrottle) {
+		locked = s.applyFailure(t, policy.MaxFailedLoginsPerUser, policy)
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.throttleRepo.Modify(models.ThrottleKindIP, clientIP, func(t *models.LoginThrottle) {
+		s.applyFailure(t, policy.MaxFailedLoginsPerIP, policy)
+	}); err != nil {
+		return err
+	}
+
+	// Tell the account owner when their account gets locked
+	if locked {
+		s.notifyLockout(username, *userThrottle.LockedUntil)
+	}
+
+	return nil
+}
+
+// RecordSuccess clears the failure history of a username. The IP history is
+// kept, so one valid account cannot be used to reset an attacker's IP.
+func (s *LoginThrottleService) RecordSuccess(username string) error {
+	return s.throttleRepo.Reset(models.ThrottleKindUsername, username)
+}
+
+// ListLockouts returns keys that are currently locked out
+func (s *LoginThrottleService) ListLockouts(page, limit int) ([]models.LoginThrottle, int64, error) {
+	return s.throttleRepo.FindLocked(time.Now(), page, limit)
+}
+
+// ClearLockout removes a lockout and its failure history
+func (s *LoginThrottleService) ClearLockout(id uint) error {
+	if _, err := s.throttleRepo.FindByID(id); err != nil {
+		return errors.New("lockout not found")
+	}
+	return s.throttleRepo.Delete(id)
+}
+
+// applyFailure updates a throttle record for one failed attempt and reports
+// whether the attempt caused a lockout
+func (s *LoginThrottleService) applyFailure(t *models.LoginThrottle, maxFailures int, policy *config.SecurityConfig) bool {
+	now := time.Now()
+
+	// Failures outside the window no longer count
+	if t.LastFailureAt != nil && now.Sub(*t.LastFailureAt) > policy.LoginFailureWindow {
+		t.Failures = 0
+	}
+
+	t.Failures++
+	t.LastFailureAt = &now
+
+	if t.Failures >= maxFailures {
+		lockedUntil := now.Add(policy.LoginLockoutDuration)
+		t.LockedUntil = &lockedUntil
+		t.NextAttemptAt = nil
+		return true
+	}
+
+	if delay := loginDelay(t.Failures); delay > 0 {
+		next := now.Add(delay)
+		t.NextAttemptAt = &next
+	}
+	return false
+}
+
+// notifyLockout emails the owner of the username, if such an account exists
+func (s *LoginThrottleService) notifyLockout(username string, lockedUntil time.Time) {
+	user, err := s.userRepo.FindByUsername(username)
+	if err != nil {
+		return
+	}
+
+	s.emailService.SendAsync(user.Email, "Your account has been temporarily locked",
+		fmt.Sprintf("Hi %s,\n\nWe locked your account after several failed sign-in attempts. "+
+			"You can try again after %s.\n\nIf this wasn't you, we recommend resetting your password.",
+			user.Username, lockedUntil.Format(time.RFC1123)))
+}
+
+// loginDelay returns the wait imposed after the given number of failures,
+// doubling from one second once the free attempts are used up
+func loginDelay(failures int) time.Duration {
+	if failures <= freeLoginAttempts {
+		return 0
+	}
+	delay := time.Second << uint(failures-freeLoginAttempts-1)
+	if delay > maxLoginDelay || delay <= 0 {
+		return maxLoginDelay
+	}
+	return delay
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,495 @@
+// services/message_service.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"strings"
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/repositories"
+)
+
+var (
+	ErrThreadNotFound     = errors.New("conversation not found")
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrThreadBlocked      = errors.New("this conversation has been blocked")
+)
+
+// Maximum number of images sent with one message
+const maxMessageAttachments = 5
+
+// MessageService handles private conversations between a listing's seller
+// and prospective or actual buyers
+type MessageService struct {
+	messageRepo         *repositories.MessageRepository
+	listingRepo         *repositories.ListingRepository
+	bidRepo             *repositories.BidRepository
+	transactionRepo     *repositories.TransactionRepository
+	notificationService *NotificationService
+	moderationService   *ModerationService
+	attachmentImages    *ImageService
+}
+
+// NewMessageService creates the service. attachmentImages must save to
+// private storage: attachments are only served to the participants.
+func NewMessageService(messageRepo *repositories.MessageRepository, listingRepo *repositories.ListingRepository, bidRepo *repositories.BidRepository, transactionRepo *repositories.TransactionRepository, notificationService *NotificationService, moderationService *ModerationService, attachmentImages *ImageService) *MessageService {
+	return &MessageService{
+		messageRepo:         messageRepo,
+		listingRepo:         listingRepo,
+		bidRepo:             bidRepo,
+		transactionRepo:     transactionRepo,
+		notificationService: notificationService,
+		moderationService:   moderationService,
+		attachmentImages:    attachmentImages,
+	}
+}
+
+// MessageRequest represents a message sent as JSON or multipart form data;
+// images are uploaded as "attachments" files
+type MessageRequest struct {
+	Body string `json:"body" form:"body" binding:"max=5000"`
+}
+
+// StartThreadRequest represents the first message about a listing. Sellers
+// name the bidder they are writing to.
+type StartThreadRequest struct {
+	MessageRequest
+	UserID uint `json:"user_id" form:"user_id"`
+}
+
+// ReportThreadRequest represents a report of a conversation to the admins
+type ReportThreadRequest struct {
+	Reason string `json:"reason" binding:"required,max=2000"`
+}
+
+// ThreadView is a conversation as shown in a participant's inbox
+type ThreadView struct {
+	ID            uint           `json:"id"`
+	Listing       ListingSummary `json:"listing"`
+	With          UserSummary    `json:"with"`
+	Role          string         `json:"role"` // the viewer's role: seller or buyer
+	LastMessageAt time.Time      `json:"last_message_at"`
+	UnreadCount   int64          `json:"unread_count"`
+	Blocked       bool           `json:"blocked"`
+	BlockedByYou  bool           `json:"blocked_by_you"`
+}
+
+// ReportedThreadView is a reported conversation as shown to admins
+type ReportedThreadView struct {
+	ID           uint           `json:"id"`
+	Listing      ListingSummary `json:"listing"`
+	Seller       UserSummary    `json:"seller"`
+	Buyer        UserSummary    `json:"buyer"`
+	ReportedByID uint           `json:"reported_by_id"`
+	ReportedAt   time.Time      `json:"reported_at"`
+	ReportReason string         `json:"report_reason"`
+	Blocked      bool           `json:"blocked"`
+}
+
+// MessageView is one message in a thread
+type MessageView struct {
+	ID               uint             `json:"id"`
+	SenderID         uint             `json:"sender_id"`
+	Body             string           `json:"body"`
+	Attachments      []AttachmentView `json:"attachments"`
+	ModerationStatus string           `json:"moderation_status,omitempty"`
+	ReadAt           *time.Time       `json:"read_at,omitempty"`
+	CreatedAt        time.Time        `json:"created_at"`
+}
+
+// AttachmentView points at an attachment's download route
+type AttachmentView struct {
+	ID  uint   `json:"id"`
+	URL string `json:"url"`
+}
+
+// StartThread sends the first message about a listing, or adds to the
+// existing conversation. Buyers write to the seller; sellers can write to
+// users who bid on or bought the listing.
+func (s *MessageService) StartThread(userID, listingID uint, req *StartThreadRequest, files []*multipart.FileHeader) (*ThreadView, *MessageView, error) {
+	listing, err := s.listingRepo.FindByID(listingID)
+	if err != nil {
+		return nil, nil, errors.New("listing not found")
+	}
+
+	buyerID := userID
+	if userID == listing.UserID {
+		if req.UserID == 0 || req.UserID == userID {
+			return nil, nil, errors.New("choose a bidder to write to")
+		}
+		hasBid, err := s.bidRepo.HasBid(listing.ID, req.UserID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !hasBid && !s.isBuyer(listing.ID, req.UserID) {
+			return nil, nil, errors.New("sellers can only start conversations with users who bid on or bought the listing")
+		}
+		buyerID = req.UserID
+	}
+
+	thread, err := s.messageRepo.FindOrCreateThread(listing.ID, listing.UserID, buyerID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	message, err := s.send(userID, thread, &req.MessageRequest, files)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	view := toThreadView(userID, thread, 0)
+	return &view, message, nil
+}
+
+// SendMessage adds a message to a thread the user takes part in
+func (s *MessageService) SendMessage(userID, threadID uint, req *MessageRequest, files []*multipart.FileHeader) (*MessageView, error) {
+	thread, err := s.participantThread(userID, threadID)
+	if err != nil {
+		return nil, err
+	}
+	return s.send(userID, thread, req, files)
+}
+
+// GetThreads lists a user's conversations with their unread counts
+func (s *MessageService) GetThreads(userID uint, page, limit int) ([]ThreadView, int64, error) {
+	threads, total, err := s.messageRepo.FindThreadsByUser(userID, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]uint, len(threads))
+	for i := range threads {
+		ids[i] = threads[i].ID
+	}
+	unread := map[uint]int64{}
+	if len(ids) > 0 {
+		if unread, err = s.messageRepo.CountUnread(userID, ids); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	views := make([]ThreadView, len(threads))
+	for i := range threads {
+		views[i] = toThreadView(userID, &threads[i], unread[threads[i].ID])
+	}
+	return views, total, nil
+}
+
+// GetUnreadCount returns the number of unread messages across all threads
+func (s *MessageService) GetUnreadCount(userID uint) (int64, error) {
+	return s.messageRepo.CountAllUnread(userID)
+}
+
+// GetMessages lists a thread's messages, newest first, and marks the ones
+// the user received as read
+func (s *MessageService) GetMessages(userID, threadID uint, page, limit int) ([]MessageView, int64, error) {
+	thread, err := s.participantThread(userID, threadID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	messages, total, err := s.messageRepo.FindMessages(thread.ID, userID, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := s.messageRepo.MarkRead(thread.ID, userID); err != nil {
+		return nil, 0, err
+	}
+
+	return toMessageViews(threadURL(thread.ID), messages), total, nil
+}
+
+// Block stops both participants from sending further messages
+func (s *MessageService) Block(userID, threadID uint) error {
+	thread, err := s.participantThread(userID, threadID)
+	if err != nil {
+		return err
+	}
+	if thread.BlockedByID != nil {
+		return nil
+	}
+
+	now := time.Now()
+	thread.BlockedByID = &userID
+	thread.BlockedAt = &now
+	return s.messageRepo.UpdateThread(thread)
+}
+
+// Unblock lifts a block. Only the participant who blocked can lift it.
+func (s *MessageService) Unblock(userID, threadID uint) error {
+	thread, err := s.participantThread(userID, threadID)
+	if err != nil {
+		return err
+	}
+	if thread.BlockedByID == nil {
+		return nil
+	}
+	if *thread.BlockedByID != userID {
+		return errors.New("only the participant who blocked this conversation can unblock it")
+	}
+
+	thread.BlockedByID = nil
+	thread.BlockedAt = nil
+	return s.messageRepo.UpdateThread(thread)
+}
+
+// Report flags a thread for review by the admins
+func (s *MessageService) Report(userID, threadID uint, req *ReportThreadRequest) error {
+	thread, err := s.participantThread(userID, threadID)
+	if err != nil {
+		return err
+	}
+	if thread.ReportedAt != nil {
+		return errors.New("this conversation has already been reported")
+	}
+
+	now := time.Now()
+	thread.ReportedByID = &userID
+	thread.ReportedAt = &now
+	thread.ReportReason = strings.TrimSpace(req.Reason)
+	return s.messageRepo.UpdateThread(thread)
+}
+
+// OpenAttachment streams an attachment to a participant of its thread
+func (s *MessageService) OpenAttachment(userID, threadID, attachmentID uint) (io.ReadCloser, string, error) {
+	if _, err := s.participantThread(userID, threadID); err != nil {
+		return nil, "", err
+	}
+	return s.openAttachment(threadID, attachmentID)
+}
+
+// GetReportedThreads lists reported threads for admin review
+func (s *MessageService) GetReportedThreads(page, limit int) ([]ReportedThreadView, int64, error) {
+	threads, total, err := s.messageRepo.FindReportedThreads(page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	views := make([]ReportedThreadView, len(threads))
+	for i := range threads {
+		views[i] = toReportedThreadView(&threads[i])
+	}
+	return views, total, nil
+}
+
+// GetReportedThread returns a reported thread and its messages for admin
+// review. Messages are not marked as read. Threads nobody reported stay
+// private to their participants.
+func (s *MessageService) GetReportedThread(threadID uint, page, limit int) (*ReportedThreadView, []MessageView, int64, error) {
+	thread, err := s.reportedThread(threadID)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	messages, total, err := s.messageRepo.FindMessages(thread.ID, 0, page, limit)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	view := toReportedThreadView(thread)
+	return &view, toMessageViews(fmt.Sprintf("/api/admin/threads/%d", thread.ID), messages), total, nil
+}
+
+// OpenReportedAttachment streams an attachment of a reported thread to an admin
+func (s *MessageService) OpenReportedAttachment(threadID, attachmentID uint) (io.ReadCloser, string, error) {
+	if _, err := s.reportedThread(threadID); err != nil {
+		return nil, "", err
+	}
+	return s.openAttachment(threadID, attachmentID)
+}
+
+// send stores a message from one participant to the other and notifies
+// the recipient
+func (s *MessageService) send(senderID uint, thread *models.MessageThread, req *MessageRequest, files []*multipart.FileHeader) (*MessageView, error) {
+	if thread.BlockedByID != nil {
+		return nil, ErrThreadBlocked
+	}
+
+	body := strings.TrimSpace(req.Body)
+	if body == "" && len(files) == 0 {
+		return nil, errors.New("a message needs text or an attachment")
+	}
+	if len(files) > maxMessageAttachments {
+		return nil, fmt.Errorf("a message can have at most %d attachments", maxMessageAttachments)
+	}
+
+	screened, err := s.moderationService.Screen(models.ContentMessage, senderID, body)
+	if err != nil {
+		return nil, err
+	}
+
+	message := &models.Message{
+		Body:             screened.Text,
+		ModerationStatus: screened.Status(),
+		ThreadID:         thread.ID,
+		SenderID:         senderID,
+		RecipientID:      thread.OtherParticipant(senderID),
+	}
+
+	prefix := fmt.Sprintf("messages/%d", thread.ID)
+	for _, file := range files {
+		image, err := s.attachmentImages.Store(prefix, file)
+		if err != nil {
+			s.deleteAttachments(message.Attachments)
+			return nil, err
+		}
+		message.Attachments = append(message.Attachments, models.MessageAttachment{StorageKey: image.Key})
+	}
+
+	if err := s.messageRepo.CreateMessage(message); err != nil {
+		s.deleteAttachments(message.Attachments)
+		return nil, err
+	}
+	s.moderationService.Record(screened, message.ID)
+
+	// Held messages are announced once a moderator releases them
+	if !screened.IsHeld() {
+		sender := &thread.Buyer
+		if senderID == thread.SellerID {
+			sender = &thread.Seller
+		}
+		s.notifyRecipient(message, sender, thread)
+	}
+
+	view := toMessageView(threadURL(thread.ID), message)
+	return &view, nil
+}
+
+// ReleaseHeld shows a held message to its recipient
+func (s *MessageService) ReleaseHeld(contentType string, messageID uint) error {
+	message, err := s.messageRepo.FindMessageByID(messageID)
+	if err != nil {
+		return err
+	}
+	if err := s.messageRepo.SetModerationStatus(message.ID, ""); err != nil {
+		return err
+	}
+	s.notifyRecipient(message, &message.Sender, &message.Thread)
+	return nil
+}
+
+// RejectHeld keeps a held message from ever reaching its recipient
+func (s *MessageService) RejectHeld(contentType string, messageID uint) error {
+	return s.messageRepo.SetModerationStatus(messageID, models.ModerationStatusRejected)
+}
+
+func (s *MessageService) notifyRecipient(message *models.Message, sender *models.User, thread *models.MessageThread) {
+	s.notificationService.Notify(message.RecipientID, models.NotificationMessage,
+		fmt.Sprintf("New message from %s", sender.Username),
+		fmt.Sprintf("%s sent you a message about \"%s\".", sender.Username, thread.Listing.Title),
+		&thread.ID)
+}
+
+// deleteAttachments removes stored images of a message that was not saved
+func (s *MessageService) deleteAttachments(attachments []models.MessageAttachment) {
+	for _, attachment := range attachments {
+		if err := s.attachmentImages.Delete(attachment.StorageKey); err != nil {
+			log.Printf("Failed to delete message attachment %s: %v", attachment.StorageKey, err)
+		}
+	}
+}
+
+// isBuyer reports whether a user bought a listing, at auction or with Buy Now
+func (s *MessageService) isBuyer(listingID, userID uint) bool {
+	transaction, err := s.transactionRepo.FindByListing(listingID)
+	return err == nil && transaction.BuyerID == userID
+}
+
+func (s *MessageService) participantThread(userID, threadID uint) (*models.MessageThread, error) {
+	thread, err := s.messageRepo.FindThreadByID(threadID)
+	if err != nil || !thread.HasParticipant(userID) {
+		return nil, ErrThreadNotFound
+	}
+	return thread, nil
+}
+
+func (s *MessageService) reportedThread(threadID uint) (*models.MessageThread, error) {
+	thread, err := s.messageRepo.FindThreadByID(threadID)
+	if err != nil || thread.ReportedAt == nil {
+		return nil, ErrThreadNotFound
+	}
+	return thread, nil
+}
+
+func (s *MessageService) openAttachment(threadID, attachmentID uint) (io.ReadCloser, string, error) {
+	attachment, err := s.messageRepo.FindAttachment(threadID, attachmentID)
+	if err != nil {
+		return nil, "", ErrAttachmentNotFound
+	}
+	return s.attachmentImages.Open(attachment.StorageKey)
+}
+
+func threadURL(threadID uint) string {
+	return fmt.Sprintf("/api/threads/%d", threadID)
+}
+
+func toThreadView(userID uint, t *models.MessageThread, unread int64) ThreadView {
+	view := ThreadView{
+		ID:            t.ID,
+		Listing:       ListingSummary{ID: t.Listing.ID, Title: t.Listing.Title},
+		Role:          "buyer",
+		With:          toUserSummary(&t.Seller),
+		LastMessageAt: t.LastMessageAt,
+		UnreadCount:   unread,
+		Blocked:       t.BlockedByID != nil,
+		BlockedByYou:  t.BlockedByID != nil && *t.BlockedByID == userID,
+	}
+	if userID == t.SellerID {
+		view.Role = "seller"
+		view.With = toUserSummary(&t.Buyer)
+	}
+	return view
+}
+
+func toReportedThreadView(t *models.MessageThread) ReportedThreadView {
+	view := ReportedThreadView{
+		ID:           t.ID,
+		Listing:      ListingSummary{ID: t.Listing.ID, Title: t.Listing.Title},
+		Seller:       toUserSummary(&t.Seller),
+		Buyer:        toUserSummary(&t.Buyer),
+		ReportReason: t.ReportReason,
+		Blocked:      t.BlockedByID != nil,
+	}
+	if t.ReportedByID != nil {
+		view.ReportedByID = *t.ReportedByID
+	}
+	if t.ReportedAt != nil {
+		view.ReportedAt = *t.ReportedAt
+	}
+	return view
+}
+
+// toMessageViews converts messages for display. Attachment URLs are built
+// under threadURL, which differs for participants and admins.
+func toMessageViews(threadURL string, messages []models.Message) []MessageView {
+	views := make([]MessageView, len(messages))
+	for i := range messages {
+		views[i] = toMessageView(threadURL, &messages[i])
+	}
+	return views
+}
+
+func toMessageView(threadURL string, m *models.Message) MessageView {
+	view := MessageView{
+		ID:               m.ID,
+		SenderID:         m.SenderID,
+		Body:             m.Body,
+		Attachments:      make([]AttachmentView, len(m.Attachments)),
+		ModerationStatus: m.ModerationStatus,
+		ReadAt:           m.ReadAt,
+		CreatedAt:        m.CreatedAt,
+	}
+	for i, a := range m.Attachments {
+		view.Attachments[i] = AttachmentView{
+			ID:  a.ID,
+			URL: fmt.Sprintf("%s/attachments/%d", threadURL, a.ID),
+		}
+	}
+	return view
+}
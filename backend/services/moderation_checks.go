@@ -0,0 +1,134 @@
+// services/moderation_checks.go
+package services
+
+import (
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/jimsyyap/auctions/backend/config"
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/repositories"
+)
+
+// ModerationCheck is one step of the moderation pipeline. Find returns the
+// parts of the text the check objects to, exactly as they appear, so they
+// can be masked. Checks are added with ModerationService.RegisterCheck.
+type ModerationCheck interface {
+	Name() string
+	Find(text string) []string
+}
+
+// bannedWordsCheck looks for the words and phrases admins have banned
+type bannedWordsCheck struct {
+	moderationRepo *repositories.ModerationRepository
+}
+
+func (c *bannedWordsCheck) Name() string {
+	return models.CheckBannedWords
+}
+
+func (c *bannedWordsCheck) Find(text string) []string {
+	words, err := c.moderationRepo.FindBannedWords()
+	if err != nil {
+		log.Printf("Failed to load banned words: %v", err)
+		return nil
+	}
+
+	var matches []string
+	for _, word := range words {
+		pattern, err := regexp.Compile(`(?i)\b` + regexp.QuoteMeta(word.Word) + `\b`)
+		if err != nil {
+			continue
+		}
+		matches = append(matches, pattern.FindAllString(text, -1)...)
+	}
+	return matches
+}
+
+var (
+	emailPattern = regexp.MustCompile(`(?i)[a-z0-9._%+-]+\s*(?:@|\(at\)|\[at\])\s*[a-z0-9-]+(?:\.[a-z0-9-]+)*\.[a-z]{2,}`)
+	phonePattern = regexp.MustCompile(`\+?\(?\d[\d\s().-]{7,}\d`)
+)
+
+// contactDetailsCheck looks for email addresses and phone numbers, which
+// are used to take deals off the platform
+type contactDetailsCheck struct{}
+
+func (c *contactDetailsCheck) Name() string {
+	return models.CheckContactDetails
+}
+
+func (c *contactDetailsCheck) Find(text string) []string {
+	matches := emailPattern.FindAllString(text, -1)
+	for _, candidate := range phonePattern.FindAllString(text, -1) {
+		// Phone numbers have 9 to 15 digits; shorter runs are prices,
+		// years and the like
+		digits := 0
+		for _, r := range candidate {
+			if r >= '0' && r <= '9' {
+				digits++
+			}
+		}
+		if digits >= 9 && digits <= 15 {
+			matches = append(matches, strings.TrimSpace(candidate))
+		}
+	}
+	return matches
+}
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:(?:https?|ftp)://|www\.)[^\s<>"']+|\b[a-z0-9-]+(?:\.[a-z0-9-]+)*\.(?:com|net|org|info|biz|io|co|me|ly|xyz|top|ru|cn|tk|gl|gd|to)\b(?:/[^\s<>"']*)?`)
+
+// suspiciousLinksCheck looks for links to sites other than our own and
+// the domains admins allow
+type suspiciousLinksCheck struct {
+	allowedDomains []string
+}
+
+func newSuspiciousLinksCheck() *suspiciousLinksCheck {
+	allowed := config.GetModerationConfig().AllowedLinkDomains
+	if frontend, err := url.Parse(config.GetAppConfig().FrontendURL); err == nil && frontend.Hostname() != "" {
+		allowed = append(allowed, strings.ToLower(frontend.Hostname()))
+	}
+	return &suspiciousLinksCheck{allowedDomains: allowed}
+}
+
+func (c *suspiciousLinksCheck) Name() string {
+	return models.CheckSuspiciousLinks
+}
+
+func (c *suspiciousLinksCheck) Find(text string) []string {
+	var matches []string
+	for _, loc := range linkPattern.FindAllStringIndex(text, -1) {
+		// The domain of an email address is the contact details check's job
+		if loc[0] > 0 && text[loc[0]-1] == '@' {
+			continue
+		}
+		link := strings.TrimRight(text[loc[0]:loc[1]], ".,;:!?)")
+		if !c.isAllowed(link) {
+			matches = append(matches, link)
+		}
+	}
+	return matches
+}
+
+// isAllowed reports whether a link points at an allowed domain or one of
+// its subdomains
+func (c *suspiciousLinksCheck) isAllowed(link string) bool {
+	if !strings.Contains(link, "://") {
+		link = "http://" + link
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range c.allowedDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
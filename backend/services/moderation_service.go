@@ -0,0 +1,323 @@
+// services/moderation_service.go
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/repositories"
+)
+
+var (
+	ErrContentRejected  = errors.New("this content breaks the marketplace rules and was not posted")
+	ErrDecisionNotFound = errors.New("moderation decision not found")
+)
+
+// Severity of each action; the most severe matching rule decides
+var moderationSeverity = map[string]int{
+	models.ModerationAllow:  0,
+	models.ModerationMask:   1,
+	models.ModerationHold:   2,
+	models.ModerationReject: 3,
+}
+
+// ModerationTarget is implemented by services whose content can be held.
+// The moderation service calls it when an admin reviews held content.
+type ModerationTarget interface {
+	ReleaseHeld(contentType string, contentID uint) error
+	RejectHeld(contentType string, contentID uint) error
+}
+
+// ModerationService screens user-written text before it is stored. Each
+// registered check looks for problems; admin-configured rules decide
+// whether a match is masked, the content held for review or rejected.
+// Every decision is written to the audit log.
+type ModerationService struct {
+	moderationRepo *repositories.ModerationRepository
+	checks         []ModerationCheck
+	targets        map[string]ModerationTarget
+}
+
+func NewModerationService(moderationRepo *repositories.ModerationRepository) *ModerationService {
+	s := &ModerationService{
+		moderationRepo: moderationRepo,
+		targets:        map[string]ModerationTarget{},
+	}
+	s.RegisterCheck(&bannedWordsCheck{moderationRepo: moderationRepo})
+	s.RegisterCheck(&contactDetailsCheck{})
+	s.RegisterCheck(newSuspiciousLinksCheck())
+	return s
+}
+
+// RegisterCheck adds a check to the pipeline. Matches only have an effect
+// once a rule for the check's name exists.
+func (s *ModerationService) RegisterCheck(check ModerationCheck) {
+	s.checks = append(s.checks, check)
+}
+
+// RegisterTarget sets the service that releases or removes held content
+// of a type
+func (s *ModerationService) RegisterTarget(contentType string, target ModerationTarget) {
+	s.targets[contentType] = target
+}
+
+// ModerationFinding is what one check matched and the action its rule took
+type ModerationFinding struct {
+	Check   string   `json:"check"`
+	Action  string   `json:"action"`
+	Matches []string `json:"matches"`
+}
+
+// ModerationResult is the outcome of screening a text. Text is what should
+// be stored, with masked parts replaced.
+type ModerationResult struct {
+	Action   string
+	Text     string
+	Findings []ModerationFinding
+
+	contentType string
+	authorID    uint
+	original    string
+}
+
+// IsHeld reports whether the content must be hidden until reviewed
+func (r *ModerationResult) IsHeld() bool {
+	return r.Action == models.ModerationHold
+}
+
+// Status is the moderation status the content should be stored with
+func (r *ModerationResult) Status() string {
+	if r.IsHeld() {
+		return models.ModerationStatusHeld
+	}
+	return ""
+}
+
+// RuleRequest represents a change to a moderation rule
+type RuleRequest struct {
+	ContentType string `json:"content_type" binding:"required,oneof=listing message question answer"`
+	Check       string `json:"check" binding:"required"`
+	Action      string `json:"action" binding:"required,oneof=mask hold reject"`
+	Enabled     *bool  `json:"enabled"`
+}
+
+// BannedWordRequest represents a word or phrase to ban
+type BannedWordRequest struct {
+	Word string `json:"word" binding:"required,max=100"`
+}
+
+// ReviewRequest represents an admin's review of held content
+type ReviewRequest struct {
+	Approve bool `json:"approve"`
+}
+
+// Screen runs a text through the pipeline. Rejected text is logged here and
+// returned as ErrContentRejected; otherwise the caller stores result.Text
+// and then calls Record with the new content's ID.
+func (s *ModerationService) Screen(contentType string, authorID uint, text string) (*ModerationResult, error) {
+	result := &ModerationResult{
+		Action:      models.ModerationAllow,
+		Text:        text,
+		contentType: contentType,
+		authorID:    authorID,
+		original:    text,
+	}
+	if strings.TrimSpace(text) == "" {
+		return result, nil
+	}
+
+	rules, err := s.rulesFor(contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, check := range s.checks {
+		rule, ok := rules[check.Name()]
+		if !ok {
+			continue
+		}
+		matches := check.Find(text)
+		if len(matches) == 0 {
+			continue
+		}
+
+		result.Findings = append(result.Findings, ModerationFinding{Check: check.Name(), Action: rule.Action, Matches: matches})
+		if moderationSeverity[rule.Action] > moderationSeverity[result.Action] {
+			result.Action = rule.Action
+		}
+		if rule.Action == models.ModerationMask {
+			for _, match := range matches {
+				result.Text = strings.ReplaceAll(result.Text, match, strings.Repeat("*", len([]rune(match))))
+			}
+		}
+	}
+
+	if result.Action == models.ModerationReject {
+		s.Record(result, 0)
+		checks := make([]string, len(result.Findings))
+		for i, finding := range result.Findings {
+			checks[i] = strings.ReplaceAll(finding.Check, "_", " ")
+		}
+		return nil, fmt.Errorf("%w (%s)", ErrContentRejected, strings.Join(checks, ", "))
+	}
+	return result, nil
+}
+
+// Record writes a screening decision to the audit log. Failures are logged
+// rather than returned: the content has already been stored by then.
+func (s *ModerationService) Record(result *ModerationResult, contentID uint) {
+	findings, _ := json.Marshal(result.Findings)
+	decision := &models.ModerationDecision{
+		ContentType: result.contentType,
+		ContentID:   contentID,
+		Action:      result.Action,
+		Findings:    string(findings),
+		Content:     result.original,
+		AuthorID:    result.authorID,
+	}
+	if err := s.moderationRepo.CreateDecision(decision); err != nil {
+		log.Printf("Failed to record moderation decision for %s %d: %v", result.contentType, contentID, err)
+	}
+}
+
+// GetRules lists the moderation rules
+func (s *ModerationService) GetRules() ([]models.ModerationRule, error) {
+	return s.moderationRepo.FindRules()
+}
+
+// UpdateRule creates or changes the rule for a content type and check
+func (s *ModerationService) UpdateRule(req *RuleRequest) (*models.ModerationRule, error) {
+	known := false
+	for _, check := range s.checks {
+		if check.Name() == req.Check {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return nil, fmt.Errorf("unknown moderation check %q", req.Check)
+	}
+
+	rule := &models.ModerationRule{
+		ContentType: req.ContentType,
+		Check:       req.Check,
+		Action:      req.Action,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+	}
+	if err := s.moderationRepo.SaveRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// GetBannedWords lists the banned words and phrases
+func (s *ModerationService) GetBannedWords() ([]models.BannedWord, error) {
+	return s.moderationRepo.FindBannedWords()
+}
+
+// AddBannedWord bans a word or phrase
+func (s *ModerationService) AddBannedWord(req *BannedWordRequest) (*models.BannedWord, error) {
+	word := strings.ToLower(strings.Join(strings.Fields(req.Word), " "))
+	if word == "" {
+		return nil, errors.New("word cannot be empty")
+	}
+
+	banned := &models.BannedWord{Word: word}
+	if err := s.moderationRepo.CreateBannedWord(banned); err != nil {
+		return nil, errors.New("this word is already banned")
+	}
+	return banned, nil
+}
+
+// RemoveBannedWord lifts a ban
+func (s *ModerationService) RemoveBannedWord(id uint) error {
+	found, err := s.moderationRepo.DeleteBannedWord(id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New("banned word not found")
+	}
+	return nil
+}
+
+// GetDecisions lists the moderation audit log. pendingOnly limits it to
+// held content awaiting review.
+func (s *ModerationService) GetDecisions(contentType, action string, pendingOnly bool, page, limit int) ([]models.ModerationDecision, int64, error) {
+	filter := repositories.DecisionFilter{ContentType: contentType, Action: action, PendingOnly: pendingOnly}
+	return s.moderationRepo.FindDecisions(filter, page, limit)
+}
+
+// GetDecision returns one entry of the audit log
+func (s *ModerationService) GetDecision(id uint) (*models.ModerationDecision, error) {
+	decision, err := s.moderationRepo.FindDecisionByID(id)
+	if err != nil {
+		return nil, ErrDecisionNotFound
+	}
+	return decision, nil
+}
+
+// Review approves or rejects held content. Approved content becomes
+// visible; rejected content stays hidden.
+func (s *ModerationService) Review(reviewerID, id uint, req *ReviewRequest) (*models.ModerationDecision, error) {
+	decision, err := s.GetDecision(id)
+	if err != nil {
+		return nil, err
+	}
+	if !decision.IsPendingReview() {
+		return nil, errors.New("this decision is not awaiting review")
+	}
+
+	target, ok := s.targets[decision.ContentType]
+	if !ok {
+		return nil, fmt.Errorf("held %s content cannot be reviewed", decision.ContentType)
+	}
+
+	now := time.Now()
+	decision.ReviewedAt = &now
+	decision.ReviewedByID = &reviewerID
+	decision.Resolution = models.ModerationRejected
+	if req.Approve {
+		decision.Resolution = models.ModerationApproved
+	}
+
+	// Claim the review first so two reviewers cannot act on the same content
+	claimed, err := s.moderationRepo.ResolveDecision(decision)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, errors.New("this decision has already been reviewed")
+	}
+
+	if req.Approve {
+		err = target.ReleaseHeld(decision.ContentType, decision.ContentID)
+	} else {
+		err = target.RejectHeld(decision.ContentType, decision.ContentID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decision, nil
+}
+
+// rulesFor returns the enabled rules for a content type, by check name
+func (s *ModerationService) rulesFor(contentType string) (map[string]models.ModerationRule, error) {
+	rules, err := s.moderationRepo.FindRules()
+	if err != nil {
+		return nil, err
+	}
+
+	byCheck := map[string]models.ModerationRule{}
+	for _, rule := range rules {
+		if rule.ContentType == contentType && rule.Enabled {
+			byCheck[rule.Check] = rule
+		}
+	}
+	return byCheck, nil
+}
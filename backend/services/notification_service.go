@@ -0,0 +1,67 @@
+// services/notification_service.go
+package services
+
+import (
+	"errors"
+	"log"
+
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/repositories"
+)
+
+type NotificationService struct {
+	notificationRepo *repositories.NotificationRepository
+	userRepo         *repositories.UserRepository
+	emailService     *EmailService
+}
+
+func NewNotificationService(notificationRepo *repositories.NotificationRepository, userRepo *repositories.UserRepository, emailService *EmailService) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+		emailService:     emailService,
+	}
+}
+
+// Notify stores an in-app notification and emails it to the user. Failures
+// are logged rather than returned so they never undo the triggering action.
+func (s *NotificationService) Notify(userID uint, notificationType, title, content string, relatedID *uint) {
+	notification := &models.Notification{
+		UserID:    userID,
+		Type:      notificationType,
+		Title:     title,
+		Content:   content,
+		RelatedID: relatedID,
+	}
+	if err := s.notificationRepo.Create(notification); err != nil {
+		log.Printf("Failed to store notification for user %d: %v", userID, err)
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return
+	}
+	s.emailService.SendAsync(user.Email, title, content)
+}
+
+// GetNotifications lists a user's notifications, newest first
+func (s *NotificationService) GetNotifications(userID uint, unreadOnly bool, page, limit int) ([]models.Notification, int64, error) {
+	return s.notificationRepo.FindByUser(userID, unreadOnly, page, limit)
+}
+
+// MarkRead marks a notification as read
+func (s *NotificationService) MarkRead(userID, id uint) error {
+	found, err := s.notificationRepo.MarkRead(userID, id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New("notification not found")
+	}
+	return nil
+}
+
+// MarkAllRead marks all of a user's notifications as read
+func (s *NotificationService) MarkAllRead(userID uint) error {
+	return s.notificationRepo.MarkAllRead(userID)
+}
@@ -0,0 +1,527 @@
+// services/oidc_service.go
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jimsyyap/auctions/backend/config"
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/repositories"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	oidcStateTTL        = 10 * time.Minute
+	oidcJWKSMinInterval = time.Minute // don't refetch keys more often than this
+)
+
+var (
+	ErrUnknownOIDCProvider = errors.New("unknown identity provider")
+	ErrInvalidOIDCState    = errors.New("login session expired, please try again")
+	ErrOIDCEmailConflict   = errors.New("an account with this email already exists; sign in with your password first")
+)
+
+var usernameUnsafeChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// oidcDiscovery is the subset of the provider metadata document we use
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcProvider caches discovery metadata and signing keys for one provider
+type oidcProvider struct {
+	config config.OIDCProviderConfig
+
+	mu            sync.Mutex
+	discovery     *oidcDiscovery
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}
+
+// oidcIDTokenClaims are the ID token claims used to find or create a user
+type oidcIDTokenClaims struct {
+	Nonce             string   `json:"nonce"`
+	Email             string   `json:"email"`
+	EmailVerified     oidcBool `json:"email_verified"`
+	PreferredUsername string   `json:"preferred_username"`
+	GivenName         string   `json:"given_name"`
+	FamilyName        string   `json:"family_name"`
+	jwt.RegisteredClaims
+}
+
+// oidcBool accepts both true and "true"; some providers send strings
+type oidcBool bool
+
+func (b *oidcBool) UnmarshalJSON(data []byte) error {
+	*b = oidcBool(strings.Trim(string(data), `"`) == "true")
+	return nil
+}
+
+type OIDCService struct {
+	providers    map[string]*oidcProvider
+	httpClient   *http.Client
+	authService  *AuthService
+	userRepo     *repositories.UserRepository
+	identityRepo *repositories.ExternalIdentityRepository
+	stateRepo    *repositories.OIDCStateRepository
+}
+
+func NewOIDCService(authService *AuthService, userRepo *repositories.UserRepository, identityRepo *repositories.ExternalIdentityRepository, stateRepo *repositories.OIDCStateRepository) *OIDCService {
+	providers := make(map[string]*oidcProvider)
+	for _, pc := range config.GetOIDCProviders() {
+		providers[pc.Name] = &oidcProvider{config: pc}
+	}
+
+	return &OIDCService{
+		providers:    providers,
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+		authService:  authService,
+		userRepo:     userRepo,
+		identityRepo: identityRepo,
+		stateRepo:    stateRepo,
+	}
+}
+
+// OIDCStartResponse tells the client where to send the user
+type OIDCStartResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// OIDCCallbackRequest carries the parameters the provider redirected back with
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+// ListProviders returns the names of the configured identity providers
+func (s *OIDCService) ListProviders() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	return names
+}
+
+// StartLogin creates the state, nonce and PKCE verifier for a login and
+// returns the provider's authorization URL
+func (s *OIDCService) StartLogin(providerName string) (*OIDCStartResponse, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, ErrUnknownOIDCProvider
+	}
+
+	discovery, err := s.discover(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := generateOpaqueToken(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := generateOpaqueToken(32)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := generateOpaqueToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.stateRepo.Create(&models.OIDCState{
+		StateHash:    hashToken(state),
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(oidcStateTTL),
+	}); err != nil {
+		return nil, err
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", provider.config.ClientID)
+	params.Set("redirect_uri", provider.config.RedirectURL)
+	params.Set("scope", strings.Join(provider.config.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return &OIDCStartResponse{
+		AuthorizationURL: discovery.AuthorizationEndpoint + separator + params.Encode(),
+	}, nil
+}
+
+// CompleteLogin exchanges the authorization code, verifies the ID token and
+// signs the linked user in, creating or linking an account on first login
+func (s *OIDCService) CompleteLogin(providerName string, req *OIDCCallbackRequest, client ClientInfo) (*AuthResponse, *TwoFactorChallenge, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, nil, ErrUnknownOIDCProvider
+	}
+
+	state, err := s.stateRepo.Consume(hashToken(req.State))
+	if err != nil || state.Provider != providerName || time.Now().After(state.ExpiresAt) {
+		return nil, nil, ErrInvalidOIDCState
+	}
+
+	rawIDToken, err := s.exchangeCode(provider, req.Code, state.CodeVerifier)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	claims, err := s.verifyIDToken(provider, rawIDToken, state.Nonce)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.resolveUser(providerName, claims)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.authService.beginSession(user, client)
+}
+
+// resolveUser finds the user linked to an external identity. On first login
+// it links to an existing account with the same verified email, or creates
+// a new account.
+func (s *OIDCService) resolveUser(providerName string, claims *oidcIDTokenClaims) (*models.User, error) {
+	identity, err := s.identityRepo.FindByProviderSubject(providerName, claims.Subject)
+	if err == nil {
+		return &identity.User, nil
+	}
+
+	if claims.Email == "" {
+		return nil, errors.New("identity provider did not share an email address")
+	}
+
+	user, err := s.userRepo.FindByEmail(claims.Email)
+	if err == nil {
+		// Only link when both sides have proven ownership of the address,
+		// otherwise a provider account could take over a local one
+		if !user.IsEmailVerified || !bool(claims.EmailVerified) {
+			return nil, ErrOIDCEmailConflict
+		}
+	} else {
+		user, err = s.createUser(claims)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.identityRepo.Create(&models.ExternalIdentity{
+		Provider: providerName,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+		UserID:   user.ID,
+	}); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// createUser registers a new account from ID token claims. The account gets
+// a random password; the user can set one later via password reset.
+func (s *OIDCService) createUser(claims *oidcIDTokenClaims) (*models.User, error) {
+	username, err := s.uniqueUsername(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	randomPassword, err := generateOpaqueToken(32)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(randomPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Username:        username,
+		Email:           claims.Email,
+		Password:        string(hashedPassword),
+		FirstName:       claims.GivenName,
+		LastName:        claims.FamilyName,
+		IsEmailVerified: bool(claims.EmailVerified),
+	}
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, err
+	}
+
+	if !user.IsEmailVerified {
+		if err := s.authService.sendVerificationEmail(user); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+// uniqueUsername derives an unused username from the claims
+func (s *OIDCService) uniqueUsername(claims *oidcIDTokenClaims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+	base = usernameUnsafeChars.ReplaceAllString(base, "")
+	if len(base) < 3 {
+		base = "user"
+	}
+	if len(base) > 40 {
+		base = base[:40]
+	}
+
+	candidate := base
+	for i := 0; i < 10; i++ {
+		if _, err := s.userRepo.FindByUsername(candidate); err != nil {
+			return candidate, nil
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(10000))
+		if err != nil {
+			return "", err
+		}
+		candidate = fmt.Sprintf("%s%04d", base, n.Int64())
+	}
+
+	return "", errors.New("could not choose a username")
+}
+
+// exchangeCode redeems the authorization code at the token endpoint and
+// returns the raw ID token
+func (s *OIDCService) exchangeCode(provider *oidcProvider, code, verifier string) (string, error) {
+	discovery, err := s.discover(provider)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", provider.config.RedirectURL)
+	form.Set("client_id", provider.config.ClientID)
+	form.Set("code_verifier", verifier)
+	if provider.config.ClientSecret != "" {
+		form.Set("client_secret", provider.config.ClientSecret)
+	}
+
+	resp, err := s.httpClient.PostForm(discovery.TokenEndpoint, form)
+	if err != nil {
+		return "", fmt.Errorf("token exchange failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token exchange failed with status %d", resp.StatusCode)
+	}
+
+	var tokenResp struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return "", err
+	}
+	if tokenResp.IDToken == "" {
+		return "", errors.New("identity provider did not return an ID token")
+	}
+
+	return tokenResp.IDToken, nil
+}
+
+// verifyIDToken checks the ID token signature, issuer, audience, expiry and nonce
+func (s *OIDCService) verifyIDToken(provider *oidcProvider, rawIDToken, nonce string) (*oidcIDTokenClaims, error) {
+	discovery, err := s.discover(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &oidcIDTokenClaims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			key, err := s.signingKey(provider, discovery, kid)
+			if err != nil {
+				return nil, err
+			}
+			// Make sure the algorithm family matches the key type
+			switch key.(type) {
+			case *rsa.PublicKey:
+				if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+					return nil, errors.New("unexpected signing method")
+				}
+			case *ecdsa.PublicKey:
+				if _, ok := token.Method.(*jwt.SigningMethodECDSA); !ok {
+					return nil, errors.New("unexpected signing method")
+				}
+			}
+			return key, nil
+		},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(provider.config.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	if claims.Nonce != nonce {
+		return nil, errors.New("invalid ID token: nonce mismatch")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("invalid ID token: missing subject")
+	}
+
+	return claims, nil
+}
+
+// discover fetches and caches the provider's metadata document
+func (s *OIDCService) discover(provider *oidcProvider) (*oidcDiscovery, error) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	if provider.discovery != nil {
+		return provider.discovery, nil
+	}
+
+	issuer := strings.TrimSuffix(provider.config.Issuer, "/")
+	var discovery oidcDiscovery
+	if err := s.getJSON(issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("provider discovery failed: %w", err)
+	}
+
+	if strings.TrimSuffix(discovery.Issuer, "/") != issuer {
+		return nil, errors.New("provider discovery failed: issuer mismatch")
+	}
+
+	provider.discovery = &discovery
+	return provider.discovery, nil
+}
+
+// signingKey returns the provider key with the given kid, refetching the
+// JWKS when the kid is unknown (the provider may have rotated keys)
+func (s *OIDCService) signingKey(provider *oidcProvider, discovery *oidcDiscovery, kid string) (interface{}, error) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	if key, ok := provider.keys[kid]; ok {
+		return key, nil
+	}
+
+	if time.Since(provider.keysFetchedAt) < oidcJWKSMinInterval && provider.keys != nil {
+		return nil, errors.New("unknown signing key")
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := s.getJSON(discovery.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("fetching provider keys failed: %w", err)
+	}
+
+	keys := make(map[string]interface{})
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch k.Kty {
+		case "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(k.N)
+			e, errE := base64.RawURLEncoding.DecodeString(k.E)
+			if errN != nil || errE != nil {
+				continue
+			}
+			keys[k.Kid] = &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			}
+		case "EC":
+			var curve elliptic.Curve
+			switch k.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				continue
+			}
+			x, errX := base64.RawURLEncoding.DecodeString(k.X)
+			y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+			if errX != nil || errY != nil {
+				continue
+			}
+			keys[k.Kid] = &ecdsa.PublicKey{
+				Curve: curve,
+				X:     new(big.Int).SetBytes(x),
+				Y:     new(big.Int).SetBytes(y),
+			}
+		}
+	}
+
+	provider.keys = keys
+	provider.keysFetchedAt = time.Now()
+
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, errors.New("unknown signing key")
+}
+
+// getJSON fetches a URL and decodes the JSON response into v
+func (s *OIDCService) getJSON(rawURL string, v interface{}) error {
+	resp, err := s.httpClient.Get(rawURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
@@ -0,0 +1,412 @@
+// services/payment_service.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/payments"
+	"github.com/jimsyyap/auctions/backend/repositories"
+)
+
+var (
+	ErrPaymentNotFound  = errors.New("payment not found")
+	ErrUnknownProvider  = errors.New("unknown payment provider")
+	ErrFakeCheckoutOnly = errors.New("payments can only be confirmed this way with the fake provider")
+)
+
+// PaymentService collects payment for transactions through the configured
+// payment provider. The provider's webhooks, not the buyer's browser,
+// decide when a transaction is paid.
+type PaymentService struct {
+	provider            payments.PaymentProvider
+	paymentRepo         *repositories.PaymentRepository
+	transactionRepo     *repositories.TransactionRepository
+	ledgerService       *LedgerService
+	notificationService *NotificationService
+}
+
+func NewPaymentService(provider payments.PaymentProvider, paymentRepo *repositories.PaymentRepository, transactionRepo *repositories.TransactionRepository, ledgerService *LedgerService, notificationService *NotificationService) *PaymentService {
+	return &PaymentService{
+		provider:            provider,
+		paymentRepo:         paymentRepo,
+		transactionRepo:     transactionRepo,
+		ledgerService:       ledgerService,
+		notificationService: notificationService,
+	}
+}
+
+// PayRequest starts paying for a transaction. Method defaults to the
+// provider's first method. Escrow holds the payment until the buyer
+// confirms delivery instead of releasing it to the seller straight away.
+type PayRequest struct {
+	Method string `json:"method"`
+	Escrow bool   `json:"escrow"`
+}
+
+// FakeCheckoutRequest completes a fake-provider checkout. Decline makes
+// the payment fail, as a declined card would.
+type FakeCheckoutRequest struct {
+	Decline bool `json:"decline"`
+}
+
+// PaymentView is a payment attempt as shown to the buyer and seller
+type PaymentView struct {
+	ID            uint         `json:"id"`
+	Provider      string       `json:"provider"`
+	IntentID      string       `json:"intent_id"`
+	Method        string       `json:"method"`
+	Amount        models.Money `json:"amount"`
+	Escrow        bool         `json:"escrow"`
+	Status        string       `json:"status"`
+	Refunded      models.Money `json:"refunded"`
+	FailureReason string       `json:"failure_reason,omitempty"`
+	PaidAt        *time.Time   `json:"paid_at,omitempty"`
+	RefundedAt    *time.Time   `json:"refunded_at,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+// CheckoutView is what the buyer's client needs to complete a payment
+// with the provider
+type CheckoutView struct {
+	Payment      PaymentView `json:"payment"`
+	ClientSecret string      `json:"client_secret"`
+	Methods      []string    `json:"methods"`
+}
+
+// StartPayment creates a payment intent for the full amount of an unpaid
+// transaction. The transaction is marked paid once the provider confirms
+// the payment by webhook.
+func (s *PaymentService) StartPayment(userID, transactionID uint, req *PayRequest) (*CheckoutView, error) {
+	transaction, err := s.transactionRepo.FindByID(transactionID)
+	if err != nil || !transaction.HasParticipant(userID) {
+		return nil, ErrTransactionNotFound
+	}
+	if transaction.BuyerID != userID {
+		return nil, ErrNotTransactionBuyer
+	}
+	if transaction.Status != models.TransactionAwaitingPayment {
+		return nil, errors.New("this transaction is not awaiting payment")
+	}
+	if !transaction.HasShippingAddress() {
+		return nil, errors.New("choose a shipping address before paying")
+	}
+
+	methods := s.provider.Methods()
+	method := req.Method
+	if method == "" {
+		method = methods[0]
+	}
+	if !slices.Contains(methods, method) {
+		return nil, fmt.Errorf("payment method %q is not supported", method)
+	}
+
+	intent, err := s.provider.CreateIntent(payments.IntentRequest{
+		Amount:    transaction.Amount.Minor,
+		Currency:  transaction.Amount.Currency,
+		Method:    method,
+		Reference: fmt.Sprintf("transaction-%d", transaction.ID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	payment := &models.Payment{
+		Provider:       s.provider.Name(),
+		ProviderRef:    intent.ID,
+		Method:         method,
+		Amount:         transaction.Amount,
+		Escrow:         req.Escrow,
+		Status:         models.PaymentPending,
+		RefundedAmount: models.NewMoney(0, transaction.Amount.Currency),
+		TransactionID:  transaction.ID,
+	}
+	if err := s.paymentRepo.Create(payment); err != nil {
+		return nil, err
+	}
+
+	return &CheckoutView{
+		Payment:      toPaymentView(payment),
+		ClientSecret: intent.ClientSecret,
+		Methods:      methods,
+	}, nil
+}
+
+// GetPayments lists the payment attempts for one of the user's transactions
+func (s *PaymentService) GetPayments(userID, transactionID uint) ([]PaymentView, error) {
+	transaction, err := s.transactionRepo.FindByID(transactionID)
+	if err != nil || !transaction.HasParticipant(userID) {
+		return nil, ErrTransactionNotFound
+	}
+
+	attempts, err := s.paymentRepo.FindByTransaction(transaction.ID)
+	if err != nil {
+		return nil, err
+	}
+	views := make([]PaymentView, len(attempts))
+	for i := range attempts {
+		views[i] = toPaymentView(&attempts[i])
+	}
+	return views, nil
+}
+
+// HandleWebhook verifies and applies a webhook from the provider. Events
+// that were already handled are acknowledged without being applied again.
+// An error tells the provider to retry later.
+func (s *PaymentService) HandleWebhook(providerName string, payload []byte, header http.Header) error {
+	if providerName != s.provider.Name() {
+		return ErrUnknownProvider
+	}
+
+	event, err := s.provider.VerifyWebhook(payload, header)
+	if err != nil {
+		return err
+	}
+
+	processed, err := s.paymentRepo.EventProcessed(providerName, event.ID)
+	if err != nil {
+		return err
+	}
+	if processed {
+		return nil
+	}
+
+	switch event.Type {
+	case payments.EventPaymentAuthorized:
+		err = s.capture(event)
+	case payments.EventPaymentFailed:
+		err = s.fail(event)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Every state change above only applies once, so a concurrent delivery
+	// of the same event that loses this insert has done no harm
+	record := &models.PaymentEvent{Provider: providerName, EventID: event.ID, Type: event.Type, Payload: string(payload)}
+	if err := s.paymentRepo.RecordEvent(record); err != nil {
+		log.Printf("Failed to record payment event %s: %v", event.ID, err)
+	}
+	return nil
+}
+
+// RefundTransaction returns the buyer's payment for a paid transaction
+func (s *PaymentService) RefundTransaction(transaction *models.Transaction) error {
+	payment, err := s.refundablePayment(transaction)
+	if err != nil {
+		return err
+	}
+	return s.refund(payment, transaction, payment.Amount, models.PaymentRefunded)
+}
+
+// RefundPart returns part of the buyer's payment for a paid transaction.
+// The amount comes out of the seller's share of the sale.
+func (s *PaymentService) RefundPart(transaction *models.Transaction, amount models.Money) error {
+	payment, err := s.refundablePayment(transaction)
+	if err != nil {
+		return err
+	}
+	if amount.Currency != payment.Amount.Currency || !amount.IsPositive() || amount.Cmp(payment.Amount) >= 0 {
+		return fmt.Errorf("a partial refund must be more than 0 and less than %s", payment.Amount)
+	}
+	return s.refund(payment, transaction, amount, models.PaymentPartiallyRefunded)
+}
+
+// ConfirmFakePayment completes a checkout with the fake provider on the
+// buyer's behalf. The provider's signed webhook goes through the same path
+// as a real one.
+func (s *PaymentService) ConfirmFakePayment(userID uint, intentID string, req *FakeCheckoutRequest) (*PaymentView, error) {
+	fake, ok := s.provider.(*payments.FakeProvider)
+	if !ok {
+		return nil, ErrFakeCheckoutOnly
+	}
+
+	payment, err := s.paymentRepo.FindByProviderRef(fake.Name(), intentID)
+	if err != nil || payment.Transaction.BuyerID != userID {
+		return nil, ErrPaymentNotFound
+	}
+
+	payload, header, err := fake.Confirm(intentID, !req.Decline)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.HandleWebhook(fake.Name(), payload, header); err != nil {
+		return nil, err
+	}
+
+	payment, err = s.paymentRepo.FindByProviderRef(fake.Name(), intentID)
+	if err != nil {
+		return nil, err
+	}
+	view := toPaymentView(payment)
+	return &view, nil
+}
+
+// capture collects an authorized payment and marks its transaction paid.
+// The payment is released to the seller at once unless the buyer chose
+// escrow. If the transaction stopped waiting for payment in the meantime,
+// the money goes straight back to the buyer.
+func (s *PaymentService) capture(event *payments.Event) error {
+	payment, err := s.paymentRepo.FindByProviderRef(s.provider.Name(), event.IntentID)
+	if err != nil {
+		log.Printf("Ignoring payment event %s for unknown intent %s", event.ID, event.IntentID)
+		return nil
+	}
+	if payment.Status != models.PaymentPending {
+		return nil
+	}
+
+	// The payment stays pending until it is marked completed below, so a
+	// webhook retried after a failure captures again; the idempotency key
+	// makes the provider collect the money once
+	if _, err := s.provider.Capture(payment.ProviderRef, fmt.Sprintf("capture-payment-%d", payment.ID)); err != nil {
+		return err
+	}
+
+	completed, paid, err := s.paymentRepo.Complete(payment, time.Now())
+	if err != nil || !completed {
+		return err
+	}
+	payment.Status = models.PaymentCompleted
+
+	transaction := &payment.Transaction
+	if err := s.ledgerService.RecordPayment(payment, transaction); err != nil {
+		log.Printf("Failed to record payment %d in the ledger: %v", payment.ID, err)
+	}
+	if !paid {
+		if err := s.refund(payment, transaction, payment.Amount, models.PaymentRefunded); err != nil {
+			return err
+		}
+		s.notify(transaction.BuyerID, transaction, "Payment refunded",
+			fmt.Sprintf("Your payment for \"%s\" was refunded because the sale is no longer awaiting payment.", transaction.Listing.Title))
+		return nil
+	}
+
+	if payment.Escrow {
+		s.notify(transaction.BuyerID, transaction, "Payment held in escrow",
+			fmt.Sprintf("Your payment for \"%s\" went through. We will hold it until you confirm delivery.", transaction.Listing.Title))
+		s.notify(transaction.SellerID, transaction, "Payment received in escrow",
+			fmt.Sprintf("The buyer paid for \"%s\" into escrow. You can ship it now; the payment is released when the buyer confirms delivery.", transaction.Listing.Title))
+		return nil
+	}
+
+	if err := s.ledgerService.Settle(transaction); err != nil {
+		log.Printf("Failed to settle transaction %d in the ledger: %v", transaction.ID, err)
+	}
+	s.notify(transaction.BuyerID, transaction, "Payment confirmed",
+		fmt.Sprintf("Your payment for \"%s\" went through. The seller will ship it soon.", transaction.Listing.Title))
+	s.notify(transaction.SellerID, transaction, "Payment received",
+		fmt.Sprintf("The buyer paid for \"%s\". You can ship it now.", transaction.Listing.Title))
+	return nil
+}
+
+// fail records a payment the provider could not collect. The buyer can
+// try again.
+func (s *PaymentService) fail(event *payments.Event) error {
+	payment, err := s.paymentRepo.FindByProviderRef(s.provider.Name(), event.IntentID)
+	if err != nil {
+		log.Printf("Ignoring payment event %s for unknown intent %s", event.ID, event.IntentID)
+		return nil
+	}
+
+	payment.Status = models.PaymentFailed
+	payment.FailureReason = event.Reason
+	updated, err := s.paymentRepo.UpdateStatus(payment, models.PaymentPending)
+	if err != nil || !updated {
+		return err
+	}
+
+	transaction := &payment.Transaction
+	s.notify(transaction.BuyerID, transaction, "Payment failed",
+		fmt.Sprintf("Your payment for \"%s\" did not go through. Please try again.", transaction.Listing.Title))
+	return nil
+}
+
+// refundablePayment returns the completed payment of a transaction, or one
+// whose refund was claimed but not finished, so that it can be retried
+func (s *PaymentService) refundablePayment(transaction *models.Transaction) (*models.Payment, error) {
+	payment, err := s.paymentRepo.FindLatest(transaction.ID, models.PaymentCompleted)
+	if err != nil {
+		payment, err = s.paymentRepo.FindLatest(transaction.ID, models.PaymentRefunding)
+	}
+	if err != nil {
+		return nil, errors.New("no completed payment was found to refund")
+	}
+	return payment, nil
+}
+
+// refund refunds amount of a payment to the buyer and leaves the payment
+// in status. The payment is claimed before the provider is called, so
+// two callers cannot both refund it; a claimed refund that did not finish
+// is retried with the same idempotency key, which the provider refunds once.
+func (s *PaymentService) refund(payment *models.Payment, transaction *models.Transaction, amount models.Money, status string) error {
+	if payment.Status == models.PaymentCompleted {
+		payment.Status = models.PaymentRefunding
+		claimed, err := s.paymentRepo.UpdateStatus(payment, models.PaymentCompleted)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			return errors.New("this payment is already being refunded")
+		}
+	}
+
+	refund, err := s.provider.Refund(payment.ProviderRef, amount.Minor, fmt.Sprintf("refund-payment-%d", payment.ID))
+	if err != nil {
+		// Release the claim so the refund can be tried again
+		payment.Status = models.PaymentCompleted
+		if _, releaseErr := s.paymentRepo.UpdateStatus(payment, models.PaymentRefunding); releaseErr != nil {
+			log.Printf("Failed to release the refund claim on payment %d: %v", payment.ID, releaseErr)
+		}
+		return err
+	}
+
+	now := time.Now()
+	payment.Status = status
+	payment.RefundRef = refund.ID
+	payment.RefundedAmount = amount
+	payment.RefundedAt = &now
+	refunded, err := s.paymentRepo.UpdateStatus(payment, models.PaymentRefunding)
+	if err != nil {
+		return err
+	}
+	if !refunded {
+		return errors.New("this payment was refunded by someone else")
+	}
+
+	if status == models.PaymentPartiallyRefunded {
+		err = s.ledgerService.RecordPartialRefund(payment, transaction)
+	} else {
+		err = s.ledgerService.RecordRefund(payment, transaction)
+	}
+	if err != nil {
+		log.Printf("Failed to record refund of payment %d in the ledger: %v", payment.ID, err)
+	}
+	return nil
+}
+
+func (s *PaymentService) notify(userID uint, transaction *models.Transaction, title, content string) {
+	s.notificationService.Notify(userID, models.NotificationTransaction, title, content, &transaction.ID)
+}
+
+func toPaymentView(p *models.Payment) PaymentView {
+	return PaymentView{
+		ID:            p.ID,
+		Provider:      p.Provider,
+		IntentID:      p.ProviderRef,
+		Method:        p.Method,
+		Amount:        p.Amount,
+		Escrow:        p.Escrow,
+		Status:        p.Status,
+		Refunded:      p.RefundedAmount,
+		FailureReason: p.FailureReason,
+		PaidAt:        p.PaidAt,
+		RefundedAt:    p.RefundedAt,
+		CreatedAt:     p.CreatedAt,
+	}
+}
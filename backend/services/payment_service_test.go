@@ -0,0 +1,237 @@
+// services/payment_service_test.go
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/database"
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/payments"
+	"github.com/jimsyyap/auctions/backend/repositories"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The payment flow runs against the fake provider, so it needs no network
+// access, and a Postgres database named by TEST_DATABASE_URL, such as
+// "host=localhost user=postgres dbname=auctions_test sslmode=disable".
+// Use a disposable database: the tests migrate it and leave their rows.
+var testDB struct {
+	once sync.Once
+	err  error
+}
+
+func connectTestDB(t *testing.T) {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	testDB.once.Do(func() {
+		database.DB, testDB.err = gorm.Open(postgres.Open(dsn), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+		})
+		if testDB.err == nil {
+			database.Migrate()
+		}
+	})
+	if testDB.err != nil {
+		t.Fatalf("connecting to the test database: %v", testDB.err)
+	}
+}
+
+// paymentTest is a payment service wired to the fake provider, with an
+// unpaid sale to pay for
+type paymentTest struct {
+	service     *PaymentService
+	provider    *payments.FakeProvider
+	transaction *models.Transaction
+}
+
+func newPaymentTest(t *testing.T) *paymentTest {
+	t.Helper()
+	connectTestDB(t)
+
+	provider, err := payments.NewFakeProvider("test-secret", 5*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notificationService := NewNotificationService(repositories.NewNotificationRepository(), repositories.NewUserRepository(), NewEmailService())
+	feeService := NewFeeService(repositories.NewFeeRepository(), repositories.NewCategoryRepository())
+	ledgerService := NewLedgerService(repositories.NewLedgerRepository(), feeService, notificationService)
+
+	return &paymentTest{
+		service:     NewPaymentService(provider, repositories.NewPaymentRepository(), repositories.NewTransactionRepository(), ledgerService, notificationService),
+		provider:    provider,
+		transaction: createTestSale(t),
+	}
+}
+
+// createTestSale records a won auction awaiting payment between two new users
+func createTestSale(t *testing.T) *models.Transaction {
+	t.Helper()
+	suffix := time.Now().UnixNano()
+	seller := &models.User{Username: fmt.Sprintf("seller%d", suffix), Email: fmt.Sprintf("seller%d@example.com", suffix), Password: "x"}
+	buyer := &models.User{Username: fmt.Sprintf("buyer%d", suffix), Email: fmt.Sprintf("buyer%d@example.com", suffix), Password: "x"}
+	for _, user := range []*models.User{seller, buyer} {
+		if err := database.DB.Create(user).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	listing := &models.Listing{
+		Title:      "Test lot",
+		Currency:   "USD",
+		StartPrice: models.NewMoney(1000, "USD"),
+		Status:     "active",
+		EndTime:    time.Now(),
+		UserID:     seller.ID,
+	}
+	if err := database.DB.Omit("Categories").Create(listing).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	transaction := &models.Transaction{
+		Type:            models.TransactionAuctionWin,
+		Status:          models.TransactionAwaitingPayment,
+		ItemPrice:       models.NewMoney(2000, "USD"),
+		ShippingCost:    models.NewMoney(500, "USD"),
+		PlatformFee:     models.NewMoney(200, "USD"),
+		TaxAmount:       models.NewMoney(0, "USD"),
+		Amount:          models.NewMoney(2500, "USD"),
+		ShippingAddress: models.AddressSnapshot{StreetAddress1: "1 Main St", City: "Springfield", Country: "US"},
+		ListingID:       listing.ID,
+		SellerID:        seller.ID,
+		BuyerID:         buyer.ID,
+	}
+	created, err := repositories.NewTransactionRepository().CreateForSale(transaction)
+	if err != nil || !created {
+		t.Fatalf("recording the sale: created %v, %v", created, err)
+	}
+	return transaction
+}
+
+// pay starts a payment for the sale and returns the webhook the fake
+// provider sends once the buyer completes checkout
+func (p *paymentTest) pay(t *testing.T) (*CheckoutView, []byte, http.Header) {
+	t.Helper()
+	checkout, err := p.service.StartPayment(p.transaction.BuyerID, p.transaction.ID, &PayRequest{})
+	if err != nil {
+		t.Fatalf("StartPayment: %v", err)
+	}
+	payload, header, err := p.provider.Confirm(checkout.Payment.IntentID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return checkout, payload, header
+}
+
+func (p *paymentTest) reload(t *testing.T) (*models.Transaction, *models.Payment) {
+	t.Helper()
+	transaction, err := repositories.NewTransactionRepository().FindByID(p.transaction.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payment models.Payment
+	if err := database.DB.Where("transaction_id = ?", p.transaction.ID).Order("id DESC").First(&payment).Error; err != nil {
+		t.Fatal(err)
+	}
+	return transaction, &payment
+}
+
+func TestWebhookCapturesPaymentAndMarksSalePaid(t *testing.T) {
+	p := newPaymentTest(t)
+	_, payload, header := p.pay(t)
+
+	if err := p.service.HandleWebhook("fake", payload, header); err != nil {
+		t.Fatalf("HandleWebhook: %v", err)
+	}
+
+	transaction, payment := p.reload(t)
+	if transaction.Status != models.TransactionPaid || transaction.PaidAt == nil {
+		t.Errorf("sale is %s, want %s with a paid time", transaction.Status, models.TransactionPaid)
+	}
+	if payment.Status != models.PaymentCompleted {
+		t.Errorf("payment is %s, want %s", payment.Status, models.PaymentCompleted)
+	}
+}
+
+func TestWebhookWithBadSignatureIsRejected(t *testing.T) {
+	p := newPaymentTest(t)
+	_, payload, header := p.pay(t)
+
+	tampered := bytes.Replace(payload, []byte("2500"), []byte("1"), 1)
+	if err := p.service.HandleWebhook("fake", tampered, header); !errors.Is(err, payments.ErrInvalidSignature) {
+		t.Fatalf("got %v, want %v", err, payments.ErrInvalidSignature)
+	}
+
+	transaction, payment := p.reload(t)
+	if transaction.Status != models.TransactionAwaitingPayment || payment.Status != models.PaymentPending {
+		t.Errorf("sale is %s and payment %s after a rejected webhook, want both unchanged", transaction.Status, payment.Status)
+	}
+}
+
+func TestReplayedWebhookIsAppliedOnce(t *testing.T) {
+	p := newPaymentTest(t)
+	_, payload, header := p.pay(t)
+
+	if err := p.service.HandleWebhook("fake", payload, header); err != nil {
+		t.Fatalf("HandleWebhook: %v", err)
+	}
+	transaction, payment := p.reload(t)
+
+	var entries int64
+	if err := database.DB.Model(&models.LedgerEntry{}).Count(&entries).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.service.HandleWebhook("fake", payload, header); err != nil {
+		t.Fatalf("replayed HandleWebhook: %v", err)
+	}
+	replayedTransaction, replayedPayment := p.reload(t)
+	if !replayedTransaction.PaidAt.Equal(*transaction.PaidAt) || !replayedPayment.PaidAt.Equal(*payment.PaidAt) {
+		t.Error("replaying the webhook paid the sale again")
+	}
+
+	var replayedEntries int64
+	if err := database.DB.Model(&models.LedgerEntry{}).Count(&replayedEntries).Error; err != nil {
+		t.Fatal(err)
+	}
+	if replayedEntries != entries {
+		t.Errorf("replaying the webhook added %d ledger entries", replayedEntries-entries)
+	}
+}
+
+func TestRefundTransactionInFull(t *testing.T) {
+	p := newPaymentTest(t)
+	_, payload, header := p.pay(t)
+	if err := p.service.HandleWebhook("fake", payload, header); err != nil {
+		t.Fatalf("HandleWebhook: %v", err)
+	}
+
+	transaction, _ := p.reload(t)
+	if err := p.service.RefundTransaction(transaction); err != nil {
+		t.Fatalf("RefundTransaction: %v", err)
+	}
+
+	_, payment := p.reload(t)
+	if payment.Status != models.PaymentRefunded {
+		t.Errorf("payment is %s, want %s", payment.Status, models.PaymentRefunded)
+	}
+	if payment.RefundedAmount.Cmp(payment.Amount) != 0 || payment.RefundRef == "" {
+		t.Errorf("refunded %s (ref %q), want %s", payment.RefundedAmount, payment.RefundRef, payment.Amount)
+	}
+
+	if err := p.service.RefundTransaction(transaction); err == nil {
+		t.Error("refunding the sale a second time succeeded")
+	}
+}
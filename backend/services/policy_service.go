@@ -0,0 +1,119 @@
+// services/policy_service.go
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/config"
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/repositories"
+)
+
+// How long resolved permissions are cached. Role changes made through this
+// service invalidate the cache immediately; the TTL bounds staleness for
+// changes made by other instances.
+const permissionCacheTTL = 30 * time.Second
+
+type cachedPermissions struct {
+	permissions map[string]bool
+	expiresAt   time.Time
+}
+
+// PolicyService is the single place that decides what a user may do. It
+// resolves role permissions for middlewares.RequirePermission and holds the
+// resource-level rules (such as listing ownership) used by other services.
+type PolicyService struct {
+	roleRepo            *repositories.RoleRepository
+	exchangeRateService *ExchangeRateService
+
+	mu    sync.RWMutex
+	cache map[uint]cachedPermissions
+}
+
+func NewPolicyService(roleRepo *repositories.RoleRepository, exchangeRateService *ExchangeRateService) *PolicyService {
+	return &PolicyService{
+		roleRepo:            roleRepo,
+		exchangeRateService: exchangeRateService,
+		cache:               make(map[uint]cachedPermissions),
+	}
+}
+
+// Permissions returns the effective permissions of a user: those of the
+// implicit "user" role plus those of every assigned role
+func (p *PolicyService) Permissions(userID uint) (map[string]bool, error) {
+	p.mu.RLock()
+	entry, ok := p.cache[userID]
+	p.mu.RUnlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.permissions, nil
+	}
+
+	user, err := p.roleRepo.GetUserWithRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := user.Roles
+	if base, err := p.roleRepo.FindByName(models.RoleUser); err == nil {
+		roles = append(roles, *base)
+	}
+
+	permissions := make(map[string]bool)
+	for _, role := range roles {
+		for _, perm := range role.Permissions {
+			permissions[perm.Name] = true
+		}
+	}
+
+	p.mu.Lock()
+	p.cache[userID] = cachedPermissions{permissions: permissions, expiresAt: time.Now().Add(permissionCacheTTL)}
+	p.mu.Unlock()
+
+	return permissions, nil
+}
+
+// HasPermission reports whether a user currently holds a permission
+func (p *PolicyService) HasPermission(userID uint, permission string) (bool, error) {
+	permissions, err := p.Permissions(userID)
+	if err != nil {
+		return false, err
+	}
+	return permissions[permission], nil
+}
+
+// Invalidate drops cached permissions so the next check sees role changes
+func (p *PolicyService) Invalidate(userID uint) {
+	p.mu.Lock()
+	delete(p.cache, userID)
+	p.mu.Unlock()
+}
+
+// CanModifyListing reports whether a user may update or delete a listing:
+// its owner, or anyone allowed to moderate listings
+func (p *PolicyService) CanModifyListing(userID uint, listing *models.Listing) bool {
+	if listing.UserID == userID {
+		return true
+	}
+	allowed, err := p.HasPermission(userID, models.PermListingModerate)
+	return err == nil && allowed
+}
+
+// CanListAtPrice reports whether a seller may list an item at the given
+// price. Above the configured limit only verified sellers may list. Prices
+// in other currencies are compared at the current exchange rate; one that
+// cannot be converted is treated as above the limit.
+func (p *PolicyService) CanListAtPrice(sellerID uint, price models.Money) bool {
+	limit := config.GetMarketplaceConfig().UnverifiedSellerMaxPrice
+	if !limit.IsPositive() {
+		return true
+	}
+	if rates, err := p.exchangeRateService.Rates(); err == nil {
+		if converted, ok := rates.Convert(price, limit.Currency); ok && converted.Cmp(limit) <= 0 {
+			return true
+		}
+	}
+
+	seller, err := p.roleRepo.GetUserWithRoles(sellerID)
+	return err == nil && seller.IsSellerVerified
+}
@@ -0,0 +1,334 @@
+// services/question_service.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/config"
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/repositories"
+)
+
+var (
+	ErrQuestionNotFound = errors.New("question not found")
+	ErrNotListingSeller = errors.New("only the seller can manage questions on this listing")
+)
+
+// QuestionService handles questions buyers ask about listings and the
+// public Q&A sellers build from them
+type QuestionService struct {
+	questionRepo        *repositories.QuestionRepository
+	listingRepo         *repositories.ListingRepository
+	notificationService *NotificationService
+	moderationService   *ModerationService
+}
+
+func NewQuestionService(questionRepo *repositories.QuestionRepository, listingRepo *repositories.ListingRepository, notificationService *NotificationService, moderationService *ModerationService) *QuestionService {
+	return &QuestionService{
+		questionRepo:        questionRepo,
+		listingRepo:         listingRepo,
+		notificationService: notificationService,
+		moderationService:   moderationService,
+	}
+}
+
+// AskQuestionRequest represents a question about a listing
+type AskQuestionRequest struct {
+	Question string `json:"question" binding:"required,max=1000"`
+}
+
+// AnswerQuestionRequest represents the seller's answer. Publish also shows
+// the question and answer on the listing page.
+type AnswerQuestionRequest struct {
+	Answer  string `json:"answer" binding:"required,max=2000"`
+	Publish bool   `json:"publish"`
+}
+
+// QuestionView is a question as shown to the seller, the asker or, once
+// published, everyone. The asker is only shown to the seller.
+type QuestionView struct {
+	ID               uint         `json:"id"`
+	Question         string       `json:"question"`
+	Answer           string       `json:"answer,omitempty"`
+	AnsweredAt       *time.Time   `json:"answered_at,omitempty"`
+	IsPublished      bool         `json:"is_published"`
+	ModerationStatus string       `json:"moderation_status,omitempty"`
+	CreatedAt        time.Time    `json:"created_at"`
+	Asker            *UserSummary `json:"asker,omitempty"`
+}
+
+// Ask sends a question to the seller. Each user can ask a limited number
+// of questions about a listing per day.
+func (s *QuestionService) Ask(userID, listingID uint, req *AskQuestionRequest) (*QuestionView, error) {
+	listing, err := s.listingRepo.FindByID(listingID)
+	if err != nil {
+		return nil, errors.New("listing not found")
+	}
+	if listing.UserID == userID {
+		return nil, errors.New("you cannot ask questions about your own listing")
+	}
+	if listing.Status != "active" {
+		return nil, errors.New("questions can only be asked about active listings")
+	}
+
+	text := strings.TrimSpace(req.Question)
+	if text == "" {
+		return nil, errors.New("question cannot be empty")
+	}
+
+	limit := config.GetMarketplaceConfig().QuestionsPerListingPerDay
+	asked, err := s.questionRepo.CountSince(listing.ID, userID, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	if asked >= int64(limit) {
+		return nil, fmt.Errorf("you can ask at most %d questions about a listing per day", limit)
+	}
+
+	screened, err := s.moderationService.Screen(models.ContentQuestion, userID, text)
+	if err != nil {
+		return nil, err
+	}
+
+	question := &models.ListingQuestion{
+		Question:         screened.Text,
+		ModerationStatus: screened.Status(),
+		ListingID:        listing.ID,
+		AskerID:          userID,
+	}
+	if err := s.questionRepo.Create(question); err != nil {
+		return nil, err
+	}
+	s.moderationService.Record(screened, question.ID)
+
+	// Held questions reach the seller once a moderator releases them
+	if !screened.IsHeld() {
+		s.notifySeller(listing, question)
+	}
+
+	view := toQuestionView(question, false)
+	return &view, nil
+}
+
+// GetQuestions lists a listing's questions for the logged-in user: every
+// question for the seller, and their own questions for anyone else
+func (s *QuestionService) GetQuestions(userID, listingID uint) ([]QuestionView, error) {
+	listing, err := s.listingRepo.FindByID(listingID)
+	if err != nil {
+		return nil, errors.New("listing not found")
+	}
+
+	isSeller := listing.UserID == userID
+	askerID := userID
+	if isSeller {
+		askerID = 0
+	}
+
+	questions, err := s.questionRepo.FindByListing(listing.ID, askerID)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]QuestionView, 0, len(questions))
+	for i := range questions {
+		// Sellers do not see questions held before they reached them
+		if isSeller && questions[i].ModerationStatus != "" && !questions[i].IsAnswered() {
+			continue
+		}
+		views = append(views, toQuestionView(&questions[i], isSeller))
+	}
+	return views, nil
+}
+
+// GetPublished lists the questions shown on a listing's page
+func (s *QuestionService) GetPublished(listingID uint) ([]QuestionView, error) {
+	questions, err := s.questionRepo.FindPublished(listingID)
+	if err != nil {
+		return nil, err
+	}
+	return toQuestionViews(questions, false), nil
+}
+
+// Answer records or replaces the seller's answer and tells the asker
+func (s *QuestionService) Answer(userID, listingID, questionID uint, req *AnswerQuestionRequest) (*QuestionView, error) {
+	question, err := s.sellerQuestion(userID, listingID, questionID)
+	if err != nil {
+		return nil, err
+	}
+
+	answer := strings.TrimSpace(req.Answer)
+	if answer == "" {
+		return nil, errors.New("answer cannot be empty")
+	}
+
+	screened, err := s.moderationService.Screen(models.ContentAnswer, userID, answer)
+	if err != nil {
+		return nil, err
+	}
+
+	firstAnswer := !question.IsAnswered()
+	now := time.Now()
+	question.Answer = screened.Text
+	question.AnsweredAt = &now
+	question.ModerationStatus = screened.Status()
+	if req.Publish && !question.IsPublished {
+		question.IsPublished = true
+		question.PublishedAt = &now
+	}
+	if err := s.questionRepo.Update(question); err != nil {
+		return nil, err
+	}
+	s.moderationService.Record(screened, question.ID)
+
+	// Held answers reach the asker once a moderator releases them
+	if firstAnswer && !screened.IsHeld() {
+		s.notifyAsker(question)
+	}
+
+	view := toQuestionView(question, true)
+	return &view, nil
+}
+
+// Publish shows an answered question on the listing page
+func (s *QuestionService) Publish(userID, listingID, questionID uint) (*QuestionView, error) {
+	question, err := s.sellerQuestion(userID, listingID, questionID)
+	if err != nil {
+		return nil, err
+	}
+	if !question.IsAnswered() {
+		return nil, errors.New("answer the question before publishing it")
+	}
+	if question.ModerationStatus != "" {
+		return nil, errors.New("this answer is awaiting moderation")
+	}
+
+	if !question.IsPublished {
+		now := time.Now()
+		question.IsPublished = true
+		question.PublishedAt = &now
+		if err := s.questionRepo.Update(question); err != nil {
+			return nil, err
+		}
+	}
+
+	view := toQuestionView(question, true)
+	return &view, nil
+}
+
+// Hide removes a question from the listing page
+func (s *QuestionService) Hide(userID, listingID, questionID uint) (*QuestionView, error) {
+	question, err := s.sellerQuestion(userID, listingID, questionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if question.IsPublished {
+		question.IsPublished = false
+		question.PublishedAt = nil
+		if err := s.questionRepo.Update(question); err != nil {
+			return nil, err
+		}
+	}
+
+	view := toQuestionView(question, true)
+	return &view, nil
+}
+
+// ReleaseHeld makes a held question or answer visible again
+func (s *QuestionService) ReleaseHeld(contentType string, questionID uint) error {
+	question, err := s.questionRepo.FindByID(questionID)
+	if err != nil {
+		return err
+	}
+
+	question.ModerationStatus = ""
+	if err := s.questionRepo.Update(question); err != nil {
+		return err
+	}
+
+	if contentType == models.ContentAnswer {
+		s.notifyAsker(question)
+	} else {
+		s.notifySeller(&question.Listing, question)
+	}
+	return nil
+}
+
+// RejectHeld removes a held question from the seller's view, or discards a
+// held answer so the seller can answer again
+func (s *QuestionService) RejectHeld(contentType string, questionID uint) error {
+	question, err := s.questionRepo.FindByID(questionID)
+	if err != nil {
+		return err
+	}
+
+	if contentType == models.ContentAnswer {
+		question.Answer = ""
+		question.AnsweredAt = nil
+		question.IsPublished = false
+		question.PublishedAt = nil
+		question.ModerationStatus = ""
+	} else {
+		question.ModerationStatus = models.ModerationStatusRejected
+	}
+	return s.questionRepo.Update(question)
+}
+
+func (s *QuestionService) notifySeller(listing *models.Listing, question *models.ListingQuestion) {
+	s.notificationService.Notify(listing.UserID, models.NotificationQuestion,
+		"New question about your listing",
+		fmt.Sprintf("Someone asked a question about \"%s\".", listing.Title),
+		&question.ID)
+}
+
+func (s *QuestionService) notifyAsker(question *models.ListingQuestion) {
+	s.notificationService.Notify(question.AskerID, models.NotificationQuestion,
+		"The seller answered your question",
+		fmt.Sprintf("The seller of \"%s\" answered your question.", question.Listing.Title),
+		&question.ID)
+}
+
+// sellerQuestion loads a question on a listing the user sells
+func (s *QuestionService) sellerQuestion(userID, listingID, questionID uint) (*models.ListingQuestion, error) {
+	question, err := s.questionRepo.FindByID(questionID)
+	if err != nil || question.ListingID != listingID {
+		return nil, ErrQuestionNotFound
+	}
+	// Questions moderation kept from the seller
+	if question.ModerationStatus == models.ModerationStatusRejected ||
+		(question.ModerationStatus == models.ModerationStatusHeld && !question.IsAnswered()) {
+		return nil, ErrQuestionNotFound
+	}
+	if question.Listing.UserID != userID {
+		return nil, ErrNotListingSeller
+	}
+	return question, nil
+}
+
+func toQuestionViews(questions []models.ListingQuestion, withAsker bool) []QuestionView {
+	views := make([]QuestionView, len(questions))
+	for i := range questions {
+		views[i] = toQuestionView(&questions[i], withAsker)
+	}
+	return views
+}
+
+func toQuestionView(q *models.ListingQuestion, withAsker bool) QuestionView {
+	view := QuestionView{
+		ID:               q.ID,
+		Question:         q.Question,
+		Answer:           q.Answer,
+		AnsweredAt:       q.AnsweredAt,
+		IsPublished:      q.IsPublished,
+		ModerationStatus: q.ModerationStatus,
+		CreatedAt:        q.CreatedAt,
+	}
+	if withAsker {
+		asker := toUserSummary(&q.Asker)
+		view.Asker = &asker
+	}
+	return view
+}
@@ -0,0 +1,131 @@
+// services/reputation_service.go
+package services
+
+import (
+	"math"
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/config"
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/repositories"
+)
+
+// Daily totals older than the longest rolling window are no longer needed
+const reputationDailyRetention = 366 * 24 * time.Hour
+
+// SentimentCounts is the number of positive, neutral and negative ratings
+// in a period
+type SentimentCounts struct {
+	Positive int64 `json:"positive"`
+	Neutral  int64 `json:"neutral"`
+	Negative int64 `json:"negative"`
+}
+
+// RoleReputation summarises the ratings a user received as a seller or as
+// a buyer
+type RoleReputation struct {
+	// Bayesian-smoothed score from 1 to 5; use this for ranking
+	Score float64 `json:"score"`
+	// Plain average of the user's own ratings
+	Average         float64         `json:"average"`
+	Count           int64           `json:"count"`
+	PositivePercent float64         `json:"positive_percent"`
+	Last30Days      SentimentCounts `json:"last_30_days"`
+	Last12Months    SentimentCounts `json:"last_12_months"`
+	Lifetime        SentimentCounts `json:"lifetime"`
+	// Disputes the user lost as a seller. Each counts towards Score as a
+	// one-star rating.
+	DisputesLost int64 `json:"disputes_lost"`
+}
+
+// Reputation is a user's reputation in each role
+type Reputation struct {
+	Seller RoleReputation `json:"seller"`
+	Buyer  RoleReputation `json:"buyer"`
+}
+
+// ReputationService maintains per-user rating aggregates and turns them
+// into reputation scores
+type ReputationService struct {
+	reputationRepo *repositories.ReputationRepository
+}
+
+func NewReputationService(reputationRepo *repositories.ReputationRepository) *ReputationService {
+	return &ReputationService{
+		reputationRepo: reputationRepo,
+	}
+}
+
+// RecordDisputeLost counts a dispute resolved with a refund against the
+// seller's reputation
+func (s *ReputationService) RecordDisputeLost(sellerID uint) error {
+	return s.reputationRepo.AddDisputeLost(sellerID)
+}
+
+// GetReputation reads a user's reputation from the stored aggregates
+func (s *ReputationService) GetReputation(userID uint) (*Reputation, error) {
+	lifetime, err := s.reputationRepo.FindLifetime(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	last30, err := s.reputationRepo.SumSince(userID, now.AddDate(0, 0, -30))
+	if err != nil {
+		return nil, err
+	}
+	last12, err := s.reputationRepo.SumSince(userID, now.AddDate(-1, 0, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]models.UserReputation, len(lifetime))
+	for _, r := range lifetime {
+		totals[r.Role] = r
+	}
+
+	return &Reputation{
+		Seller: buildRoleReputation(totals[models.RatedAsSeller], last30[models.RatedAsSeller], last12[models.RatedAsSeller]),
+		Buyer:  buildRoleReputation(totals[models.RatedAsBuyer], last30[models.RatedAsBuyer], last12[models.RatedAsBuyer]),
+	}, nil
+}
+
+// PruneDaily drops daily totals that no rolling window uses any more
+func (s *ReputationService) PruneDaily() error {
+	return s.reputationRepo.PruneDaily(time.Now().Add(-reputationDailyRetention))
+}
+
+// buildRoleReputation computes scores from one role's totals. Lost
+// disputes weigh on the score as one-star ratings.
+func buildRoleReputation(reputation models.UserReputation, last30, last12 models.ReputationCounts) RoleReputation {
+	lifetime := reputation.ReputationCounts
+	rep := RoleReputation{
+		Count:        lifetime.Total(),
+		Last30Days:   toSentimentCounts(last30),
+		Last12Months: toSentimentCounts(last12),
+		Lifetime:     toSentimentCounts(lifetime),
+		DisputesLost: reputation.DisputesLost,
+	}
+
+	cfg := config.GetReputationConfig()
+	n := float64(rep.Count + rep.DisputesLost)
+	if cfg.PriorWeight+n > 0 {
+		rep.Score = round2((cfg.PriorWeight*cfg.PriorMean + float64(lifetime.ScoreSum+rep.DisputesLost)) / (cfg.PriorWeight + n))
+	}
+
+	// The average and positive share describe ratings alone
+	if rep.Count > 0 {
+		rep.Average = round2(float64(lifetime.ScoreSum) / float64(rep.Count))
+		rep.PositivePercent = round2(100 * float64(lifetime.Positive) / float64(rep.Count))
+	}
+	return rep
+}
+
+func toSentimentCounts(c models.ReputationCounts) SentimentCounts {
+	return SentimentCounts{Positive: c.Positive, Neutral: c.Neutral, Negative: c.Negative}
+}
+
+// round2 rounds to two decimal places for display
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
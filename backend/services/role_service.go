@@ -0,0 +1,97 @@
+// services/role_service.go
+package services
+
+import (
+	"errors"
+
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/repositories"
+)
+
+type RoleService struct {
+	roleRepo      *repositories.RoleRepository
+	policyService *PolicyService
+}
+
+func NewRoleService(roleRepo *repositories.RoleRepository, policyService *PolicyService) *RoleService {
+	return &RoleService{
+		roleRepo:      roleRepo,
+		policyService: policyService,
+	}
+}
+
+// AssignRolesRequest sets the complete list of a user's roles
+type AssignRolesRequest struct {
+	Roles []string `json:"roles"`
+}
+
+// UserRolesResponse shows a user's roles and resulting permissions
+type UserRolesResponse struct {
+	UserID      uint     `json:"user_id"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+// GetRoles lists all roles with their permissions
+func (s *RoleService) GetRoles() ([]models.Role, error) {
+	return s.roleRepo.FindAll()
+}
+
+// GetUserRoles returns a user's assigned roles and effective permissions
+func (s *RoleService) GetUserRoles(userID uint) (*UserRolesResponse, error) {
+	user, err := s.roleRepo.GetUserWithRoles(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	permissions, err := s.policyService.Permissions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &UserRolesResponse{UserID: user.ID, Roles: []string{}, Permissions: []string{}}
+	for _, role := range user.Roles {
+		resp.Roles = append(resp.Roles, role.Name)
+	}
+	for perm := range permissions {
+		resp.Permissions = append(resp.Permissions, perm)
+	}
+	return resp, nil
+}
+
+// AssignRoles replaces a user's roles. The change applies to the user's
+// next request, without waiting for their access token to expire.
+func (s *RoleService) AssignRoles(actorID, userID uint, req *AssignRolesRequest) (*UserRolesResponse, error) {
+	user, err := s.roleRepo.GetUserWithRoles(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	roles, err := s.roleRepo.FindByNames(req.Roles)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) != len(req.Roles) {
+		return nil, errors.New("unknown role")
+	}
+
+	// Don't let an admin lock themselves out of role management
+	if actorID == userID && user.HasRole(models.RoleAdmin) {
+		keepsAdmin := false
+		for _, role := range roles {
+			if role.Name == models.RoleAdmin {
+				keepsAdmin = true
+			}
+		}
+		if !keepsAdmin {
+			return nil, errors.New("you cannot remove your own admin role")
+		}
+	}
+
+	if err := s.roleRepo.ReplaceUserRoles(user, roles); err != nil {
+		return nil, err
+	}
+	s.policyService.Invalidate(userID)
+
+	return s.GetUserRoles(userID)
+}
@@ -0,0 +1,261 @@
+// services/session_service.go
+package services
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/config"
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/repositories"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// How often each instance reloads recently revoked sessions and flushes
+// last-seen times. Revocations made through this instance apply at once;
+// the interval bounds how long other instances keep accepting the session.
+const sessionSyncInterval = 10 * time.Second
+
+// ClientInfo describes the client a request came from
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// SessionInfo is a session as shown to its owner
+type SessionInfo struct {
+	models.Session
+	Current bool `json:"current"`
+}
+
+type sessionActivity struct {
+	ip string
+	at time.Time
+}
+
+// SessionService tracks logins per device. It also answers the revocation
+// check made by middlewares.Auth on every request from memory, so revoked
+// access tokens stop working before they expire without a database query
+// per request.
+type SessionService struct {
+	sessionRepo      *repositories.SessionRepository
+	refreshTokenRepo *repositories.RefreshTokenRepository
+
+	mu       sync.RWMutex
+	revoked  map[uint]time.Time // session ID -> when its tokens have all expired
+	activity map[uint]sessionActivity
+	lastSync time.Time
+	syncing  bool
+}
+
+func NewSessionService(sessionRepo *repositories.SessionRepository, refreshTokenRepo *repositories.RefreshTokenRepository) *SessionService {
+	return &SessionService{
+		sessionRepo:      sessionRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		revoked:          make(map[uint]time.Time),
+		activity:         make(map[uint]sessionActivity),
+	}
+}
+
+// Record returns the session for a refresh token family, creating it on
+// first login, and notes that the client was just seen
+func (s *SessionService) Record(userID uint, familyID string, client ClientInfo, expiresAt time.Time) (*models.Session, error) {
+	now := time.Now()
+
+	session, err := s.sessionRepo.FindByFamily(familyID)
+	if err != nil {
+		session = &models.Session{
+			FamilyID:   familyID,
+			Device:     describeDevice(client.UserAgent),
+			UserAgent:  client.UserAgent,
+			IPAddress:  client.IP,
+			LastSeenAt: now,
+			ExpiresAt:  expiresAt,
+			UserID:     userID,
+		}
+		if err := s.sessionRepo.Create(session); err != nil {
+			return nil, err
+		}
+		return session, nil
+	}
+
+	session.IPAddress = client.IP
+	session.LastSeenAt = now
+	session.ExpiresAt = expiresAt
+	if err := s.sessionRepo.Update(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// GetSessions lists a user's active sessions, flagging the one the request
+// was made from
+func (s *SessionService) GetSessions(userID, currentSessionID uint) ([]SessionInfo, error) {
+	sessions, err := s.sessionRepo.FindActiveByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]SessionInfo, len(sessions))
+	for i, session := range sessions {
+		infos[i] = SessionInfo{Session: session, Current: session.ID == currentSessionID}
+	}
+	return infos, nil
+}
+
+// Revoke signs one of a user's sessions out
+func (s *SessionService) Revoke(userID, sessionID uint) error {
+	session, err := s.sessionRepo.FindByID(sessionID)
+	if err != nil || session.UserID != userID || !session.IsActive() {
+		return ErrSessionNotFound
+	}
+	return s.RevokeFamily(session.FamilyID)
+}
+
+// RevokeFamily ends the session that owns a refresh token family
+func (s *SessionService) RevokeFamily(familyID string) error {
+	if err := s.refreshTokenRepo.RevokeFamily(familyID); err != nil {
+		return err
+	}
+	ids, err := s.sessionRepo.RevokeFamily(familyID)
+	if err != nil {
+		return err
+	}
+	s.markRevoked(ids)
+	return nil
+}
+
+// RevokeAllForUser ends every session belonging to a user
+func (s *SessionService) RevokeAllForUser(userID uint) error {
+	if err := s.refreshTokenRepo.RevokeAllForUser(userID); err != nil {
+		return err
+	}
+	ids, err := s.sessionRepo.RevokeAllForUser(userID)
+	if err != nil {
+		return err
+	}
+	s.markRevoked(ids)
+	return nil
+}
+
+// IsSessionRevoked reports whether access tokens for a session must be
+// rejected. It is answered from memory.
+func (s *SessionService) IsSessionRevoked(sessionID uint) bool {
+	s.maybeSync()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, revoked := s.revoked[sessionID]
+	return revoked
+}
+
+// RecordActivity notes that a session was used. Activity is written to the
+// database in batches on the next sync.
+func (s *SessionService) RecordActivity(sessionID uint, ip string) {
+	s.mu.Lock()
+	s.activity[sessionID] = sessionActivity{ip: ip, at: time.Now()}
+	s.mu.Unlock()
+}
+
+// markRevoked remembers revoked sessions until every access token issued
+// for them has expired
+func (s *SessionService) markRevoked(ids []uint) {
+	until := time.Now().Add(config.GetAuthConfig().AccessTokenTTL)
+
+	s.mu.Lock()
+	for _, id := range ids {
+		s.revoked[id] = until
+	}
+	s.mu.Unlock()
+}
+
+// maybeSync starts a sync when the cache is stale. The first sync runs
+// inline so a fresh instance never accepts an already revoked session;
+// later ones run in the background.
+func (s *SessionService) maybeSync() {
+	s.mu.Lock()
+	if s.syncing || time.Since(s.lastSync) < sessionSyncInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.syncing = true
+	first := s.lastSync.IsZero()
+	s.mu.Unlock()
+
+	if first {
+		s.sync()
+	} else {
+		go s.sync()
+	}
+}
+
+// sync reloads recent revocations and flushes pending activity
+func (s *SessionService) sync() {
+	now := time.Now()
+	accessTTL := config.GetAuthConfig().AccessTokenTTL
+	sessions, err := s.sessionRepo.FindRevokedSince(now.Add(-accessTTL))
+
+	s.mu.Lock()
+	if err == nil {
+		for _, session := range sessions {
+			s.revoked[session.ID] = session.RevokedAt.Add(accessTTL)
+		}
+	}
+	for id, until := range s.revoked {
+		if now.After(until) {
+			delete(s.revoked, id)
+		}
+	}
+	activity := s.activity
+	s.activity = make(map[uint]sessionActivity)
+	if err == nil {
+		s.lastSync = now
+	}
+	s.syncing = false
+	s.mu.Unlock()
+
+	for id, seen := range activity {
+		s.sessionRepo.Touch(id, seen.ip, seen.at)
+	}
+}
+
+// describeDevice turns a user agent into a short label such as
+// "Firefox on Windows"
+func describeDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+
+	browser := "Unknown browser"
+	for _, b := range []struct{ token, name string }{
+		{"edg/", "Edge"},
+		{"opr/", "Opera"},
+		{"firefox/", "Firefox"},
+		{"chrome/", "Chrome"},
+		{"safari/", "Safari"},
+		{"curl/", "curl"},
+	} {
+		if strings.Contains(ua, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	os := "unknown device"
+	for _, o := range []struct{ token, name string }{
+		{"iphone", "iPhone"},
+		{"ipad", "iPad"},
+		{"android", "Android"},
+		{"windows", "Windows"},
+		{"mac os", "macOS"},
+		{"linux", "Linux"},
+	} {
+		if strings.Contains(ua, o.token) {
+			os = o.name
+			break
+		}
+	}
+
+	return browser + " on " + os
+}
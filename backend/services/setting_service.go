@@ -0,0 +1,179 @@
+// services/setting_service.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/pdf"
+	"github.com/jimsyyap/auctions/backend/repositories"
+)
+
+var ErrUnknownSetting = errors.New("unknown setting")
+
+var invoicePrefixPattern = regexp.MustCompile(`^[A-Z0-9-]{1,10}$`)
+
+// settingValidators check new values of the settings that need more than
+// a length limit
+var settingValidators = map[string]func(string) error{
+	models.SettingBrandName: func(v string) error {
+		if v == "" {
+			return errors.New("the brand name cannot be empty")
+		}
+		return nil
+	},
+	models.SettingBrandEmail: func(v string) error {
+		if v == "" {
+			return nil
+		}
+		if _, err := mail.ParseAddress(v); err != nil {
+			return fmt.Errorf("%q is not an email address", v)
+		}
+		return nil
+	},
+	models.SettingBrandColor: func(v string) error {
+		_, err := pdf.ParseColor(v)
+		return err
+	},
+	models.SettingInvoicePrefix: func(v string) error {
+		if !invoicePrefixPattern.MatchString(v) {
+			return errors.New("the invoice number prefix must be 1 to 10 capital letters, digits or dashes")
+		}
+		return nil
+	},
+}
+
+// SettingService manages system settings admins can change at runtime
+type SettingService struct {
+	settingRepo *repositories.SettingRepository
+}
+
+func NewSettingService(settingRepo *repositories.SettingRepository) *SettingService {
+	return &SettingService{
+		settingRepo: settingRepo,
+	}
+}
+
+// SettingRequest sets a system setting
+type SettingRequest struct {
+	Value string `json:"value" binding:"max=2000"`
+}
+
+// SettingView is a system setting with the value it falls back to
+type SettingView struct {
+	Key         string     `json:"key"`
+	Value       string     `json:"value"`
+	Default     string     `json:"default"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+	UpdatedByID *uint      `json:"updated_by_id,omitempty"`
+}
+
+// Branding is how the marketplace presents itself on documents it issues
+type Branding struct {
+	Name          string
+	Address       []string
+	Email         string
+	Color         pdf.Color
+	TaxID         string
+	InvoicePrefix string
+	InvoiceFooter string
+}
+
+// GetSettings lists every setting with its current value
+func (s *SettingService) GetSettings() ([]SettingView, error) {
+	settings, err := s.settingRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	stored := map[string]models.SystemSetting{}
+	for _, setting := range settings {
+		stored[setting.Key] = setting
+	}
+
+	views := make([]SettingView, 0, len(models.DefaultSystemSettings))
+	for key, fallback := range models.DefaultSystemSettings {
+		view := SettingView{Key: key, Value: fallback, Default: fallback}
+		if setting, ok := stored[key]; ok {
+			view.Value = setting.Value
+			view.UpdatedAt = &setting.UpdatedAt
+			view.UpdatedByID = setting.UpdatedByID
+		}
+		views = append(views, view)
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].Key < views[j].Key })
+	return views, nil
+}
+
+// UpdateSetting changes a setting
+func (s *SettingService) UpdateSetting(adminID uint, key string, req *SettingRequest) (*SettingView, error) {
+	fallback, ok := models.DefaultSystemSettings[key]
+	if !ok {
+		return nil, ErrUnknownSetting
+	}
+	value := strings.TrimSpace(req.Value)
+	if validate, ok := settingValidators[key]; ok {
+		if err := validate(value); err != nil {
+			return nil, err
+		}
+	}
+
+	setting := &models.SystemSetting{Key: key, Value: value, UpdatedByID: &adminID}
+	if err := s.settingRepo.Save(setting); err != nil {
+		return nil, err
+	}
+	return &SettingView{Key: key, Value: value, Default: fallback, UpdatedAt: &setting.UpdatedAt, UpdatedByID: &adminID}, nil
+}
+
+// Branding returns the current branding settings
+func (s *SettingService) Branding() (*Branding, error) {
+	values, err := s.values()
+	if err != nil {
+		return nil, err
+	}
+
+	color, err := pdf.ParseColor(values[models.SettingBrandColor])
+	if err != nil {
+		color, _ = pdf.ParseColor(models.DefaultSystemSettings[models.SettingBrandColor])
+	}
+	var address []string
+	for _, line := range strings.Split(values[models.SettingBrandAddress], "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			address = append(address, line)
+		}
+	}
+
+	return &Branding{
+		Name:          values[models.SettingBrandName],
+		Address:       address,
+		Email:         values[models.SettingBrandEmail],
+		Color:         color,
+		TaxID:         values[models.SettingBrandTaxID],
+		InvoicePrefix: values[models.SettingInvoicePrefix],
+		InvoiceFooter: values[models.SettingInvoiceFooter],
+	}, nil
+}
+
+// values returns the current value of every setting
+func (s *SettingService) values() (map[string]string, error) {
+	settings, err := s.settingRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(models.DefaultSystemSettings))
+	for key, fallback := range models.DefaultSystemSettings {
+		values[key] = fallback
+	}
+	for _, setting := range settings {
+		if _, ok := values[setting.Key]; ok {
+			values[setting.Key] = setting.Value
+		}
+	}
+	return values, nil
+}
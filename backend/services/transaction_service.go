@@ -0,0 +1,497 @@
+// services/transaction_service.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/config"
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/repositories"
+)
+
+var (
+	ErrTransactionNotFound  = errors.New("transaction not found")
+	ErrNotTransactionBuyer  = errors.New("only the buyer can do this")
+	ErrNotTransactionSeller = errors.New("only the seller can do this")
+)
+
+// TransactionService records sales and moves them through payment,
+// shipping and delivery
+type TransactionService struct {
+	transactionRepo     *repositories.TransactionRepository
+	listingRepo         *repositories.ListingRepository
+	bidRepo             *repositories.BidRepository
+	addressService      *AddressService
+	paymentService      *PaymentService
+	feeService          *FeeService
+	ledgerService       *LedgerService
+	notificationService *NotificationService
+}
+
+func NewTransactionService(transactionRepo *repositories.TransactionRepository, listingRepo *repositories.ListingRepository, bidRepo *repositories.BidRepository, addressService *AddressService, paymentService *PaymentService, feeService *FeeService, ledgerService *LedgerService, notificationService *NotificationService) *TransactionService {
+	return &TransactionService{
+		transactionRepo:     transactionRepo,
+		listingRepo:         listingRepo,
+		bidRepo:             bidRepo,
+		addressService:      addressService,
+		paymentService:      paymentService,
+		feeService:          feeService,
+		ledgerService:       ledgerService,
+		notificationService: notificationService,
+	}
+}
+
+// BuyNowRequest represents a purchase at a listing's Buy Now price.
+// AddressID defaults to the buyer's default shipping address.
+type BuyNowRequest struct {
+	AddressID uint `json:"address_id"`
+}
+
+// ShippingAddressRequest chooses where a purchase ships to
+type ShippingAddressRequest struct {
+	AddressID uint `json:"address_id" binding:"required"`
+}
+
+// ShipRequest represents the seller sending the item
+type ShipRequest struct {
+	TrackingNumber string `json:"tracking_number" binding:"max=100"`
+	TrackingURL    string `json:"tracking_url" binding:"omitempty,url,max=255"`
+}
+
+// TransactionReasonRequest explains a cancellation
+type TransactionReasonRequest struct {
+	Reason string `json:"reason" binding:"required,max=2000"`
+}
+
+// TransactionQuery filters a user's transaction list
+type TransactionQuery struct {
+	Role   string `form:"role" binding:"omitempty,oneof=buyer seller"`
+	Status string `form:"status" binding:"omitempty,oneof=awaiting_payment paid shipped completed cancelled disputed"`
+}
+
+// TransactionView is a transaction as shown to its buyer or seller. The
+// platform fee and the seller's proceeds are only shown to the seller.
+type TransactionView struct {
+	ID              uint                    `json:"id"`
+	Type            string                  `json:"type"`
+	Status          string                  `json:"status"`
+	Role            string                  `json:"role"` // the viewer's side of the sale
+	ItemPrice       models.Money            `json:"item_price"`
+	ShippingCost    models.Money            `json:"shipping_cost"`
+	TaxAmount       models.Money            `json:"tax_amount"`
+	Amount          models.Money            `json:"amount"`
+	PlatformFee     *models.Money           `json:"platform_fee,omitempty"`
+	SellerProceeds  *models.Money           `json:"seller_proceeds,omitempty"`
+	Escrow          bool                    `json:"escrow"`
+	EscrowReleaseAt *time.Time              `json:"escrow_release_at,omitempty"`
+	Funds           string                  `json:"funds,omitempty"` // held, frozen, released or refunded
+	ShippingAddress *models.AddressSnapshot `json:"shipping_address,omitempty"`
+	TrackingNumber  string                  `json:"tracking_number,omitempty"`
+	TrackingURL     string                  `json:"tracking_url,omitempty"`
+	PaidAt          *time.Time              `json:"paid_at,omitempty"`
+	ShippedAt       *time.Time              `json:"shipped_at,omitempty"`
+	CompletedAt     *time.Time              `json:"completed_at,omitempty"`
+	CancelledAt     *time.Time              `json:"cancelled_at,omitempty"`
+	CancelReason    string                  `json:"cancel_reason,omitempty"`
+	DisputedAt      *time.Time              `json:"disputed_at,omitempty"`
+	DisputeReason   string                  `json:"dispute_reason,omitempty"`
+	CreatedAt       time.Time               `json:"created_at"`
+	Listing         ListingSummary          `json:"listing"`
+	Buyer           UserSummary             `json:"buyer"`
+	Seller          UserSummary             `json:"seller"`
+}
+
+// CloseEndedAuctions ends listings whose time has run out. A listing whose
+// highest bid meets the reserve is sold to that bidder; the rest end
+// without a sale.
+func (s *TransactionService) CloseEndedAuctions() error {
+	listings, err := s.listingRepo.FindDueToClose(time.Now())
+	if err != nil {
+		return err
+	}
+
+	for i := range listings {
+		if err := s.closeAuction(&listings[i]); err != nil {
+			log.Printf("Failed to close listing %d: %v", listings[i].ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *TransactionService) closeAuction(listing *models.Listing) error {
+	// An error leaves the listing open for the next run to close
+	winning, err := s.bidRepo.FindHighestBid(listing.ID)
+	if err != nil {
+		return err
+	}
+	if winning == nil || winning.Amount.Cmp(listing.ReservePrice) < 0 {
+		_, err := s.listingRepo.CloseIfActive(listing.ID, "ended")
+		return err
+	}
+
+	// The winner picks an address before paying if they have no default
+	address, err := s.addressService.SnapshotShippingAddress(winning.UserID, 0)
+	if err != nil {
+		address = &models.AddressSnapshot{}
+	}
+
+	_, err = s.recordSale(listing, winning.UserID, models.TransactionAuctionWin, winning.Amount, address)
+	return err
+}
+
+// BuyNow sells an active listing to the buyer at its Buy Now price
+func (s *TransactionService) BuyNow(buyerID, listingID uint, req *BuyNowRequest) (*TransactionView, error) {
+	listing, err := s.listingRepo.FindByID(listingID)
+	if err != nil {
+		return nil, errors.New("listing not found")
+	}
+	if listing.UserID == buyerID {
+		return nil, errors.New("you cannot buy your own listing")
+	}
+	if !listing.BuyNowPrice.IsPositive() {
+		return nil, errors.New("this listing cannot be bought now")
+	}
+	if listing.Status != "active" || time.Now().After(listing.EndTime) {
+		return nil, errors.New("this listing is no longer for sale")
+	}
+
+	address, err := s.addressService.SnapshotShippingAddress(buyerID, req.AddressID)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction, err := s.recordSale(listing, buyerID, models.TransactionBuyNow, listing.BuyNowPrice, address)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetTransaction(buyerID, transaction.ID)
+}
+
+// GetTransactions lists the sales a user bought or sold in
+func (s *TransactionService) GetTransactions(userID uint, query TransactionQuery, page, limit int) ([]TransactionView, int64, error) {
+	filter := repositories.TransactionFilter{Role: query.Role, Status: query.Status}
+	transactions, total, err := s.transactionRepo.FindByUser(userID, filter, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	views := make([]TransactionView, len(transactions))
+	for i := range transactions {
+		views[i] = toTransactionView(&transactions[i], userID)
+	}
+	return views, total, nil
+}
+
+// GetTransaction returns one of the user's transactions
+func (s *TransactionService) GetTransaction(userID, id uint) (*TransactionView, error) {
+	transaction, err := s.participantTransaction(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	view := toTransactionView(transaction, userID)
+	return &view, nil
+}
+
+// SetShippingAddress chooses or changes the address an unpaid purchase
+// ships to
+func (s *TransactionService) SetShippingAddress(userID, id uint, req *ShippingAddressRequest) (*TransactionView, error) {
+	transaction, err := s.buyerTransaction(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if transaction.Status != models.TransactionAwaitingPayment {
+		return nil, errors.New("the shipping address cannot be changed after payment")
+	}
+
+	address, err := s.addressService.SnapshotShippingAddress(userID, req.AddressID)
+	if err != nil {
+		return nil, err
+	}
+	transaction.ShippingAddress = *address
+
+	if err := s.update(transaction, transaction.Status); err != nil {
+		return nil, err
+	}
+	view := toTransactionView(transaction, userID)
+	return &view, nil
+}
+
+// Ship records that the seller sent the item, with tracking if available
+func (s *TransactionService) Ship(userID, id uint, req *ShipRequest) (*TransactionView, error) {
+	transaction, err := s.sellerTransaction(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	transaction.ShippedAt = &now
+	transaction.TrackingNumber = strings.TrimSpace(req.TrackingNumber)
+	transaction.TrackingURL = strings.TrimSpace(req.TrackingURL)
+	if transaction.Escrow {
+		releaseAt := now.Add(config.GetMarketplaceConfig().EscrowReleaseAfter)
+		transaction.EscrowReleaseAt = &releaseAt
+	}
+	if err := s.moveTo(transaction, models.TransactionShipped); err != nil {
+		return nil, err
+	}
+
+	content := fmt.Sprintf("The seller shipped \"%s\".", transaction.Listing.Title)
+	if transaction.EscrowReleaseAt != nil {
+		content += fmt.Sprintf(" Your payment is released to the seller when you confirm delivery, or on %s unless you open a dispute.",
+			transaction.EscrowReleaseAt.Format("January 2"))
+	}
+	s.notify(transaction.BuyerID, transaction, "Your item has shipped", content)
+
+	view := toTransactionView(transaction, userID)
+	return &view, nil
+}
+
+// ConfirmDelivery records that the buyer received the item, completing
+// the sale and releasing an escrow payment to the seller
+func (s *TransactionService) ConfirmDelivery(userID, id uint) (*TransactionView, error) {
+	transaction, err := s.buyerTransaction(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	transaction.CompletedAt = &now
+	if err := s.moveTo(transaction, models.TransactionCompleted); err != nil {
+		return nil, err
+	}
+
+	// The seller's proceeds leave escrow and are paid out in the next batch.
+	// A failed settlement is retried by the payout job.
+	if err := s.ledgerService.Settle(transaction); err != nil {
+		log.Printf("Failed to settle transaction %d in the ledger: %v", transaction.ID, err)
+	}
+
+	s.notify(transaction.SellerID, transaction, "Sale completed",
+		fmt.Sprintf("The buyer confirmed delivery of \"%s\". You can now leave feedback.", transaction.Listing.Title))
+
+	view := toTransactionView(transaction, userID)
+	return &view, nil
+}
+
+// Cancel calls off a sale. Either party can cancel before payment; once
+// paid, only the seller can, which refunds the buyer, and the buyer opens
+// a dispute instead.
+func (s *TransactionService) Cancel(userID, id uint, req *TransactionReasonRequest) (*TransactionView, error) {
+	transaction, err := s.participantTransaction(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if transaction.Status == models.TransactionDisputed {
+		return nil, errors.New("a disputed sale is closed by an admin resolving the dispute")
+	}
+	if transaction.Status == models.TransactionPaid && userID != transaction.SellerID {
+		return nil, errors.New("open a dispute to cancel a purchase you have paid for")
+	}
+	if !transaction.CanMoveTo(models.TransactionCancelled) {
+		return nil, fmt.Errorf("a %s transaction cannot be cancelled", strings.ReplaceAll(transaction.Status, "_", " "))
+	}
+
+	// The buyer gets their money back before the sale is called off
+	if transaction.Status == models.TransactionPaid {
+		if err := s.paymentService.RefundTransaction(transaction); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	transaction.CancelledAt = &now
+	transaction.CancelReason = strings.TrimSpace(req.Reason)
+	if err := s.moveTo(transaction, models.TransactionCancelled); err != nil {
+		return nil, err
+	}
+
+	// The listing did not sell after all
+	if err := s.listingRepo.UpdateStatus(transaction.ListingID, "ended"); err != nil {
+		log.Printf("Failed to end listing %d after cancelling transaction %d: %v", transaction.ListingID, transaction.ID, err)
+	}
+
+	s.notify(transaction.OtherParty(userID), transaction, "Sale cancelled",
+		fmt.Sprintf("The sale of \"%s\" was cancelled: %s", transaction.Listing.Title, transaction.CancelReason))
+
+	view := toTransactionView(transaction, userID)
+	return &view, nil
+}
+
+// ReleaseDueEscrow completes shipped escrow sales whose buyer neither
+// confirmed delivery nor opened a dispute in time, releasing the payment
+// to the seller. Disputed sales are frozen and never released this way.
+func (s *TransactionService) ReleaseDueEscrow() error {
+	transactions, err := s.transactionRepo.FindEscrowDue(time.Now())
+	if err != nil {
+		return err
+	}
+
+	for i := range transactions {
+		if err := s.releaseEscrow(&transactions[i]); err != nil {
+			log.Printf("Failed to release escrow for transaction %d: %v", transactions[i].ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *TransactionService) releaseEscrow(transaction *models.Transaction) error {
+	now := time.Now()
+	transaction.CompletedAt = &now
+	if err := s.moveTo(transaction, models.TransactionCompleted); err != nil {
+		return err
+	}
+	// A failed settlement is retried by the payout job
+	if err := s.ledgerService.Settle(transaction); err != nil {
+		log.Printf("Failed to settle transaction %d in the ledger: %v", transaction.ID, err)
+	}
+
+	s.notify(transaction.BuyerID, transaction, "Payment released",
+		fmt.Sprintf("Your payment for \"%s\" was released to the seller because the escrow period ended.", transaction.Listing.Title))
+	s.notify(transaction.SellerID, transaction, "Payment released",
+		fmt.Sprintf("The escrow payment for \"%s\" was released to you. You can now leave feedback.", transaction.Listing.Title))
+	return nil
+}
+
+// recordSale creates the transaction for a listing sold to a buyer at a
+// price and tells both parties
+func (s *TransactionService) recordSale(listing *models.Listing, buyerID uint, saleType string, price models.Money, address *models.AddressSnapshot) (*models.Transaction, error) {
+	fee, err := s.feeService.Fee(models.FeeFinalValue, listing, price)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction := &models.Transaction{
+		Type:            saleType,
+		Status:          models.TransactionAwaitingPayment,
+		ItemPrice:       price,
+		ShippingCost:    listing.ShippingPrice,
+		PlatformFee:     fee,
+		TaxAmount:       price.Add(listing.ShippingPrice).ApplyRate(config.GetMarketplaceConfig().SalesTaxRate),
+		ShippingAddress: *address,
+		ListingID:       listing.ID,
+		SellerID:        listing.UserID,
+		BuyerID:         buyerID,
+	}
+	transaction.Amount = transaction.ItemPrice.Add(transaction.ShippingCost).Add(transaction.TaxAmount)
+
+	created, err := s.transactionRepo.CreateForSale(transaction)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, errors.New("this listing is no longer for sale")
+	}
+	transaction.Listing = *listing
+
+	buyerTitle := "You won an auction"
+	if saleType == models.TransactionBuyNow {
+		buyerTitle = "Purchase confirmed"
+	}
+	s.notify(buyerID, transaction, buyerTitle,
+		fmt.Sprintf("You bought \"%s\" for %s. Pay the seller to complete your purchase.", listing.Title, transaction.Amount))
+	s.notify(listing.UserID, transaction, "Your item sold",
+		fmt.Sprintf("\"%s\" sold for %s.", listing.Title, transaction.ItemPrice))
+
+	return transaction, nil
+}
+
+// moveTo changes a transaction's status if the lifecycle allows it
+func (s *TransactionService) moveTo(transaction *models.Transaction, status string) error {
+	if !transaction.CanMoveTo(status) {
+		return fmt.Errorf("a %s transaction cannot be marked %s", strings.ReplaceAll(transaction.Status, "_", " "), strings.ReplaceAll(status, "_", " "))
+	}
+	from := transaction.Status
+	transaction.Status = status
+	return s.update(transaction, from)
+}
+
+func (s *TransactionService) update(transaction *models.Transaction, fromStatus string) error {
+	updated, err := s.transactionRepo.Update(transaction, fromStatus)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return errors.New("this transaction was changed by someone else; reload it and try again")
+	}
+	return nil
+}
+
+func (s *TransactionService) notify(userID uint, transaction *models.Transaction, title, content string) {
+	s.notificationService.Notify(userID, models.NotificationTransaction, title, content, &transaction.ID)
+}
+
+// participantTransaction loads a transaction the user bought or sold in
+func (s *TransactionService) participantTransaction(userID, id uint) (*models.Transaction, error) {
+	transaction, err := s.transactionRepo.FindByID(id)
+	if err != nil || !transaction.HasParticipant(userID) {
+		return nil, ErrTransactionNotFound
+	}
+	return transaction, nil
+}
+
+func (s *TransactionService) buyerTransaction(userID, id uint) (*models.Transaction, error) {
+	transaction, err := s.participantTransaction(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if transaction.BuyerID != userID {
+		return nil, ErrNotTransactionBuyer
+	}
+	return transaction, nil
+}
+
+func (s *TransactionService) sellerTransaction(userID, id uint) (*models.Transaction, error) {
+	transaction, err := s.participantTransaction(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if transaction.SellerID != userID {
+		return nil, ErrNotTransactionSeller
+	}
+	return transaction, nil
+}
+
+func toTransactionView(t *models.Transaction, viewerID uint) TransactionView {
+	view := TransactionView{
+		ID:              t.ID,
+		Type:            t.Type,
+		Status:          t.Status,
+		Role:            "buyer",
+		ItemPrice:       t.ItemPrice,
+		ShippingCost:    t.ShippingCost,
+		TaxAmount:       t.TaxAmount,
+		Amount:          t.Amount,
+		Escrow:          t.Escrow,
+		EscrowReleaseAt: t.EscrowReleaseAt,
+		Funds:           t.FundsStatus(),
+		TrackingNumber:  t.TrackingNumber,
+		TrackingURL:     t.TrackingURL,
+		PaidAt:          t.PaidAt,
+		ShippedAt:       t.ShippedAt,
+		CompletedAt:     t.CompletedAt,
+		CancelledAt:     t.CancelledAt,
+		CancelReason:    t.CancelReason,
+		DisputedAt:      t.DisputedAt,
+		DisputeReason:   t.DisputeReason,
+		CreatedAt:       t.CreatedAt,
+		Listing:         ListingSummary{ID: t.Listing.ID, Title: t.Listing.Title},
+		Buyer:           toUserSummary(&t.Buyer),
+		Seller:          toUserSummary(&t.Seller),
+	}
+	if t.HasShippingAddress() {
+		address := t.ShippingAddress
+		view.ShippingAddress = &address
+	}
+	if viewerID == t.SellerID {
+		fee := t.PlatformFee
+		proceeds := t.SellerProceeds()
+		view.Role = "seller"
+		view.PlatformFee = &fee
+		view.SellerProceeds = &proceeds
+	}
+	return view
+}
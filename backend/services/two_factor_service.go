@@ -0,0 +1,288 @@
+// services/two_factor_service.go
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/config"
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/repositories"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const recoveryCodeCount = 10
+
+// Unambiguous characters for recovery codes (no 0/O, 1/I)
+const recoveryCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+var ErrInvalidTwoFactorCode = errors.New("invalid authentication code")
+
+type TwoFactorService struct {
+	userRepo            *repositories.UserRepository
+	recoveryCodeRepo    *repositories.RecoveryCodeRepository
+	exchangeRateService *ExchangeRateService
+}
+
+func NewTwoFactorService(userRepo *repositories.UserRepository, recoveryCodeRepo *repositories.RecoveryCodeRepository, exchangeRateService *ExchangeRateService) *TwoFactorService {
+	return &TwoFactorService{
+		userRepo:            userRepo,
+		recoveryCodeRepo:    recoveryCodeRepo,
+		exchangeRateService: exchangeRateService,
+	}
+}
+
+// TwoFactorSetupResponse carries what an authenticator app needs to enrol
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// TwoFactorCodeRequest carries a TOTP or recovery code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// DisableTwoFactorRequest requires both the password and a current code
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// TwoFactorStatus describes a user's 2FA state
+type TwoFactorStatus struct {
+	Enabled                bool  `json:"enabled"`
+	Required               bool  `json:"required"`
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
+
+// GetStatus returns whether 2FA is enabled and required for a user
+func (s *TwoFactorService) GetStatus(userID uint) (*TwoFactorStatus, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	remaining, err := s.recoveryCodeRepo.CountUnused(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorStatus{
+		Enabled:                user.TwoFactorEnabled,
+		Required:               s.IsRequired(user),
+		RecoveryCodesRemaining: remaining,
+	}, nil
+}
+
+// Setup generates a new secret for enrolment. 2FA is not active until the
+// user confirms a code with Enable.
+func (s *TwoFactorService) Setup(userID uint) (*TwoFactorSetupResponse, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if user.TwoFactorEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	user.TwoFactorSecret = secret
+	user.TwoFactorLastStep = 0
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: totpProvisioningURI(config.GetSecurityConfig().TOTPIssuer, user.Email, secret),
+	}, nil
+}
+
+// Enable confirms enrolment with a code from the authenticator app and
+// returns a fresh set of recovery codes. The codes are only shown once.
+func (s *TwoFactorService) Enable(userID uint, code string) ([]string, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if user.TwoFactorEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+	if user.TwoFactorSecret == "" {
+		return nil, errors.New("two-factor setup has not been started")
+	}
+
+	step, ok := verifyTOTP(user.TwoFactorSecret, code, user.TwoFactorLastStep, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	user.TwoFactorEnabled = true
+	user.TwoFactorLastStep = step
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	return s.issueRecoveryCodes(user.ID)
+}
+
+// Disable turns 2FA off after re-checking the password and a current code
+func (s *TwoFactorService) Disable(userID uint, req *DisableTwoFactorRequest) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if !user.TwoFactorEnabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	if s.IsRequired(user) {
+		return errors.New("two-factor authentication is required for your account")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		return errors.New("current password is incorrect")
+	}
+
+	ok, err := s.VerifyCode(user, req.Code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+
+	user.TwoFactorEnabled = false
+	user.TwoFactorSecret = ""
+	user.TwoFactorLastStep = 0
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+
+	return s.recoveryCodeRepo.DeleteForUser(user.ID)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after checking a current code
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if !user.TwoFactorEnabled {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+
+	step, ok := verifyTOTP(user.TwoFactorSecret, code, user.TwoFactorLastStep, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	user.TwoFactorLastStep = step
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	return s.issueRecoveryCodes(user.ID)
+}
+
+// VerifyCode accepts either a current TOTP code or an unused recovery code
+func (s *TwoFactorService) VerifyCode(user *models.User, code string) (bool, error) {
+	if step, ok := verifyTOTP(user.TwoFactorSecret, code, user.TwoFactorLastStep, time.Now()); ok {
+		user.TwoFactorLastStep = step
+		if err := s.userRepo.Update(user); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	return s.recoveryCodeRepo.Consume(user.ID, hashToken(normalizeRecoveryCode(code)))
+}
+
+// IsRequired applies the 2FA policy: admins and sellers whose sales exceed
+// the configured threshold must use two-factor authentication
+func (s *TwoFactorService) IsRequired(user *models.User) bool {
+	policy := config.GetSecurityConfig()
+
+	if user.IsAdmin && policy.RequireTwoFactorForAdmins {
+		return true
+	}
+
+	if policy.TwoFactorSalesThreshold.IsPositive() && s.salesVolume(user.ID, policy.TwoFactorSalesThreshold.Currency).Cmp(policy.TwoFactorSalesThreshold) >= 0 {
+		return true
+	}
+
+	return false
+}
+
+// salesVolume totals a seller's sales in one currency. Sales in other
+// currencies are converted at the current rate; any that cannot be are
+// left out.
+func (s *TwoFactorService) salesVolume(userID uint, currency string) models.Money {
+	volume := models.NewMoney(0, currency)
+	totals, err := s.userRepo.GetSalesVolume(userID)
+	if err != nil {
+		return volume
+	}
+	rates, err := s.exchangeRateService.Rates()
+	if err != nil {
+		return volume
+	}
+
+	for _, total := range totals {
+		if converted, ok := rates.Convert(total, currency); ok {
+			volume = volume.Add(converted)
+		}
+	}
+	return volume
+}
+
+// issueRecoveryCodes generates, stores and returns a new set of recovery codes
+func (s *TwoFactorService) issueRecoveryCodes(userID uint) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		hashes[i] = hashToken(normalizeRecoveryCode(code))
+	}
+
+	if err := s.recoveryCodeRepo.ReplaceForUser(userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// generateRecoveryCode returns a random code formatted as XXXXX-XXXXX
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		// The alphabet has 32 characters, so masking keeps the distribution uniform
+		b[i] = recoveryCodeAlphabet[b[i]&31]
+	}
+	return string(b[:5]) + "-" + string(b[5:]), nil
+}
+
+// normalizeRecoveryCode strips formatting so users can type codes loosely
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
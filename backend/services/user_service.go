@@ -3,6 +3,12 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"strings"
+	"time"
+
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/jimsyyap/auctions/backend/models"
@@ -10,36 +16,89 @@ import (
 )
 
 type UserService struct {
-	userRepo *repositories.UserRepository
+	userRepo          *repositories.UserRepository
+	imageService      *ImageService
+	reputationService *ReputationService
+	authService       *AuthService
 }
 
-func NewUserService(userRepo *repositories.UserRepository) *UserService {
+func NewUserService(userRepo *repositories.UserRepository, imageService *ImageService, reputationService *ReputationService, authService *AuthService) *UserService {
 	return &UserService{
-		userRepo: userRepo,
+		userRepo:          userRepo,
+		imageService:      imageService,
+		reputationService: reputationService,
+		authService:       authService,
 	}
 }
 
-// UserProfile represents a user's public profile
-type UserProfile struct {
-	ID          uint   `json:"id"`
-	Username    string `json:"username"`
-	Email       string `json:"email"`
-	FirstName   string `json:"first_name,omitempty"`
-	LastName    string `json:"last_name,omitempty"`
-	PhoneNumber string `json:"phone_number,omitempty"`
-	Address     string `json:"address,omitempty"`
-	Rating      float64 `json:"rating"`
+// Number of active listings shown on a public profile's storefront
+const storefrontSize = 12
+
+// PrivateProfile is the logged-in user's own profile, including contact
+// details that are never shown to other users
+type PrivateProfile struct {
+	ID               uint        `json:"id"`
+	Username         string      `json:"username"`
+	Email            string      `json:"email"`
+	FirstName        string      `json:"first_name,omitempty"`
+	LastName         string      `json:"last_name,omitempty"`
+	PhoneNumber      string      `json:"phone_number,omitempty"`
+	Address          string      `json:"address,omitempty"`
+	Bio              string      `json:"bio,omitempty"`
+	AvatarURL        string      `json:"avatar_url,omitempty"`
+	MemberSince      time.Time   `json:"member_since"`
+	IsEmailVerified  bool        `json:"is_email_verified"`
+	IsVerifiedSeller bool        `json:"is_verified_seller"`
+	Rating           float64     `json:"rating"` // smoothed seller score
+	Reputation       *Reputation `json:"reputation"`
+}
+
+// PublicProfile is what anyone can see about a user
+type PublicProfile struct {
+	ID               uint        `json:"id"`
+	Username         string      `json:"username"`
+	Bio              string      `json:"bio,omitempty"`
+	AvatarURL        string      `json:"avatar_url,omitempty"`
+	MemberSince      time.Time   `json:"member_since"`
+	IsVerifiedSeller bool        `json:"is_verified_seller"`
+	Reputation       *Reputation `json:"reputation"`
+	Storefront       Storefront  `json:"storefront"`
+}
+
+// Storefront shows a seller's newest active listings
+type Storefront struct {
+	Listings []models.Listing `json:"listings"`
+	Total    int64            `json:"total"`
+}
+
+// UserSummary is the public part of a user shown next to their activity,
+// such as feedback and messages
+type UserSummary struct {
+	ID        uint   `json:"id"`
+	Username  string `json:"username"`
+	AvatarURL string `json:"avatar_url,omitempty"`
+}
+
+// ListingSummary identifies the listing an activity is about
+type ListingSummary struct {
+	ID    uint   `json:"id"`
+	Title string `json:"title"`
+}
+
+func toUserSummary(u *models.User) UserSummary {
+	return UserSummary{ID: u.ID, Username: u.Username, AvatarURL: u.ProfileImageURL}
 }
 
 // UpdateProfileRequest represents the data for updating a user profile
 type UpdateProfileRequest struct {
-	Email       string `json:"email"`
-	FirstName   string `json:"first_name"`
-	LastName    string `json:"last_name"`
-	PhoneNumber string `json:"phone_number"`
-	Address     string `json:"address"`
-	Password    string `json:"password,omitempty"`
-	NewPassword string `json:"new_password,omitempty"`
+	Email       string  `json:"email"`
+	FirstName   string  `json:"first_name"`
+	LastName    string  `json:"last_name"`
+	PhoneNumber string  `json:"phone_number"`
+	Address     string  `json:"address"`
+	Bio         *string `json:"bio" binding:"omitempty,max=1000"`
+	Password    string  `json:"password,omitempty"`
+	NewPassword string  `json:"new_password,omitempty"`
 }
 
 // GetUserByID retrieves a user by ID
@@ -53,29 +112,113 @@ func (s *UserService) GetUserByID(id uint) (*models.User, error) {
 	return user, nil
 }
 
-// GetUserProfile creates a safe user profile from a user model
-func (s *UserService) GetUserProfile(id uint) (*UserProfile, error) {
+// GetPrivateProfile returns the logged-in user's own profile
+func (s *UserService) GetPrivateProfile(id uint) (*PrivateProfile, error) {
+	user, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	reputation, err := s.reputationService.GetReputation(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PrivateProfile{
+		ID:               user.ID,
+		Username:         user.Username,
+		Email:            user.Email,
+		FirstName:        user.FirstName,
+		LastName:         user.LastName,
+		PhoneNumber:      user.PhoneNumber,
+		Address:          user.Address,
+		Bio:              user.Bio,
+		AvatarURL:        user.ProfileImageURL,
+		MemberSince:      user.CreatedAt,
+		IsEmailVerified:  user.IsEmailVerified,
+		IsVerifiedSeller: user.IsSellerVerified,
+		Rating:           reputation.Seller.Score,
+		Reputation:       reputation,
+	}, nil
+}
+
+// GetPublicProfile returns the profile other users see, with the seller's
+// storefront of active listings
+func (s *UserService) GetPublicProfile(id uint) (*PublicProfile, error) {
 	user, err := s.userRepo.FindByID(id)
 	if err != nil {
 		return nil, err
 	}
 
-	// TODO: Calculate user rating
-	// This would typically be done by averaging all ratings received by the user
-	rating := 0.0
+	reputation, err := s.reputationService.GetReputation(id)
+	if err != nil {
+		return nil, err
+	}
+
+	listings, total, err := s.userRepo.GetActiveListings(id, 1, storefrontSize)
+	if err != nil {
+		return nil, err
+	}
 
-	return &UserProfile{
-		ID:          user.ID,
-		Username:    user.Username,
-		Email:       user.Email,
-		FirstName:   user.FirstName,
-		LastName:    user.LastName,
-		PhoneNumber: user.PhoneNumber,
-		Address:     user.Address,
-		Rating:      rating,
+	return &PublicProfile{
+		ID:               user.ID,
+		Username:         user.Username,
+		Bio:              user.Bio,
+		AvatarURL:        user.ProfileImageURL,
+		MemberSince:      user.CreatedAt,
+		IsVerifiedSeller: user.IsSellerVerified,
+		Reputation:       reputation,
+		Storefront:       Storefront{Listings: listings, Total: total},
 	}, nil
 }
 
+// UpdateAvatar replaces the user's profile picture
+func (s *UserService) UpdateAvatar(userID uint, file *multipart.FileHeader) (string, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return "", err
+	}
+
+	stored, err := s.imageService.Store(fmt.Sprintf("avatars/%d", userID), file)
+	if err != nil {
+		return "", err
+	}
+
+	oldKey := user.ProfileImageKey
+	user.ProfileImageKey = stored.Key
+	user.ProfileImageURL = stored.URL
+	if err := s.userRepo.Update(user); err != nil {
+		s.imageService.Delete(stored.Key)
+		return "", err
+	}
+
+	if oldKey != "" {
+		if err := s.imageService.Delete(oldKey); err != nil {
+			log.Printf("Failed to delete old avatar %s: %v", oldKey, err)
+		}
+	}
+	return stored.URL, nil
+}
+
+// DeleteAvatar removes the user's profile picture
+func (s *UserService) DeleteAvatar(userID uint) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.ProfileImageKey == "" {
+		return nil
+	}
+
+	oldKey := user.ProfileImageKey
+	user.ProfileImageKey = ""
+	user.ProfileImageURL = ""
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+	return s.imageService.Delete(oldKey)
+}
+
 // UpdateProfile updates a user's profile information
 func (s *UserService) UpdateProfile(userID uint, req *UpdateProfileRequest) error {
 	user, err := s.userRepo.FindByID(userID)
@@ -84,12 +227,16 @@ func (s *UserService) UpdateProfile(userID uint, req *UpdateProfileRequest) erro
 	}
 
 	// If changing email, check if it's already used
+	emailChanged := false
 	if req.Email != "" && req.Email != user.Email {
 		existingUser, err := s.userRepo.FindByEmail(req.Email)
 		if err == nil && existingUser.ID > 0 && existingUser.ID != userID {
 			return errors.New("email already in use")
 		}
 		user.Email = req.Email
+		// The new address has to be confirmed again
+		user.IsEmailVerified = false
+		emailChanged = true
 	}
 
 	// Update user fields if provided
@@ -105,6 +252,9 @@ func (s *UserService) UpdateProfile(userID uint, req *UpdateProfileRequest) erro
 	if req.Address != "" {
 		user.Address = req.Address
 	}
+	if req.Bio != nil {
+		user.Bio = strings.TrimSpace(*req.Bio)
+	}
 
 	// Change password if requested
 	if req.Password != "" && req.NewPassword != "" {
@@ -128,7 +278,17 @@ func (s *UserService) UpdateProfile(userID uint, req *UpdateProfileRequest) erro
 		user.Password = string(hashedPassword)
 	}
 
-	return s.userRepo.Update(user)
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+
+	// Send the confirmation link to the new address straight away
+	if emailChanged {
+		if err := s.authService.ResendVerificationEmail(user.ID); err != nil {
+			log.Printf("Failed to send a verification email to user %d: %v", user.ID, err)
+		}
+	}
+	return nil
 }
 
 // GetUserListings gets all listings by a specific user
@@ -0,0 +1,283 @@
+// services/verification_service.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jimsyyap/auctions/backend/models"
+	"github.com/jimsyyap/auctions/backend/repositories"
+	"github.com/jimsyyap/auctions/backend/storage"
+)
+
+const (
+	maxVerificationDocuments    = 5
+	maxVerificationDocumentSize = 10 << 20 // 10 MB
+)
+
+// Document formats accepted for verification, by detected content type
+var allowedDocumentTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+type VerificationService struct {
+	verificationRepo    *repositories.VerificationRepository
+	roleRepo            *repositories.RoleRepository
+	policyService       *PolicyService
+	notificationService *NotificationService
+	documentStorage     storage.Storage
+}
+
+func NewVerificationService(verificationRepo *repositories.VerificationRepository, roleRepo *repositories.RoleRepository, policyService *PolicyService, notificationService *NotificationService, documentStorage storage.Storage) *VerificationService {
+	return &VerificationService{
+		verificationRepo:    verificationRepo,
+		roleRepo:            roleRepo,
+		policyService:       policyService,
+		notificationService: notificationService,
+		documentStorage:     documentStorage,
+	}
+}
+
+// SubmitVerificationRequest describes the documents being submitted
+type SubmitVerificationRequest struct {
+	VerificationType string `form:"verification_type" binding:"required"`
+	DocumentType     string `form:"document_type" binding:"required"`
+}
+
+// ReviewVerificationRequest carries the admin's review notes
+type ReviewVerificationRequest struct {
+	Notes string `json:"notes"`
+}
+
+// Submit stores the uploaded documents privately and opens a pending request
+func (s *VerificationService) Submit(userID uint, req *SubmitVerificationRequest, files []*multipart.FileHeader) (*models.UserVerification, error) {
+	switch req.VerificationType {
+	case models.VerificationTypeSeller, models.VerificationTypeIdentity, models.VerificationTypeAddress:
+	default:
+		return nil, errors.New("verification type must be seller, identity or address")
+	}
+
+	if len(files) == 0 {
+		return nil, errors.New("at least one document is required")
+	}
+	if len(files) > maxVerificationDocuments {
+		return nil, fmt.Errorf("at most %d documents can be submitted", maxVerificationDocuments)
+	}
+
+	pending, err := s.verificationRepo.HasPending(userID, req.VerificationType)
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return nil, errors.New("you already have a pending verification request")
+	}
+
+	var documents []models.VerificationDocument
+	for _, file := range files {
+		doc, err := s.storeDocument(userID, req.DocumentType, file)
+		if err != nil {
+			// Don't leave orphaned files behind
+			for _, stored := range documents {
+				s.documentStorage.Delete(stored.StorageKey)
+			}
+			return nil, err
+		}
+		documents = append(documents, *doc)
+	}
+
+	verification := &models.UserVerification{
+		UserID:           userID,
+		VerificationType: req.VerificationType,
+		Status:           models.VerificationPending,
+		SubmittedAt:      time.Now(),
+		Documents:        documents,
+	}
+	if err := s.verificationRepo.Create(verification); err != nil {
+		return nil, err
+	}
+
+	return verification, nil
+}
+
+// GetUserVerifications lists a user's own verification requests
+func (s *VerificationService) GetUserVerifications(userID uint) ([]models.UserVerification, error) {
+	return s.verificationRepo.FindByUser(userID)
+}
+
+// GetVerifications lists requests for review, oldest first
+func (s *VerificationService) GetVerifications(status string, page, limit int) ([]models.UserVerification, int64, error) {
+	verifications, total, err := s.verificationRepo.FindByStatus(status, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range verifications {
+		verifications[i].User.Password = ""
+	}
+	return verifications, total, nil
+}
+
+// GetVerification returns a request with its documents
+func (s *VerificationService) GetVerification(id uint) (*models.UserVerification, error) {
+	verification, err := s.verificationRepo.FindByID(id)
+	if err != nil {
+		return nil, errors.New("verification request not found")
+	}
+	verification.User.Password = ""
+	if verification.ReviewedBy != nil {
+		verification.ReviewedBy.Password = ""
+	}
+	return verification, nil
+}
+
+// OpenDocument streams a document from private storage for review
+func (s *VerificationService) OpenDocument(verificationID, documentID uint) (io.ReadCloser, *models.VerificationDocument, error) {
+	verification, err := s.verificationRepo.FindByID(verificationID)
+	if err != nil {
+		return nil, nil, errors.New("verification request not found")
+	}
+
+	for _, doc := range verification.Documents {
+		if doc.ID == documentID {
+			r, err := s.documentStorage.Open(doc.StorageKey)
+			if err != nil {
+				return nil, nil, err
+			}
+			return r, &doc, nil
+		}
+	}
+
+	return nil, nil, errors.New("document not found")
+}
+
+// Approve accepts a request. Approved seller verifications mark the user as
+// a verified seller and grant the seller role.
+func (s *VerificationService) Approve(reviewerID, id uint, req *ReviewVerificationRequest) (*models.UserVerification, error) {
+	verification, err := s.startReview(reviewerID, id, req)
+	if err != nil {
+		return nil, err
+	}
+	verification.Status = models.VerificationApproved
+
+	sellerRole, err := s.roleRepo.FindByName(models.RoleSeller)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verificationRepo.Approve(verification, sellerRole); err != nil {
+		return nil, err
+	}
+	s.policyService.Invalidate(verification.UserID)
+
+	s.notificationService.Notify(verification.UserID, models.NotificationVerification,
+		"Your verification was approved",
+		fmt.Sprintf("Your %s verification has been approved.%s", verification.VerificationType, formatReviewNotes(req.Notes)),
+		&verification.ID)
+
+	return verification, nil
+}
+
+// Reject declines a request. Notes are required so the user knows what to fix.
+func (s *VerificationService) Reject(reviewerID, id uint, req *ReviewVerificationRequest) (*models.UserVerification, error) {
+	if strings.TrimSpace(req.Notes) == "" {
+		return nil, errors.New("notes are required when rejecting a request")
+	}
+
+	verification, err := s.startReview(reviewerID, id, req)
+	if err != nil {
+		return nil, err
+	}
+	verification.Status = models.VerificationRejected
+
+	if err := s.verificationRepo.Update(verification); err != nil {
+		return nil, err
+	}
+
+	s.notificationService.Notify(verification.UserID, models.NotificationVerification,
+		"Your verification was not approved",
+		fmt.Sprintf("Your %s verification could not be approved.%s You can submit a new request at any time.", verification.VerificationType, formatReviewNotes(req.Notes)),
+		&verification.ID)
+
+	return verification, nil
+}
+
+// startReview loads a pending request and fills in the review fields
+func (s *VerificationService) startReview(reviewerID, id uint, req *ReviewVerificationRequest) (*models.UserVerification, error) {
+	verification, err := s.verificationRepo.FindByID(id)
+	if err != nil {
+		return nil, errors.New("verification request not found")
+	}
+
+	if verification.Status != models.VerificationPending {
+		return nil, errors.New("verification request has already been reviewed")
+	}
+	if verification.UserID == reviewerID {
+		return nil, errors.New("you cannot review your own verification request")
+	}
+
+	now := time.Now()
+	verification.User.Password = ""
+	verification.ReviewedByID = &reviewerID
+	verification.ReviewNotes = req.Notes
+	verification.ReviewedAt = &now
+	return verification, nil
+}
+
+// storeDocument validates an upload and saves it to private storage
+func (s *VerificationService) storeDocument(userID uint, documentType string, file *multipart.FileHeader) (*models.VerificationDocument, error) {
+	if file.Size > maxVerificationDocumentSize {
+		return nil, fmt.Errorf("%s is larger than 10 MB", file.Filename)
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// Trust the file's content, not its name or the client's header
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	contentType := http.DetectContentType(head[:n])
+	ext, ok := allowedDocumentTypes[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%s must be a JPEG, PNG or PDF file", file.Filename)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	name, err := generateOpaqueToken(16)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("verifications/%d/%s%s", userID, name, ext)
+	if err := s.documentStorage.Save(key, f); err != nil {
+		return nil, err
+	}
+
+	return &models.VerificationDocument{
+		DocumentType: documentType,
+		StorageKey:   key,
+		OriginalName: filepath.Base(file.Filename),
+		ContentType:  contentType,
+		Size:         file.Size,
+	}, nil
+}
+
+// formatReviewNotes appends reviewer notes to a notification message
+func formatReviewNotes(notes string) string {
+	if strings.TrimSpace(notes) == "" {
+		return ""
+	}
+	return "\n\nReviewer notes: " + notes
+}
@@ -0,0 +1,100 @@
+// storage/storage.go
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// Storage stores files under slash-separated keys such as
+// "verifications/12/passport.pdf"
+type Storage interface {
+	Save(key string, r io.Reader) error
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+	// URL returns the public URL of a file, or "" for private storage
+	URL(key string) string
+}
+
+// LocalStorage keeps files on the local filesystem
+type LocalStorage struct {
+	root    string
+	urlPath string
+}
+
+// NewLocalStorage creates storage rooted at dir. Files are public when
+// urlPath is set (the router must serve dir at that path) and private otherwise.
+func NewLocalStorage(dir, urlPath string) *LocalStorage {
+	return &LocalStorage{
+		root:    dir,
+		urlPath: strings.TrimSuffix(urlPath, "/"),
+	}
+}
+
+func (s *LocalStorage) Save(key string, r io.Reader) error {
+	fullPath, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o750); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(fullPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(fullPath)
+		return err
+	}
+	return f.Close()
+}
+
+func (s *LocalStorage) Open(key string) (io.ReadCloser, error) {
+	fullPath, err := s.resolve(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(fullPath)
+}
+
+func (s *LocalStorage) Delete(key string) error {
+	fullPath, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) URL(key string) string {
+	if s.urlPath == "" {
+		return ""
+	}
+	return s.urlPath + "/" + key
+}
+
+// Root returns the directory files are stored in
+func (s *LocalStorage) Root() string {
+	return s.root
+}
+
+// resolve maps a key to a path inside the root, rejecting traversal
+func (s *LocalStorage) resolve(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || clean != "/"+key {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.root, filepath.FromSlash(clean)), nil
+}